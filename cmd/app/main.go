@@ -3,17 +3,43 @@
 // @description REST service for aggregating user subscription costs
 // @host localhost:8080
 // @BasePath /api
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description JWT bearer token, "Bearer <token>"
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 package main
 
 import (
 	"context"
 	"log"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/config"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/graphqlapi"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/grpcapi"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/http/handlers"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/http/router"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/ratelimit"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/server"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/stream"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/webhook"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
 
 	_ "github.com/DevSchmied/subscription-aggregation-service/docs"
 )
@@ -21,38 +47,223 @@ import (
 func main() {
 	// Load application configuration (env)
 	cfg, err := config.Load()
-	_ = cfg
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	log.Println("App started")
+	// Structured logging for the app and the standard logger
+	logger, err := logging.New(os.Stdout, cfg.LogFormat, cfg.LogLevel)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	// Cancel on SIGINT/SIGTERM to start graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Run the migrate subcommand instead of the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(ctx, cfg, os.Args[2:]); err != nil {
+			fatal("migrate", err)
+		}
+		return
+	}
 
-	ctx := context.Background()
+	slog.Info("App started")
+
+	// Build trace exporter (nil when tracing is disabled); the pool
+	// records statements through the global provider
+	tracer, err := newTracerProvider(ctx, cfg)
+	if err != nil {
+		fatal("configure tracing", err)
+	}
+	var requestTracer trace.TracerProvider
+	if tracer != nil {
+		otel.SetTracerProvider(tracer)
+		requestTracer = tracer
+	}
 
 	// Initialize PostgreSQL connection pool
 	pool, err := postgres.NewPool(ctx, cfg)
 	if err != nil {
-		log.Fatal(err)
+		fatal("connect to database", err)
+	}
+
+	// Bring the schema up to date before serving, as the schema owner
+	if cfg.DBAutoMigrate {
+		if err := runMigrate(ctx, cfg, []string{"up"}); err != nil {
+			fatal("apply migrations", err)
+		}
+	}
+
+	// Register runtime, pool, query and business metrics
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	postgres.RegisterPoolMetrics(reg, pool)
+	postgres.RegisterSubscriptionMetrics(reg, pool)
+
+	// Create subscription repository with query timing
+	repo := postgres.NewSubscriptionRepo(pool).WithObserver(postgres.QueryMetrics(reg))
+
+	// Deliver subscription events to registered webhooks in the background
+	webhookRepo := postgres.NewWebhookRepo(pool)
+	webhooks := webhook.New(webhookRepo, webhook.Options{
+		Interval:             cfg.WebhookPollInterval,
+		Timeout:              cfg.WebhookTimeout,
+		MaxAttempts:          cfg.WebhookMaxAttempts,
+		AllowPrivateNetworks: cfg.WebhookAllowPrivateNetworks,
+	})
+
+	// Relay committed subscription events to the configured sink and webhooks
+	outboxSink, err := outbox.NewSinkFromConfig(cfg)
+	if err != nil {
+		fatal("configure outbox sink", err)
+	}
+	sinks := outbox.Fanout{webhooks}
+	if outboxSink != nil {
+		sinks = append(sinks, outboxSink)
 	}
-	defer pool.Close()
+	relay := outbox.NewRelay(postgres.NewOutboxRepo(pool), sinks, outbox.Options{
+		Interval:  cfg.OutboxPollInterval,
+		Retention: cfg.OutboxRetention,
+	})
+
+	// Push committed changes of every instance to event stream clients
+	streamHub := stream.NewHub(postgres.NewOutboxRepo(pool), postgres.NewOutboxListener(pool), stream.Options{
+		Backlog: cfg.StreamBacklog,
+	})
 
-	// Create subscription repository
-	repo := postgres.NewSubscriptionRepo(pool)
+	// Business rules on top of the repository
+	subSvc := service.NewSubscriptionService(repo).WithOverlapConstraint(cfg.DBOverlapConstraint)
+	aggSvc := service.NewAggregationService(repo)
 
 	// Initialize HTTP handlers with DB timeout
-	subH := handlers.NewSubscriptionsHandler(repo, 3*time.Second)
-	aggH := handlers.NewAggregationHandler(repo, 3*time.Second)
+	subH := handlers.NewSubscriptionsHandler(subSvc, 3*time.Second)
+	aggH := handlers.NewAggregationHandler(aggSvc, 3*time.Second)
+	auditH := handlers.NewAuditHandler(postgres.NewAuditRepo(pool), 3*time.Second)
+	gqlH := handlers.NewGraphQLHandler(graphqlapi.NewServer(subSvc, aggSvc), 3*time.Second)
+	webhookH := handlers.NewWebhooksHandler(webhookRepo, 3*time.Second, cfg.WebhookAllowPrivateNetworks)
+	streamH := handlers.NewStreamHandler(streamHub, cfg.StreamHeartbeat, 3*time.Second)
+
+	// Readiness checks: connectivity with pool stats, schema version
+	healthH := handlers.NewHealthHandler(2*time.Second,
+		handlers.HealthCheck{
+			Name: "postgres",
+			Check: func(ctx context.Context) (any, error) {
+				return postgres.Stats(pool), pool.Ping(ctx)
+			},
+		},
+		handlers.HealthCheck{
+			Name: "migrations",
+			Check: func(ctx context.Context) (any, error) {
+				return postgres.CheckSchemaVersion(ctx, pool)
+			},
+		},
+	)
+
+	// Load JWT verification keys (nil when auth is not configured)
+	verifier, err := auth.NewVerifierFromConfig(cfg)
+	if err != nil {
+		fatal("load JWT keys", err)
+	}
+
+	// Build rate limiter (nil when no limits are configured)
+	limiter, err := ratelimit.NewFromConfig(cfg)
+	if err != nil {
+		fatal("configure rate limits", err)
+	}
 
 	// Build HTTP router and inject dependencies
 	rtr := router.NewRouter(router.Dependencies{
-		Subscriptions: subH,
-		Aggregation:   aggH,
+		Subscriptions:   subH,
+		Aggregation:     aggH,
+		Audit:           auditH,
+		GraphQL:         gqlH,
+		Webhooks:        webhookH,
+		Stream:          streamH,
+		Health:          healthH,
+		Auth:            verifier,
+		APIKeys:         postgres.NewAPIKeyRepo(pool),
+		AllowAnonymous:  !cfg.AuthRequired,
+		DefaultTenantID: cfg.DefaultTenantID,
+		TrustedProxies:  cfg.TrustedProxies,
+		RateLimiter:     limiter,
+		Metrics:         reg,
+		Tracer:          requestTracer,
 	})
 
-	// Start HTTP server
-	log.Println("HTTP server started on port", cfg.AppPort)
-	if err := rtr.Run(":" + cfg.AppPort); err != nil {
-		log.Fatal(err)
+	// gRPC API on its own port, backed by the same services
+	grpcSrv := grpcapi.New(grpcapi.Dependencies{
+		Subscriptions:   subSvc,
+		Aggregation:     aggSvc,
+		Auth:            verifier,
+		APIKeys:         postgres.NewAPIKeyRepo(pool),
+		AllowAnonymous:  !cfg.AuthRequired,
+		DefaultTenantID: cfg.DefaultTenantID,
+		DBTimeout:       3 * time.Second,
+	})
+	grpcLn, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		fatal("listen for gRPC", err)
+	}
+	go func() {
+		slog.Info("gRPC server started", "port", cfg.GRPCPort)
+		if err := grpcSrv.Serve(grpcLn); err != nil {
+			fatal("gRPC server failed", err)
+		}
+	}()
+
+	srv := server.New(server.Config{
+		Addr:              ":" + cfg.AppPort,
+		ReadHeaderTimeout: cfg.HTTPReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTPReadTimeout,
+		WriteTimeout:      cfg.HTTPWriteTimeout,
+		IdleTimeout:       cfg.HTTPIdleTimeout,
+		DrainDelay:        cfg.ShutdownDrainDelay,
+		ShutdownTimeout:   cfg.ShutdownTimeout,
+	}, rtr)
+
+	// Fail readiness as soon as shutdown starts
+	srv.OnDrain(healthH.SetShuttingDown)
+	srv.OnDrain(grpcSrv.SetShuttingDown)
+
+	// End open event streams, they would hold up draining
+	srv.OnDrain(streamHub.Close)
+
+	// Finish in-flight gRPC calls before their dependencies go away
+	srv.OnShutdown("gRPC server", grpcSrv.Shutdown)
+
+	// Stop listening for streamed changes
+	srv.OnShutdown("event stream", streamHub.Shutdown)
+
+	// Stop relaying events, then delivering the queued webhooks
+	srv.OnShutdown("outbox relay", relay.Shutdown)
+	srv.OnShutdown("webhooks", webhooks.Shutdown)
+
+	// Export remaining spans once requests are done
+	if tracer != nil {
+		srv.OnShutdown("tracer", tracer.Shutdown)
 	}
+
+	// Close the pool last, after requests and workers are done
+	srv.OnShutdown("postgres pool", func(context.Context) error {
+		pool.Close()
+		return nil
+	})
+
+	// Start HTTP server, blocks until shutdown completes
+	slog.Info("HTTP server started", "port", cfg.AppPort)
+	if err := srv.Run(ctx); err != nil {
+		fatal("HTTP server failed", err)
+	}
+}
+
+// fatal logs an error and exits.
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
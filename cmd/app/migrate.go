@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/config"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
+)
+
+const migrateUsage = "usage: app migrate up|down|status|goto N"
+
+// runMigrate runs the migrate subcommand against the configured database,
+// connected as the schema owner (see config.Config.ForMigrations).
+func runMigrate(ctx context.Context, cfg *config.Config, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	pool, err := postgres.NewPool(ctx, cfg.ForMigrations())
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer pool.Close()
+
+	migrations := postgres.Migrations()
+	m := postgres.NewMigrator(pool, migrations).WithOverlapConstraint(cfg.DBOverlapConstraint)
+
+	switch {
+	case args[0] == "up" && len(args) == 1:
+		return m.Up(ctx)
+
+	case args[0] == "down" && len(args) == 1:
+		return m.Down(ctx)
+
+	case args[0] == "goto" && len(args) == 2:
+		v, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("goto: invalid version %q", args[1])
+		}
+		return m.Goto(ctx, v)
+
+	case args[0] == "status" && len(args) == 1:
+		st, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "version %d (latest %d)", st.Version, st.Expected)
+		if st.Dirty {
+			fmt.Fprint(os.Stdout, " dirty")
+		}
+		fmt.Fprintln(os.Stdout)
+
+		for _, mig := range migrations {
+			state := "pending"
+			if mig.Version <= st.Version {
+				state = "applied"
+			}
+			fmt.Fprintf(os.Stdout, "%04d_%s\t%s\n", mig.Version, mig.Name, state)
+		}
+
+		overlap, err := postgres.OverlapConstraintEnabled(ctx, pool)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "overlap constraint\t%t (DB_OVERLAP_CONSTRAINT=%t)\n", overlap, cfg.DBOverlapConstraint)
+		return nil
+	}
+
+	return errors.New(migrateUsage)
+}
@@ -9,7 +9,9 @@ import (
 	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/http/handlers"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/http/router"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
 	"github.com/google/uuid"
 )
 
@@ -22,7 +24,8 @@ func main() {
 
 	log.Println("Smoke test started")
 
-	ctx := context.Background()
+	// Run repository calls in the default tenant
+	ctx := tenant.WithID(context.Background(), cfg.DefaultTenantID)
 
 	// Initialize PostgreSQL connection pool
 	pool, err := postgres.NewPool(ctx, cfg)
@@ -57,10 +60,12 @@ func main() {
 	}
 	log.Printf("got: %+v\n", got)
 
-	subH := handlers.NewSubscriptionsHandler(repo, 3*time.Second)
+	subH := handlers.NewSubscriptionsHandler(service.NewSubscriptionService(repo), 3*time.Second)
 
 	rtr := router.NewRouter(router.Dependencies{
-		Subscriptions: subH,
+		Subscriptions:   subH,
+		AllowAnonymous:  true,
+		DefaultTenantID: cfg.DefaultTenantID,
 	})
 
 	addr := "localhost"
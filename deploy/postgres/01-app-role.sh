@@ -0,0 +1,23 @@
+#!/bin/sh
+# Creates the login role of the service on first start of the container.
+# It is neither superuser nor table owner and cannot bypass row-level
+# security; migration 0012_app_role grants it the privileges it needs.
+set -eu
+
+psql -v ON_ERROR_STOP=1 \
+	-v app_user="$APP_DB_USER" \
+	-v app_password="$APP_DB_PASSWORD" \
+	--username "$POSTGRES_USER" \
+	--dbname "$POSTGRES_DB" <<'EOSQL'
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'subscriptions_app') THEN
+        CREATE ROLE subscriptions_app NOLOGIN NOSUPERUSER NOBYPASSRLS;
+    END IF;
+END
+$$;
+
+CREATE ROLE :"app_user" LOGIN PASSWORD :'app_password'
+    NOSUPERUSER NOBYPASSRLS NOCREATEDB NOCREATEROLE
+    IN ROLE subscriptions_app;
+EOSQL
@@ -0,0 +1,35 @@
+// Package audit carries the actor and request ID of a change through the context.
+package audit
+
+import "context"
+
+// Actions recorded in the audit log.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Meta identifies who made a change and in which request.
+type Meta struct {
+	Actor     string
+	RequestID string
+}
+
+// metaKey is the context key for audit metadata.
+type metaKey struct{}
+
+// WithMeta returns a context carrying audit metadata.
+func WithMeta(ctx context.Context, m Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, m)
+}
+
+// MetaFrom returns the audit metadata stored in ctx.
+// Changes made outside a request are attributed to "system".
+func MetaFrom(ctx context.Context) Meta {
+	m, _ := ctx.Value(metaKey{}).(Meta)
+	if m.Actor == "" {
+		m.Actor = "system"
+	}
+	return m
+}
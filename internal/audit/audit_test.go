@@ -0,0 +1,20 @@
+package audit
+
+import (
+	"context"
+	"testing"
+)
+
+func TestMetaFrom(t *testing.T) {
+	// Act
+	empty := MetaFrom(context.Background())
+	set := MetaFrom(WithMeta(context.Background(), Meta{Actor: "alice", RequestID: "req-1"}))
+
+	// Assert
+	if empty.Actor != "system" || empty.RequestID != "" {
+		t.Errorf("expected system actor without request id, got %+v", empty)
+	}
+	if set.Actor != "alice" || set.RequestID != "req-1" {
+		t.Errorf("expected stored meta, got %+v", set)
+	}
+}
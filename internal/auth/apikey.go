@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// API key scopes.
+const (
+	ScopeSubscriptionsRead  = "subscriptions:read"
+	ScopeSubscriptionsWrite = "subscriptions:write"
+	ScopeAggregationRead    = "aggregation:read"
+	ScopeAuditRead          = "audit:read"
+	ScopeWebhooksManage     = "webhooks:manage"
+)
+
+// KnownScopes lists scopes that can be granted to API keys.
+var KnownScopes = []string{
+	ScopeSubscriptionsRead,
+	ScopeSubscriptionsWrite,
+	ScopeAggregationRead,
+	ScopeAuditRead,
+	ScopeWebhooksManage,
+}
+
+// apiKeyPrefix marks keys issued by this service.
+const apiKeyPrefix = "sas_"
+
+// GenerateAPIKey returns a new random key, its display prefix and its hash.
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", fmt.Errorf("generate api key: %w", err)
+	}
+
+	key = apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(apiKeyPrefix)+8], HashAPIKey(key), nil
+}
+
+// HashAPIKey returns the hex SHA-256 of a key.
+// Keys are long random strings, so a fast hash is sufficient.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// ValidateScopes checks that every scope is known.
+func ValidateScopes(scopes []string) error {
+	for _, s := range scopes {
+		if !slices.Contains(KnownScopes, s) {
+			return fmt.Errorf("unknown scope %q", s)
+		}
+	}
+	return nil
+}
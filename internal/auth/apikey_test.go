@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ====================================
+// API keys
+// ====================================
+
+func TestGenerateAPIKey(t *testing.T) {
+	// Act
+	key, prefix, hash, err := GenerateAPIKey()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(key, prefix) || !strings.HasPrefix(prefix, "sas_") {
+		t.Errorf("expected key %q to start with prefix %q", key, prefix)
+	}
+	if hash != HashAPIKey(key) {
+		t.Error("expected hash to match key")
+	}
+	if strings.Contains(hash, key) {
+		t.Error("expected hash not to contain the key")
+	}
+
+	other, _, _, _ := GenerateAPIKey()
+	if other == key {
+		t.Error("expected unique keys")
+	}
+}
+
+func TestValidateScopes(t *testing.T) {
+	// Act & Assert
+	if err := ValidateScopes([]string{ScopeSubscriptionsRead, ScopeAggregationRead}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateScopes([]string{"subscriptions:admin"}); err == nil {
+		t.Error("expected error for unknown scope")
+	}
+}
+
+func TestPrincipalHasScope(t *testing.T) {
+	// Arrange
+	user := Principal{}
+	key := Principal{Scopes: []string{ScopeSubscriptionsRead}}
+
+	// Act & Assert
+	if !user.HasScope(ScopeSubscriptionsWrite) {
+		t.Error("expected unrestricted principal to have every scope")
+	}
+	if !key.HasScope(ScopeSubscriptionsRead) {
+		t.Error("expected granted scope")
+	}
+	if key.HasScope(ScopeSubscriptionsWrite) {
+		t.Error("expected missing scope to be denied")
+	}
+}
+
+// keyStore serves the API keys it holds by hash.
+type keyStore map[string]domain.APIKey
+
+func (s keyStore) GetByHash(_ context.Context, hash string) (domain.APIKey, error) {
+	k, ok := s[hash]
+	if !ok {
+		return domain.APIKey{}, domain.ErrNotFound
+	}
+	return k, nil
+}
+
+func (s keyStore) TouchLastUsed(context.Context, uuid.UUID) error { return nil }
+
+func TestAuthenticateAPIKey_Principal(t *testing.T) {
+	// Arrange
+	user := uuid.New()
+	tenantID := "acme"
+	store := keyStore{
+		HashAPIKey("sas_user"):  {ID: uuid.New(), UserID: &user, TenantID: &tenantID, Scopes: []string{ScopeSubscriptionsRead}},
+		HashAPIKey("sas_admin"): {ID: uuid.New(), Admin: true},
+		HashAPIKey("sas_bare"):  {ID: uuid.New()},
+	}
+
+	// Act
+	userKey, userErr := AuthenticateAPIKey(context.Background(), store, "sas_user")
+	adminKey, adminErr := AuthenticateAPIKey(context.Background(), store, "sas_admin")
+	bareKey, bareErr := AuthenticateAPIKey(context.Background(), store, "sas_bare")
+	_, unknownErr := AuthenticateAPIKey(context.Background(), store, "sas_unknown")
+
+	// Assert
+	if userErr != nil || adminErr != nil || bareErr != nil {
+		t.Fatalf("unexpected errors: %v, %v, %v", userErr, adminErr, bareErr)
+	}
+	if userKey.Admin || userKey.UserID != user || userKey.TenantID != "acme" || userKey.HasScope(ScopeSubscriptionsWrite) {
+		t.Errorf("expected a user key limited to its user, tenant and scopes, got %+v", userKey)
+	}
+	if !adminKey.Admin {
+		t.Errorf("expected an admin key, got %+v", adminKey)
+	}
+	if bareKey.Admin || bareKey.UserID != uuid.Nil {
+		t.Errorf("expected no access beyond the nil user, got %+v", bareKey)
+	}
+	if !errors.Is(unknownErr, ErrInvalidAPIKey) {
+		t.Errorf("expected ErrInvalidAPIKey, got %v", unknownErr)
+	}
+}
+
+// touchCounter counts recorded key uses.
+type touchCounter struct {
+	keyStore
+	touched int
+}
+
+func (s *touchCounter) TouchLastUsed(context.Context, uuid.UUID) error {
+	s.touched++
+	return nil
+}
+
+func TestAuthenticateAPIKey_ThrottlesLastUsed(t *testing.T) {
+	// Arrange
+	user := uuid.New()
+	recent := time.Now().Add(-10 * time.Second)
+	stale := time.Now().Add(-2 * lastUsedInterval)
+	store := &touchCounter{keyStore: keyStore{
+		HashAPIKey("sas_recent"): {ID: uuid.New(), UserID: &user, LastUsedAt: &recent},
+		HashAPIKey("sas_stale"):  {ID: uuid.New(), UserID: &user, LastUsedAt: &stale},
+		HashAPIKey("sas_new"):    {ID: uuid.New(), UserID: &user},
+	}}
+
+	// Act
+	for _, key := range []string{"sas_recent", "sas_stale", "sas_new"} {
+		if _, err := AuthenticateAPIKey(context.Background(), store, key); err != nil {
+			t.Fatalf("%s: unexpected error: %v", key, err)
+		}
+	}
+
+	// Assert
+	if store.touched != 2 {
+		t.Errorf("expected only the stale and unused keys to be touched, got %d updates", store.touched)
+	}
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Authentication errors, shared by all transports.
+var (
+	ErrMissingCredentials = errors.New("missing bearer token or api key")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrInvalidAPIKey      = errors.New("invalid api key")
+	ErrUnavailable        = errors.New("authentication unavailable")
+)
+
+// lastUsedInterval is how often the last use of an API key is recorded.
+const lastUsedInterval = time.Minute
+
+// APIKeyStore looks up API keys by hash.
+type APIKeyStore interface {
+	GetByHash(ctx context.Context, hash string) (domain.APIKey, error)
+	TouchLastUsed(ctx context.Context, id uuid.UUID) error
+}
+
+// AuthenticateBearer verifies a JWT from an Authorization header value.
+func AuthenticateBearer(v *Verifier, header string) (Principal, error) {
+	scheme, token, ok := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return Principal{}, ErrMissingCredentials
+	}
+
+	return v.Authenticate(token)
+}
+
+// AuthenticateAPIKey looks up a hashed API key and checks its validity.
+// Keys act as their user unless issued as admin, and are limited to
+// their scopes and tenant.
+func AuthenticateAPIKey(ctx context.Context, keys APIKeyStore, key string) (Principal, error) {
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	k, err := keys.GetByHash(ctx, HashAPIKey(key))
+	if errors.Is(err, domain.ErrNotFound) {
+		return Principal{}, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return Principal{}, errors.Join(ErrUnavailable, err)
+	}
+
+	if k.RevokedAt != nil {
+		return Principal{}, errors.Join(ErrInvalidAPIKey, errors.New("revoked"))
+	}
+	if k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt) {
+		return Principal{}, errors.Join(ErrInvalidAPIKey, errors.New("expired"))
+	}
+
+	// Usage is tracked at most once per interval, and must not fail
+	// the request
+	if k.LastUsedAt == nil || time.Since(*k.LastUsedAt) >= lastUsedInterval {
+		if err := keys.TouchLastUsed(ctx, k.ID); err != nil {
+			slog.ErrorContext(ctx, "track api key usage", "api_key_id", k.ID, "error", err)
+		}
+	}
+
+	scopes := k.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	p := Principal{
+		Subject: "api_key:" + k.ID.String(),
+		Admin:   k.Admin,
+		Scopes:  scopes,
+	}
+	if k.UserID != nil {
+		p.UserID = *k.UserID
+	}
+	if k.TenantID != nil {
+		p.TenantID = *k.TenantID
+	}
+
+	return p, nil
+}
@@ -0,0 +1,307 @@
+// Package auth verifies bearer tokens and carries the caller identity.
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/config"
+	"github.com/google/uuid"
+)
+
+// Token verification errors.
+var (
+	ErrMalformedToken   = errors.New("malformed token")
+	ErrUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrTokenExpired     = errors.New("token expired")
+	ErrTokenNotYetValid = errors.New("token not yet valid")
+	ErrInvalidClaims    = errors.New("invalid token claims")
+)
+
+// leeway tolerates clock skew between issuer and service.
+const leeway = 30 * time.Second
+
+// audience accepts both a single string and a list, as allowed by RFC 7519.
+type audience []string
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (a *audience) UnmarshalJSON(b []byte) error {
+	var single string
+	if err := json.Unmarshal(b, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(b, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Claims holds the registered and custom claims used by the service.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt int64    `json:"exp"`
+	NotBefore int64    `json:"nbf"`
+	IssuedAt  int64    `json:"iat"`
+	Scope     string   `json:"scope"` // space-separated scopes
+	Roles     []string `json:"roles"`
+	TenantID  string   `json:"tenant_id"`
+}
+
+// HasScope reports whether the token grants scope via "scope" or "roles".
+func (c Claims) HasScope(scope string) bool {
+	return slices.Contains(strings.Fields(c.Scope), scope) || slices.Contains(c.Roles, scope)
+}
+
+// header is the JOSE header of a token.
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// Verifier validates HS256 and RS256 signed tokens.
+type Verifier struct {
+	hmacSecret []byte
+	rsaKeys    map[string]*rsa.PublicKey // keyed by kid, "" for a key without kid
+	issuer     string
+	audience   string
+	adminScope string
+	now        func() time.Time
+}
+
+// VerifierConfig configures token verification.
+// At least one of HMACSecret or RSAKeys must be set.
+type VerifierConfig struct {
+	HMACSecret []byte
+	RSAKeys    map[string]*rsa.PublicKey
+	Issuer     string // expected "iss", optional
+	Audience   string // expected "aud", optional
+	AdminScope string // scope or role granting cross-user access
+}
+
+// NewVerifier creates a token verifier.
+func NewVerifier(cfg VerifierConfig) (*Verifier, error) {
+	if len(cfg.HMACSecret) == 0 && len(cfg.RSAKeys) == 0 {
+		return nil, errors.New("auth: no verification keys configured")
+	}
+
+	return &Verifier{
+		hmacSecret: cfg.HMACSecret,
+		rsaKeys:    cfg.RSAKeys,
+		issuer:     cfg.Issuer,
+		audience:   cfg.Audience,
+		adminScope: cfg.AdminScope,
+		now:        time.Now,
+	}, nil
+}
+
+// Verify checks the token signature and time-based claims and returns its claims.
+func (v *Verifier) Verify(token string) (Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Claims{}, ErrMalformedToken
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return Claims{}, ErrMalformedToken
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformedToken
+	}
+
+	// Verify signature over "header.payload"
+	signed := []byte(parts[0] + "." + parts[1])
+	if err := v.verifySignature(h, signed, sig); err != nil {
+		return Claims{}, err
+	}
+
+	var c Claims
+	if err := decodeSegment(parts[1], &c); err != nil {
+		return Claims{}, ErrMalformedToken
+	}
+
+	if err := v.validateClaims(c); err != nil {
+		return Claims{}, err
+	}
+
+	return c, nil
+}
+
+// Scopes returns the scopes the token grants via "scope" or "roles",
+// empty rather than nil, so tokens without any grant nothing.
+func (c Claims) Scopes() []string {
+	scopes := []string{}
+	for _, s := range append(strings.Fields(c.Scope), c.Roles...) {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// Authenticate verifies the token and maps its claims to a principal.
+// Non-admin tokens must carry the user UUID in "sub".
+func (v *Verifier) Authenticate(token string) (Principal, error) {
+	c, err := v.Verify(token)
+	if err != nil {
+		return Principal{}, err
+	}
+
+	p := Principal{
+		Subject:  c.Subject,
+		Admin:    v.adminScope != "" && c.HasScope(v.adminScope),
+		TenantID: c.TenantID,
+		Scopes:   c.Scopes(),
+	}
+
+	userID, err := uuid.Parse(c.Subject)
+	switch {
+	case err == nil:
+		p.UserID = userID
+	case !p.Admin:
+		return Principal{}, fmt.Errorf("%w: sub must be a user UUID", ErrInvalidClaims)
+	}
+
+	return p, nil
+}
+
+// verifySignature checks sig with the key matching the header algorithm.
+func (v *Verifier) verifySignature(h header, signed, sig []byte) error {
+	switch h.Alg {
+	case "HS256":
+		if len(v.hmacSecret) == 0 {
+			return ErrUnsupportedAlg
+		}
+		mac := hmac.New(sha256.New, v.hmacSecret)
+		mac.Write(signed)
+		if !hmac.Equal(mac.Sum(nil), sig) {
+			return ErrInvalidSignature
+		}
+		return nil
+
+	case "RS256":
+		if len(v.rsaKeys) == 0 {
+			return ErrUnsupportedAlg
+		}
+		digest := sha256.Sum256(signed)
+
+		// Use the key named by kid, or try every key when kid is absent
+		if h.Kid != "" {
+			key, ok := v.rsaKeys[h.Kid]
+			if !ok {
+				// Fall back to the configured PEM key, which has no kid
+				key, ok = v.rsaKeys[""]
+			}
+			if !ok {
+				return fmt.Errorf("%w: unknown kid %q", ErrInvalidSignature, h.Kid)
+			}
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) != nil {
+				return ErrInvalidSignature
+			}
+			return nil
+		}
+		for _, key := range v.rsaKeys {
+			if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sig) == nil {
+				return nil
+			}
+		}
+		return ErrInvalidSignature
+
+	default:
+		return ErrUnsupportedAlg
+	}
+}
+
+// validateClaims checks expiry, not-before, issuer and audience.
+func (v *Verifier) validateClaims(c Claims) error {
+	now := v.now()
+
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("%w: exp is required", ErrInvalidClaims)
+	}
+	if now.After(time.Unix(c.ExpiresAt, 0).Add(leeway)) {
+		return ErrTokenExpired
+	}
+	if c.NotBefore != 0 && now.Add(leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return ErrTokenNotYetValid
+	}
+
+	if v.issuer != "" && c.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidClaims)
+	}
+	if v.audience != "" && !slices.Contains(c.Audience, v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidClaims)
+	}
+	if c.Subject == "" {
+		return fmt.Errorf("%w: sub is required", ErrInvalidClaims)
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url JSON segment into v.
+func decodeSegment(seg string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// NewVerifierFromConfig builds a verifier from JWT settings.
+// It returns nil without error when no keys are configured,
+// which leaves authentication disabled.
+func NewVerifierFromConfig(cfg *config.Config) (*Verifier, error) {
+	vc := VerifierConfig{
+		Issuer:     cfg.JWTIssuer,
+		Audience:   cfg.JWTAudience,
+		AdminScope: cfg.JWTAdminScope,
+		RSAKeys:    map[string]*rsa.PublicKey{},
+	}
+
+	if cfg.JWTHS256Secret != "" {
+		vc.HMACSecret = []byte(cfg.JWTHS256Secret)
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		key, err := LoadRSAPublicKeyPEM(cfg.JWTPublicKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		vc.RSAKeys[""] = key
+	}
+
+	if cfg.JWTJWKSFile != "" {
+		keys, err := LoadJWKS(cfg.JWTJWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		for kid, key := range keys {
+			vc.RSAKeys[kid] = key
+		}
+	}
+
+	if len(vc.HMACSecret) == 0 && len(vc.RSAKeys) == 0 {
+		return nil, nil
+	}
+
+	return NewVerifier(vc)
+}
@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// encodeSegment encodes v as a base64url JSON segment.
+func encodeSegment(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signHS256 builds an HS256 token for the given claims.
+func signHS256(t *testing.T, secret []byte, claims map[string]any) string {
+	t.Helper()
+	unsigned := encodeSegment(t, map[string]string{"alg": "HS256", "typ": "JWT"}) + "." + encodeSegment(t, claims)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// signRS256 builds an RS256 token for the given claims.
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	unsigned := encodeSegment(t, map[string]string{"alg": "RS256", "kid": kid}) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+// userClaims returns valid claims for a regular user.
+func userClaims(sub string) map[string]any {
+	return map[string]any{
+		"sub": sub,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+// ====================================
+// HS256
+// ====================================
+
+func TestAuthenticate_HS256UserToken(t *testing.T) {
+	// Arrange
+	secret := []byte("test-secret")
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: secret, AdminScope: "admin"})
+	userID := uuid.New()
+
+	// Act
+	p, err := v.Authenticate(signHS256(t, secret, userClaims(userID.String())))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.UserID != userID || p.Admin {
+		t.Errorf("expected non-admin user %v, got %+v", userID, p)
+	}
+}
+
+func TestAuthenticate_AdminScope(t *testing.T) {
+	// Arrange
+	secret := []byte("test-secret")
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: secret, AdminScope: "admin"})
+	claims := userClaims("batch-service")
+	claims["scope"] = "subscriptions:read admin"
+
+	// Act
+	p, err := v.Authenticate(signHS256(t, secret, claims))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !p.Admin {
+		t.Error("expected admin principal")
+	}
+}
+
+func TestAuthenticate_ScopesFromClaims(t *testing.T) {
+	// Arrange
+	secret := []byte("test-secret")
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: secret, AdminScope: "admin"})
+	scoped := userClaims(uuid.NewString())
+	scoped["scope"] = ScopeSubscriptionsRead
+	scoped["roles"] = []string{ScopeAggregationRead}
+
+	// Act
+	p, err := v.Authenticate(signHS256(t, secret, scoped))
+	bare, bareErr := v.Authenticate(signHS256(t, secret, userClaims(uuid.NewString())))
+
+	// Assert
+	if err != nil || bareErr != nil {
+		t.Fatalf("unexpected errors: %v, %v", err, bareErr)
+	}
+	if !p.HasScope(ScopeSubscriptionsRead) || !p.HasScope(ScopeAggregationRead) || p.HasScope(ScopeSubscriptionsWrite) {
+		t.Errorf("expected only the granted scopes, got %v", p.Scopes)
+	}
+	if bare.Scopes == nil || bare.HasScope(ScopeSubscriptionsRead) {
+		t.Errorf("expected a token without scopes to grant none, got %v", bare.Scopes)
+	}
+}
+
+func TestAuthenticate_TenantClaim(t *testing.T) {
+	// Arrange
+	secret := []byte("test-secret")
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: secret, AdminScope: "admin"})
+	claims := userClaims(uuid.NewString())
+	claims["tenant_id"] = "acme"
+
+	// Act
+	p, err := v.Authenticate(signHS256(t, secret, claims))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.TenantID != "acme" {
+		t.Errorf("expected tenant acme, got %q", p.TenantID)
+	}
+}
+
+func TestAuthenticate_NonUUIDSubjectRejected(t *testing.T) {
+	// Arrange
+	secret := []byte("test-secret")
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: secret, AdminScope: "admin"})
+
+	// Act
+	_, err := v.Authenticate(signHS256(t, secret, userClaims("alice")))
+
+	// Assert
+	if !errors.Is(err, ErrInvalidClaims) {
+		t.Errorf("expected ErrInvalidClaims, got %v", err)
+	}
+}
+
+func TestVerify_WrongSecret(t *testing.T) {
+	// Arrange
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: []byte("right")})
+	token := signHS256(t, []byte("wrong"), userClaims(uuid.NewString()))
+
+	// Act
+	_, err := v.Verify(token)
+
+	// Assert
+	if !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature, got %v", err)
+	}
+}
+
+func TestVerify_Expired(t *testing.T) {
+	// Arrange
+	secret := []byte("test-secret")
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: secret})
+	claims := userClaims(uuid.NewString())
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	// Act
+	_, err := v.Verify(signHS256(t, secret, claims))
+
+	// Assert
+	if !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestVerify_AudienceAndIssuer(t *testing.T) {
+	// Arrange
+	secret := []byte("test-secret")
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: secret, Issuer: "idp", Audience: "subs"})
+	claims := userClaims(uuid.NewString())
+	claims["iss"] = "idp"
+	claims["aud"] = []string{"other", "subs"}
+
+	// Act
+	_, okErr := v.Verify(signHS256(t, secret, claims))
+	claims["aud"] = "other"
+	_, badErr := v.Verify(signHS256(t, secret, claims))
+
+	// Assert
+	if okErr != nil {
+		t.Errorf("unexpected error: %v", okErr)
+	}
+	if !errors.Is(badErr, ErrInvalidClaims) {
+		t.Errorf("expected ErrInvalidClaims, got %v", badErr)
+	}
+}
+
+func TestVerify_AlgNoneRejected(t *testing.T) {
+	// Arrange
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: []byte("secret")})
+	token := encodeSegment(t, map[string]string{"alg": "none"}) + "." +
+		encodeSegment(t, userClaims(uuid.NewString())) + "."
+
+	// Act
+	_, err := v.Verify(token)
+
+	// Assert
+	if !errors.Is(err, ErrUnsupportedAlg) {
+		t.Errorf("expected ErrUnsupportedAlg, got %v", err)
+	}
+}
+
+func TestVerify_Malformed(t *testing.T) {
+	// Arrange
+	v, _ := NewVerifier(VerifierConfig{HMACSecret: []byte("secret")})
+
+	// Act
+	_, err := v.Verify("not-a-token")
+
+	// Assert
+	if !errors.Is(err, ErrMalformedToken) {
+		t.Errorf("expected ErrMalformedToken, got %v", err)
+	}
+}
+
+// ====================================
+// RS256 / JWKS
+// ====================================
+
+func TestVerify_RS256WithJWKS(t *testing.T) {
+	// Arrange
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	jwks := map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"kid": "k1",
+		"use": "sig",
+		"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	data, _ := json.Marshal(jwks)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	keys, err := LoadJWKS(path)
+	if err != nil {
+		t.Fatalf("load jwks: %v", err)
+	}
+	v, _ := NewVerifier(VerifierConfig{RSAKeys: keys})
+
+	// Act
+	_, okErr := v.Verify(signRS256(t, key, "k1", userClaims(uuid.NewString())))
+	_, kidErr := v.Verify(signRS256(t, key, "unknown", userClaims(uuid.NewString())))
+	_, hsErr := v.Verify(signHS256(t, []byte("x"), userClaims(uuid.NewString())))
+
+	// Assert
+	if okErr != nil {
+		t.Errorf("unexpected error: %v", okErr)
+	}
+	if !errors.Is(kidErr, ErrInvalidSignature) {
+		t.Errorf("expected ErrInvalidSignature for unknown kid, got %v", kidErr)
+	}
+	if !errors.Is(hsErr, ErrUnsupportedAlg) {
+		t.Errorf("expected ErrUnsupportedAlg for HS256 without secret, got %v", hsErr)
+	}
+}
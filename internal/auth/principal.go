@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	Subject string
+	UserID  uuid.UUID // parsed from Subject, zero for non-user callers
+	Admin   bool      // admin callers may access every user's data
+
+	// TenantID binds the caller to one tenant; requests of authenticated
+	// callers without one are rejected
+	TenantID string
+
+	// Scopes restricts the allowed operations; nil means unrestricted,
+	// tokens and API keys always carry a list
+	Scopes []string
+}
+
+// HasScope reports whether the principal may perform operations of scope.
+func (p Principal) HasScope(scope string) bool {
+	return p.Scopes == nil || slices.Contains(p.Scopes, scope)
+}
+
+// principalKey is the context key for the request principal.
+type principalKey struct{}
+
+// WithPrincipal returns a context carrying the principal.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the principal stored in ctx, if any.
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// ScopedUserID returns the user every query must be limited to.
+// It returns nil when the request is unauthenticated or made by an admin.
+func ScopedUserID(ctx context.Context) *uuid.UUID {
+	p, ok := PrincipalFrom(ctx)
+	if !ok || p.Admin {
+		return nil
+	}
+	id := p.UserID
+	return &id
+}
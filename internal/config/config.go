@@ -2,8 +2,11 @@ package config
 
 import (
 	"fmt"
+	"net/netip"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -11,12 +14,89 @@ import (
 // App configuration structure
 type Config struct {
 	AppPort    string
+	GRPCPort   string
 	DBHost     string
 	DBPort     string
 	DBName     string
 	DBUser     string
 	DBPassword string
 	DBSSLMode  string
+
+	// Schema owner used for migrations; the service role may not
+	// change the schema. Empty uses DBUser and DBPassword.
+	DBMigrateUser     string
+	DBMigratePassword string
+
+	// DBAutoMigrate applies pending migrations on startup, as
+	// DBMigrateUser
+	DBAutoMigrate bool
+
+	// DBOverlapConstraint keeps the exclusion constraint against
+	// overlapping subscriptions in place after migrating
+	DBOverlapConstraint bool
+
+	// AuthRequired rejects requests without credentials; only disable
+	// it for local development
+	AuthRequired bool
+
+	// JWT authentication, disabled when no key is configured
+	JWTHS256Secret   string
+	JWTPublicKeyFile string
+	JWTJWKSFile      string
+	JWTIssuer        string
+	JWTAudience      string
+	JWTAdminScope    string
+
+	// Structured logging
+	LogFormat string // json or text
+	LogLevel  string // debug, info, warn or error
+
+	// DefaultTenantID is used for requests that name no tenant
+	DefaultTenantID string
+
+	// HTTP server timeouts
+	HTTPReadHeaderTimeout time.Duration
+	HTTPReadTimeout       time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	ShutdownTimeout       time.Duration
+	ShutdownDrainDelay    time.Duration
+
+	// TrustedProxies are the proxies whose X-Forwarded-For header is
+	// trusted for the client IP; none by default
+	TrustedProxies []string
+
+	// Rate limits, disabled when all are empty
+	RateLimitDefault string // e.g. 100/m
+	RateLimitRoutes  string // e.g. GET /api/subscriptions/total=10/m
+	RateLimitIP      string // per IP before authentication, e.g. 1200/m
+
+	// Tracing, disabled when TracesExporter is "none"
+	TracesExporter   string  // none, otlp, stdout or file
+	OTLPEndpoint     string  // OTLP/HTTP base URL, e.g. http://localhost:4318
+	TracesFile       string  // target of the file exporter
+	ServiceName      string  // service.name resource attribute
+	TraceSampleRatio float64 // share of new traces that are recorded
+
+	// Webhook delivery
+	WebhookPollInterval time.Duration
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+
+	// WebhookAllowPrivateNetworks lets webhooks target loopback and
+	// private addresses, for local development only
+	WebhookAllowPrivateNetworks bool
+
+	// Outbox relay, publishing change events to a sink
+	OutboxSink         string // none, stdout, file or http
+	OutboxFile         string // target of the file sink
+	OutboxHTTPURL      string // target of the http sink
+	OutboxPollInterval time.Duration
+	OutboxRetention    time.Duration // how long sent events are kept
+
+	// Server-Sent Events stream of subscription changes
+	StreamHeartbeat time.Duration // comment sent to idle streams
+	StreamBacklog   int           // events replayed at most on resume
 }
 
 // Load and validate configuration
@@ -25,12 +105,41 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 	cfg := &Config{
 		AppPort:    os.Getenv("APP_PORT"),
+		GRPCPort:   os.Getenv("GRPC_PORT"),
 		DBHost:     os.Getenv("DB_HOST"),
 		DBPort:     os.Getenv("DB_PORT"),
 		DBName:     os.Getenv("DB_NAME"),
 		DBUser:     os.Getenv("DB_USER"),
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		DBSSLMode:  os.Getenv("DB_SSLMODE"),
+
+		DBMigrateUser:     os.Getenv("DB_MIGRATE_USER"),
+		DBMigratePassword: os.Getenv("DB_MIGRATE_PASSWORD"),
+
+		JWTHS256Secret:   os.Getenv("JWT_HS256_SECRET"),
+		JWTPublicKeyFile: os.Getenv("JWT_RS256_PUBLIC_KEY_FILE"),
+		JWTJWKSFile:      os.Getenv("JWT_JWKS_FILE"),
+		JWTIssuer:        os.Getenv("JWT_ISSUER"),
+		JWTAudience:      os.Getenv("JWT_AUDIENCE"),
+		JWTAdminScope:    os.Getenv("JWT_ADMIN_SCOPE"),
+
+		LogFormat: os.Getenv("LOG_FORMAT"),
+		LogLevel:  os.Getenv("LOG_LEVEL"),
+
+		DefaultTenantID: os.Getenv("DEFAULT_TENANT_ID"),
+
+		RateLimitDefault: os.Getenv("RATE_LIMIT_DEFAULT"),
+		RateLimitRoutes:  os.Getenv("RATE_LIMIT_ROUTES"),
+		RateLimitIP:      os.Getenv("RATE_LIMIT_IP"),
+
+		TracesExporter: os.Getenv("OTEL_TRACES_EXPORTER"),
+		OTLPEndpoint:   os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		TracesFile:     os.Getenv("OTEL_TRACES_FILE"),
+		ServiceName:    os.Getenv("OTEL_SERVICE_NAME"),
+
+		OutboxSink:    os.Getenv("OUTBOX_SINK"),
+		OutboxFile:    os.Getenv("OUTBOX_FILE"),
+		OutboxHTTPURL: os.Getenv("OUTBOX_HTTP_URL"),
 	}
 
 	// Validate required fields
@@ -41,6 +150,13 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("APP_PORT must be numeric")
 	}
 
+	if cfg.GRPCPort == "" {
+		cfg.GRPCPort = "9090"
+	}
+	if _, err := strconv.Atoi(cfg.GRPCPort); err != nil {
+		return nil, fmt.Errorf("GRPC_PORT must be numeric")
+	}
+
 	if cfg.DBHost == "" {
 		cfg.DBHost = "localhost"
 	}
@@ -66,6 +182,191 @@ func Load() (*Config, error) {
 	if cfg.DBSSLMode == "" {
 		cfg.DBSSLMode = "disable"
 	}
+	if cfg.JWTAdminScope == "" {
+		cfg.JWTAdminScope = "admin"
+	}
+	if cfg.LogFormat == "" {
+		cfg.LogFormat = "json"
+	}
+	if cfg.LogLevel == "" {
+		cfg.LogLevel = "info"
+	}
+	if cfg.DefaultTenantID == "" {
+		cfg.DefaultTenantID = "default"
+	}
+
+	if v := os.Getenv("DB_AUTO_MIGRATE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("DB_AUTO_MIGRATE must be true or false")
+		}
+		cfg.DBAutoMigrate = b
+	}
+	if cfg.DBAutoMigrate && (cfg.DBMigrateUser == "" || cfg.DBMigratePassword == "") {
+		return nil, fmt.Errorf("DB_MIGRATE_USER and DB_MIGRATE_PASSWORD are required with DB_AUTO_MIGRATE")
+	}
+
+	cfg.DBOverlapConstraint = true
+	if v := os.Getenv("DB_OVERLAP_CONSTRAINT"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("DB_OVERLAP_CONSTRAINT must be true or false")
+		}
+		cfg.DBOverlapConstraint = b
+	}
+
+	// Validate trusted proxy addresses
+	for _, p := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		if _, err := netip.ParsePrefix(p); err != nil {
+			if _, err := netip.ParseAddr(p); err != nil {
+				return nil, fmt.Errorf("TRUSTED_PROXIES must be comma-separated IPs or CIDRs")
+			}
+		}
+		cfg.TrustedProxies = append(cfg.TrustedProxies, p)
+	}
+
+	cfg.AuthRequired = true
+	if v := os.Getenv("AUTH_REQUIRED"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("AUTH_REQUIRED must be true or false")
+		}
+		cfg.AuthRequired = b
+	}
+
+	// Validate tracing settings
+	switch cfg.TracesExporter {
+	case "":
+		cfg.TracesExporter = "none"
+	case "none", "otlp", "stdout":
+	case "file":
+		if cfg.TracesFile == "" {
+			return nil, fmt.Errorf("OTEL_TRACES_FILE is required for the file exporter")
+		}
+	default:
+		return nil, fmt.Errorf("OTEL_TRACES_EXPORTER must be none, otlp, stdout or file")
+	}
+	if cfg.OTLPEndpoint == "" {
+		cfg.OTLPEndpoint = "http://localhost:4318"
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "subscription-aggregation-service"
+	}
+
+	// Validate outbox sink settings
+	switch cfg.OutboxSink {
+	case "":
+		cfg.OutboxSink = "none"
+	case "none", "stdout":
+	case "file":
+		if cfg.OutboxFile == "" {
+			return nil, fmt.Errorf("OUTBOX_FILE is required for the file sink")
+		}
+	case "http":
+		if cfg.OutboxHTTPURL == "" {
+			return nil, fmt.Errorf("OUTBOX_HTTP_URL is required for the http sink")
+		}
+	default:
+		return nil, fmt.Errorf("OUTBOX_SINK must be none, stdout, file or http")
+	}
+
+	cfg.TraceSampleRatio = 1
+	if v := os.Getenv("OTEL_TRACES_SAMPLER_ARG"); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r < 0 || r > 1 {
+			return nil, fmt.Errorf("OTEL_TRACES_SAMPLER_ARG must be a ratio between 0 and 1")
+		}
+		cfg.TraceSampleRatio = r
+	}
+
+	// Parse server timeouts
+	timeouts := []struct {
+		env string
+		dst *time.Duration
+		def time.Duration
+	}{
+		{"HTTP_READ_HEADER_TIMEOUT", &cfg.HTTPReadHeaderTimeout, 5 * time.Second},
+		{"HTTP_READ_TIMEOUT", &cfg.HTTPReadTimeout, 30 * time.Second},
+		{"HTTP_WRITE_TIMEOUT", &cfg.HTTPWriteTimeout, 60 * time.Second},
+		{"HTTP_IDLE_TIMEOUT", &cfg.HTTPIdleTimeout, 120 * time.Second},
+		{"SHUTDOWN_TIMEOUT", &cfg.ShutdownTimeout, 20 * time.Second},
+		{"SHUTDOWN_DRAIN_DELAY", &cfg.ShutdownDrainDelay, 0},
+		{"WEBHOOK_POLL_INTERVAL", &cfg.WebhookPollInterval, 5 * time.Second},
+		{"WEBHOOK_TIMEOUT", &cfg.WebhookTimeout, 10 * time.Second},
+		{"OUTBOX_POLL_INTERVAL", &cfg.OutboxPollInterval, time.Second},
+		{"OUTBOX_RETENTION", &cfg.OutboxRetention, 24 * time.Hour},
+		{"STREAM_HEARTBEAT", &cfg.StreamHeartbeat, 15 * time.Second},
+	}
+	for _, t := range timeouts {
+		d, err := durationEnv(t.env, t.def)
+		if err != nil {
+			return nil, err
+		}
+		*t.dst = d
+	}
+	if cfg.WebhookPollInterval == 0 || cfg.WebhookTimeout == 0 {
+		return nil, fmt.Errorf("WEBHOOK_POLL_INTERVAL and WEBHOOK_TIMEOUT must be positive")
+	}
+	if cfg.OutboxPollInterval == 0 || cfg.OutboxRetention == 0 {
+		return nil, fmt.Errorf("OUTBOX_POLL_INTERVAL and OUTBOX_RETENTION must be positive")
+	}
+	if cfg.StreamHeartbeat == 0 {
+		return nil, fmt.Errorf("STREAM_HEARTBEAT must be positive")
+	}
+
+	cfg.WebhookMaxAttempts = 10
+	if v := os.Getenv("WEBHOOK_MAX_ATTEMPTS"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be a positive integer")
+		}
+		cfg.WebhookMaxAttempts = n
+	}
+
+	if v := os.Getenv("WEBHOOK_ALLOW_PRIVATE_NETWORKS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("WEBHOOK_ALLOW_PRIVATE_NETWORKS must be true or false")
+		}
+		cfg.WebhookAllowPrivateNetworks = b
+	}
+
+	cfg.StreamBacklog = 1000
+	if v := os.Getenv("STREAM_BACKLOG"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("STREAM_BACKLOG must be a positive integer")
+		}
+		cfg.StreamBacklog = n
+	}
 
 	return cfg, nil
 }
+
+// ForMigrations returns the configuration to change the schema with:
+// the credentials of the schema owner when set, else the service ones.
+func (c *Config) ForMigrations() *Config {
+	out := *c
+	if c.DBMigrateUser != "" {
+		out.DBUser, out.DBPassword = c.DBMigrateUser, c.DBMigratePassword
+	}
+	return &out
+}
+
+// durationEnv parses a duration variable such as "30s", def if unset.
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration, e.g. 30s", name)
+	}
+
+	return d, nil
+}
@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a hashed credential for service-to-service clients
+type APIKey struct {
+	ID         uuid.UUID
+	Name       string
+	Prefix     string // first characters of the key, shown to identify it
+	Hash       string // SHA-256 of the full key, the key itself is never stored
+	Scopes     []string
+	Admin      bool       // admin keys act across users
+	UserID     *uuid.UUID // user a non-admin key acts as
+	TenantID   *string    // tenant the key is bound to, keys without one are rejected
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+	CreatedAt  time.Time
+}
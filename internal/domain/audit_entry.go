@@ -0,0 +1,21 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry records one change of a subscription
+type AuditEntry struct {
+	ID             int64
+	SubscriptionID uuid.UUID
+	UserID         uuid.UUID // owner after the change, or before a delete
+	Action         string    // create, update or delete
+	Actor          string
+	RequestID      string
+	Before         json.RawMessage // nil for create
+	After          json.RawMessage // nil for delete
+	CreatedAt      time.Time
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+)
+
+// Errors shared by the storage and transport layers.
+var (
+	// ErrNotFound indicates that a requested entity was not found.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict indicates that a write violates a uniqueness or overlap rule.
+	ErrConflict = errors.New("conflict")
+
+	// ErrInvalidInput indicates that client input was rejected.
+	ErrInvalidInput = errors.New("invalid input")
+)
+
+// FieldError describes why one input field is invalid.
+type FieldError struct {
+	Field   string `json:"field" example:"price"`
+	Message string `json:"message" example:"must be >= 0"`
+}
+
+// ValidationError lists all invalid fields of an input.
+// It matches ErrInvalidInput with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// Invalid returns a validation error for a single field.
+func Invalid(field, message string) error {
+	return &ValidationError{Fields: []FieldError{{Field: field, Message: message}}}
+}
+
+// Add records an invalid field.
+func (e *ValidationError) Add(field, message string) {
+	e.Fields = append(e.Fields, FieldError{Field: field, Message: message})
+}
+
+// Err returns e if any field was recorded, otherwise nil.
+func (e *ValidationError) Err() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Error joins the field messages, e.g. "price: must be >= 0".
+func (e *ValidationError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Field+": "+f.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Is reports whether target is ErrInvalidInput.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidInput
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Subscription event types.
+const (
+	EventSubscriptionCreated = "subscription.created"
+	EventSubscriptionUpdated = "subscription.updated"
+	EventSubscriptionDeleted = "subscription.deleted"
+	EventSubscriptionStarted = "subscription.started" // first month began
+	EventSubscriptionEnded   = "subscription.ended"   // last month is over
+)
+
+// EventTypes lists all subscription event types.
+var EventTypes = []string{
+	EventSubscriptionCreated,
+	EventSubscriptionUpdated,
+	EventSubscriptionDeleted,
+	EventSubscriptionStarted,
+	EventSubscriptionEnded,
+}
+
+// Event reports a change of a subscription
+type Event struct {
+	ID           uuid.UUID
+	Type         string
+	OccurredAt   time.Time
+	Subscription Subscription // state after the change, or before a delete
+}
+
+// NewEvent returns an event of type typ about sub, occurring now.
+func NewEvent(typ string, sub Subscription) Event {
+	return Event{
+		ID:           uuid.New(),
+		Type:         typ,
+		OccurredAt:   time.Now().UTC(),
+		Subscription: sub,
+	}
+}
@@ -0,0 +1,62 @@
+package domain
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ListFilter defines optional list filters.
+type ListFilter struct {
+	UserID       *uuid.UUID
+	ServiceName  *string
+	UserIDs      []uuid.UUID // any of these users, unfiltered when nil
+	ServiceNames []string    // any of these services, unfiltered when nil
+	AsOf         *time.Time  // read rows as they were at this moment
+	After        *Cursor     // only rows following this position
+}
+
+// Cursor is a position in the newest-first order of lists, which breaks
+// ties of creation time by ID. Unlike an offset it stays put when rows
+// before it are added or removed.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// SubscriptionStore persists the subscriptions of the tenant in ctx.
+//
+// Missing subscriptions, including those of other tenants, are reported
+// as ErrNotFound. Writes that overlap another subscription of the same
+// user and service return ErrConflict.
+type SubscriptionStore interface {
+	// InTx runs fn in a transaction committed if fn returns nil.
+	// Calling InTx on the store passed to fn nests a savepoint.
+	InTx(ctx context.Context, fn func(tx SubscriptionStore) error) error
+
+	Create(ctx context.Context, s Subscription) (Subscription, error)
+	CreateMany(ctx context.Context, subs []Subscription) (int64, error)
+	GetByID(ctx context.Context, id uuid.UUID) (Subscription, error)
+	GetByIDAsOf(ctx context.Context, id uuid.UUID, asOf time.Time) (Subscription, error)
+	Update(ctx context.Context, s Subscription) (Subscription, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+
+	// List returns matching subscriptions, newest first.
+	List(ctx context.Context, f ListFilter) ([]Subscription, error)
+
+	// ListOverlapping returns subscriptions active in any month of the
+	// period, ordered by start date.
+	ListOverlapping(
+		ctx context.Context,
+		userID *uuid.UUID,
+		serviceName *string,
+		periodStart,
+		periodEnd time.Time,
+		asOf *time.Time,
+	) ([]Subscription, error)
+
+	// Stream passes the subscriptions List would return to fn one by one.
+	// An error from fn stops the stream and is returned.
+	Stream(ctx context.Context, f ListFilter, fn func(Subscription) error) error
+}
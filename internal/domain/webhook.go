@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Webhook delivery statuses.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead" // gave up after the last attempt
+)
+
+// Webhook is an endpoint notified about subscription events
+type Webhook struct {
+	ID        uuid.UUID
+	URL       string
+	Secret    string   // key of the HMAC-SHA256 payload signature
+	Events    []string // event types to send, empty for all
+	CreatedAt time.Time
+}
+
+// Wants reports whether events of type typ are sent to the webhook.
+func (w Webhook) Wants(typ string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, typ)
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a webhook
+type WebhookDelivery struct {
+	ID             int64
+	WebhookID      uuid.UUID
+	EventID        uuid.UUID
+	Event          string
+	Payload        json.RawMessage
+	Status         string
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseStatus *int    // HTTP status of the last attempt, nil without response
+	LastError      *string // failure of the last attempt
+	DeliveredAt    *time.Time
+	CreatedAt      time.Time
+}
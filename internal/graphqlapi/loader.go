@@ -0,0 +1,214 @@
+package graphqlapi
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/google/uuid"
+)
+
+// loaders batch the lookups of one query. Nested fields such as
+// User.total run once per node; the loaders turn them into a single
+// storage call per distinct argument set and group the result in memory.
+type loaders struct {
+	subs *service.SubscriptionService
+	agg  *service.AggregationService
+
+	mu       sync.Mutex
+	lists    map[string]*entry
+	periods  map[activeKey]*entry
+	users    map[string]*batcher[uuid.UUID]
+	services map[string]*batcher[string]
+}
+
+// entry is a lookup shared by all nodes asking for the same key.
+type entry struct {
+	once  sync.Once
+	items []domain.Subscription
+	err   error
+}
+
+// activeKey identifies an Active lookup.
+type activeKey struct {
+	start, end time.Time
+	asOf       string
+}
+
+// loadersKey is the context key for the loaders of a query.
+type loadersKey struct{}
+
+// withLoaders returns a context carrying fresh loaders.
+func withLoaders(ctx context.Context, subs *service.SubscriptionService, agg *service.AggregationService) context.Context {
+	return context.WithValue(ctx, loadersKey{}, &loaders{
+		subs:     subs,
+		agg:      agg,
+		lists:    make(map[string]*entry),
+		periods:  make(map[activeKey]*entry),
+		users:    make(map[string]*batcher[uuid.UUID]),
+		services: make(map[string]*batcher[string]),
+	})
+}
+
+// loadersFrom returns the loaders of the query in ctx.
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
+
+// subscriptions returns all subscriptions visible to the caller at asOf,
+// shared by the unfiltered lists of a query.
+func (l *loaders) subscriptions(ctx context.Context, asOf *time.Time) ([]domain.Subscription, error) {
+	key := asOfKey(asOf)
+
+	l.mu.Lock()
+	e, ok := l.lists[key]
+	if !ok {
+		e = &entry{}
+		l.lists[key] = e
+	}
+	l.mu.Unlock()
+
+	e.once.Do(func() {
+		e.items, e.err = l.subs.List(ctx, domain.ListFilter{AsOf: asOf})
+	})
+	return e.items, e.err
+}
+
+// byUser returns the batcher of the subscriptions of users at asOf.
+func (l *loaders) byUser(asOf *time.Time) *batcher[uuid.UUID] {
+	key := asOfKey(asOf)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.users[key]
+	if !ok {
+		b = newBatcher(
+			func(ctx context.Context, ids []uuid.UUID) ([]domain.Subscription, error) {
+				return l.subs.List(ctx, domain.ListFilter{UserIDs: ids, AsOf: asOf})
+			},
+			func(s domain.Subscription) uuid.UUID { return s.UserID },
+		)
+		l.users[key] = b
+	}
+	return b
+}
+
+// byService returns the batcher of the subscriptions of services at asOf.
+func (l *loaders) byService(asOf *time.Time) *batcher[string] {
+	key := asOfKey(asOf)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.services[key]
+	if !ok {
+		b = newBatcher(
+			func(ctx context.Context, names []string) ([]domain.Subscription, error) {
+				return l.subs.List(ctx, domain.ListFilter{ServiceNames: names, AsOf: asOf})
+			},
+			func(s domain.Subscription) string { return s.ServiceName },
+		)
+		l.services[key] = b
+	}
+	return b
+}
+
+// active returns all subscriptions visible to the caller that are active
+// in the period.
+func (l *loaders) active(ctx context.Context, p service.Period, asOf *time.Time) ([]domain.Subscription, error) {
+	key := activeKey{start: p.Start, end: p.End, asOf: asOfKey(asOf)}
+
+	l.mu.Lock()
+	e, ok := l.periods[key]
+	if !ok {
+		e = &entry{}
+		l.periods[key] = e
+	}
+	l.mu.Unlock()
+
+	e.once.Do(func() {
+		e.items, e.err = l.agg.Active(ctx, p, asOf)
+	})
+	return e.items, e.err
+}
+
+// asOfKey formats an optional point in time as a map key.
+func asOfKey(asOf *time.Time) string {
+	if asOf == nil {
+		return ""
+	}
+	return asOf.UTC().Format(time.RFC3339Nano)
+}
+
+// batcher loads the subscriptions of many users or services with one
+// filtered storage call. Resolvers creating nodes announce their keys
+// with want; the first node asking loads the announced keys together.
+type batcher[K comparable] struct {
+	load func(ctx context.Context, keys []K) ([]domain.Subscription, error)
+	key  func(domain.Subscription) K
+
+	mu      sync.Mutex
+	pending []K
+	batches map[K]*batch[K]
+}
+
+// batch is one load shared by all nodes of its keys.
+type batch[K comparable] struct {
+	keys  []K
+	once  sync.Once
+	items map[K][]domain.Subscription
+	err   error
+}
+
+// newBatcher creates a batcher loading with load and grouping by key.
+func newBatcher[K comparable](
+	load func(ctx context.Context, keys []K) ([]domain.Subscription, error),
+	key func(domain.Subscription) K,
+) *batcher[K] {
+
+	return &batcher[K]{load: load, key: key, batches: make(map[K]*batch[K])}
+}
+
+// want announces keys that nodes will ask for.
+func (b *batcher[K]) want(keys ...K) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, k := range keys {
+		if _, ok := b.batches[k]; !ok && !slices.Contains(b.pending, k) {
+			b.pending = append(b.pending, k)
+		}
+	}
+}
+
+// get returns the subscriptions of k, loading it with the pending keys.
+func (b *batcher[K]) get(ctx context.Context, k K) ([]domain.Subscription, error) {
+	b.mu.Lock()
+	bt, ok := b.batches[k]
+	if !ok {
+		keys := b.pending
+		if !slices.Contains(keys, k) {
+			keys = append(keys, k)
+		}
+		b.pending = nil
+
+		bt = &batch[K]{keys: keys}
+		for _, key := range keys {
+			b.batches[key] = bt
+		}
+	}
+	b.mu.Unlock()
+
+	bt.once.Do(func() {
+		items, err := b.load(ctx, bt.keys)
+		bt.items, bt.err = make(map[K][]domain.Subscription), err
+		for _, s := range items {
+			bt.items[b.key(s)] = append(bt.items[b.key(s)], s)
+		}
+	})
+	return bt.items[k], bt.err
+}
@@ -0,0 +1,445 @@
+package graphqlapi
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/utils"
+	"github.com/google/uuid"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// rootResolver holds the resolvers of the operation types. A separate
+// query resolver keeps Query.subscription apart from the subscription
+// operation type.
+type rootResolver struct {
+	query *queryResolver
+}
+
+// Query returns the resolver of the Query type.
+func (r *rootResolver) Query() *queryResolver { return r.query }
+
+// queryResolver resolves the Query type.
+type queryResolver struct {
+	subs *service.SubscriptionService
+	agg  *service.AggregationService
+}
+
+// subscriptionFilter is the SubscriptionFilter input.
+type subscriptionFilter struct {
+	UserID      *graphql.ID
+	ServiceName *string
+	AsOf        *graphql.Time
+}
+
+// filterArgs are the arguments of list fields.
+type filterArgs struct {
+	Filter *subscriptionFilter
+}
+
+// periodArgs select the months of a total.
+type periodArgs struct {
+	StartDate string
+	EndDate   string
+}
+
+// Subscription resolves Query.subscription.
+func (r *queryResolver) Subscription(ctx context.Context, args struct {
+	ID   graphql.ID
+	AsOf *graphql.Time
+}) (*subscriptionResolver, error) {
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, toQueryError(ctx, "subscription: invalid request", err)
+	}
+
+	asOf := timeArg(args.AsOf)
+	sub, err := r.subs.Get(ctx, id, asOf)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toQueryError(ctx, "subscription failed", err)
+	}
+
+	return &subscriptionResolver{sub: sub, asOf: asOf}, nil
+}
+
+// Subscriptions resolves Query.subscriptions.
+func (r *queryResolver) Subscriptions(ctx context.Context, args filterArgs) ([]*subscriptionResolver, error) {
+	items, asOf, err := r.list(ctx, args.Filter)
+	if err != nil {
+		return nil, toQueryError(ctx, "subscriptions failed", err)
+	}
+
+	return subscriptionResolvers(ctx, items, asOf), nil
+}
+
+// User resolves Query.user. Scoped callers only see themselves.
+func (r *queryResolver) User(ctx context.Context, args struct {
+	ID   graphql.ID
+	AsOf *graphql.Time
+}) (*userResolver, error) {
+
+	id, err := parseID("id", args.ID)
+	if err != nil {
+		return nil, toQueryError(ctx, "user: invalid request", err)
+	}
+	if uid := auth.ScopedUserID(ctx); uid != nil && *uid != id {
+		return nil, nil
+	}
+
+	return &userResolver{id: id, asOf: timeArg(args.AsOf)}, nil
+}
+
+// Users resolves Query.users.
+func (r *queryResolver) Users(ctx context.Context, args filterArgs) ([]*userResolver, error) {
+	items, asOf, err := r.list(ctx, args.Filter)
+	if err != nil {
+		return nil, toQueryError(ctx, "users failed", err)
+	}
+
+	return userResolvers(ctx, items, asOf), nil
+}
+
+// Service resolves Query.service.
+func (r *queryResolver) Service(args struct {
+	Name string
+	AsOf *graphql.Time
+}) *serviceResolver {
+
+	return &serviceResolver{name: strings.TrimSpace(args.Name), asOf: timeArg(args.AsOf)}
+}
+
+// Services resolves Query.services.
+func (r *queryResolver) Services(ctx context.Context, args filterArgs) ([]*serviceResolver, error) {
+	items, asOf, err := r.list(ctx, args.Filter)
+	if err != nil {
+		return nil, toQueryError(ctx, "services failed", err)
+	}
+
+	names := make([]string, 0)
+	for _, s := range items {
+		if !slices.Contains(names, s.ServiceName) {
+			names = append(names, s.ServiceName)
+		}
+	}
+	slices.Sort(names)
+	loadersFrom(ctx).byService(asOf).want(names...)
+
+	out := make([]*serviceResolver, len(names))
+	for i, name := range names {
+		out[i] = &serviceResolver{name: name, asOf: asOf}
+	}
+	return out, nil
+}
+
+// list returns the subscriptions matching a filter.
+func (r *queryResolver) list(ctx context.Context, in *subscriptionFilter) ([]domain.Subscription, *time.Time, error) {
+	if in == nil {
+		in = &subscriptionFilter{}
+	}
+
+	f := domain.ListFilter{AsOf: timeArg(in.AsOf)}
+	if in.UserID != nil {
+		id, err := parseID("user_id", *in.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		f.UserID = &id
+	}
+	if in.ServiceName != nil {
+		if v := strings.TrimSpace(*in.ServiceName); v != "" {
+			f.ServiceName = &v
+		}
+	}
+
+	// Unfiltered lists are shared with nested fields
+	if f.UserID == nil && f.ServiceName == nil {
+		items, err := loadersFrom(ctx).subscriptions(ctx, f.AsOf)
+		return items, f.AsOf, err
+	}
+
+	items, err := r.subs.List(ctx, f)
+	return items, f.AsOf, err
+}
+
+// subscriptionResolver resolves the Subscription type.
+type subscriptionResolver struct {
+	sub  domain.Subscription
+	asOf *time.Time
+}
+
+func (s *subscriptionResolver) ID() graphql.ID      { return graphql.ID(s.sub.ID.String()) }
+func (s *subscriptionResolver) ServiceName() string { return s.sub.ServiceName }
+func (s *subscriptionResolver) Price() int32        { return int32(s.sub.Price) }
+func (s *subscriptionResolver) UserID() graphql.ID  { return graphql.ID(s.sub.UserID.String()) }
+func (s *subscriptionResolver) StartDate() string   { return utils.FormatMonthYear(s.sub.StartDate) }
+
+func (s *subscriptionResolver) EndDate() *string {
+	if s.sub.EndDate == nil {
+		return nil
+	}
+	v := utils.FormatMonthYear(*s.sub.EndDate)
+	return &v
+}
+
+func (s *subscriptionResolver) User() *userResolver {
+	return &userResolver{id: s.sub.UserID, asOf: s.asOf}
+}
+
+func (s *subscriptionResolver) Service() *serviceResolver {
+	return &serviceResolver{name: s.sub.ServiceName, asOf: s.asOf}
+}
+
+// Total is computed from the subscription itself, without storage calls.
+func (s *subscriptionResolver) Total(ctx context.Context, args periodArgs) (int32, error) {
+	if err := requireScope(ctx, auth.ScopeAggregationRead); err != nil {
+		return 0, err
+	}
+
+	p, err := service.ParsePeriod(args.StartDate, args.EndDate)
+	if err != nil {
+		return 0, toQueryError(ctx, "Subscription.total: invalid request", err)
+	}
+
+	total, _ := service.Breakdown([]domain.Subscription{s.sub}, p)
+	return toInt(total)
+}
+
+// userResolver resolves the User type.
+type userResolver struct {
+	id   uuid.UUID
+	asOf *time.Time
+}
+
+// userArgs select the period and services of a user's total.
+type userArgs struct {
+	StartDate   string
+	EndDate     string
+	ServiceName *string
+}
+
+func (u *userResolver) ID() graphql.ID { return graphql.ID(u.id.String()) }
+
+func (u *userResolver) Subscriptions(ctx context.Context, args struct{ ServiceName *string }) ([]*subscriptionResolver, error) {
+	items, err := loadersFrom(ctx).byUser(u.asOf).get(ctx, u.id)
+	if err != nil {
+		return nil, toQueryError(ctx, "User.subscriptions failed", err)
+	}
+
+	return subscriptionResolvers(ctx, filter(items, nil, args.ServiceName), u.asOf), nil
+}
+
+func (u *userResolver) Total(ctx context.Context, args userArgs) (int32, error) {
+	total, _, err := spend(ctx, "User.total", args.StartDate, args.EndDate, u.asOf, &u.id, args.ServiceName)
+	if err != nil {
+		return 0, err
+	}
+	return toInt(total)
+}
+
+func (u *userResolver) MonthlySpend(ctx context.Context, args userArgs) ([]*monthlySpendResolver, error) {
+	_, months, err := spend(ctx, "User.monthlySpend", args.StartDate, args.EndDate, u.asOf, &u.id, args.ServiceName)
+	return months, err
+}
+
+// serviceResolver resolves the Service type.
+type serviceResolver struct {
+	name string
+	asOf *time.Time
+}
+
+// serviceArgs select the period and users of a service's total.
+type serviceArgs struct {
+	StartDate string
+	EndDate   string
+	UserID    *graphql.ID
+}
+
+func (s *serviceResolver) Name() string { return s.name }
+
+func (s *serviceResolver) Subscriptions(ctx context.Context, args struct{ UserID *graphql.ID }) ([]*subscriptionResolver, error) {
+	userID, err := optionalID("user_id", args.UserID)
+	if err != nil {
+		return nil, toQueryError(ctx, "Service.subscriptions: invalid request", err)
+	}
+
+	items, err := loadersFrom(ctx).byService(s.asOf).get(ctx, s.name)
+	if err != nil {
+		return nil, toQueryError(ctx, "Service.subscriptions failed", err)
+	}
+
+	return subscriptionResolvers(ctx, filter(items, userID, nil), s.asOf), nil
+}
+
+func (s *serviceResolver) Subscribers(ctx context.Context) ([]*userResolver, error) {
+	items, err := loadersFrom(ctx).byService(s.asOf).get(ctx, s.name)
+	if err != nil {
+		return nil, toQueryError(ctx, "Service.subscribers failed", err)
+	}
+
+	return userResolvers(ctx, items, s.asOf), nil
+}
+
+func (s *serviceResolver) Total(ctx context.Context, args serviceArgs) (int32, error) {
+	userID, err := optionalID("user_id", args.UserID)
+	if err != nil {
+		return 0, toQueryError(ctx, "Service.total: invalid request", err)
+	}
+
+	total, _, err := spend(ctx, "Service.total", args.StartDate, args.EndDate, s.asOf, userID, &s.name)
+	if err != nil {
+		return 0, err
+	}
+	return toInt(total)
+}
+
+func (s *serviceResolver) MonthlySpend(ctx context.Context, args serviceArgs) ([]*monthlySpendResolver, error) {
+	userID, err := optionalID("user_id", args.UserID)
+	if err != nil {
+		return nil, toQueryError(ctx, "Service.monthlySpend: invalid request", err)
+	}
+
+	_, months, err := spend(ctx, "Service.monthlySpend", args.StartDate, args.EndDate, s.asOf, userID, &s.name)
+	return months, err
+}
+
+// monthlySpendResolver resolves the MonthlySpend type.
+type monthlySpendResolver struct {
+	m service.MonthlyTotal
+}
+
+func (m *monthlySpendResolver) Month() string         { return utils.FormatMonthYear(m.m.Month) }
+func (m *monthlySpendResolver) Total() (int32, error) { return toInt(m.m.Total) }
+
+// spend sums the active subscriptions of a period matching the optional
+// user and service. All nodes asking for the same period share one
+// storage call.
+func spend(
+	ctx context.Context,
+	field, startDate, endDate string,
+	asOf *time.Time,
+	userID *uuid.UUID,
+	serviceName *string,
+) (int, []*monthlySpendResolver, error) {
+
+	if err := requireScope(ctx, auth.ScopeAggregationRead); err != nil {
+		return 0, nil, err
+	}
+
+	p, err := service.ParsePeriod(startDate, endDate)
+	if err != nil {
+		return 0, nil, toQueryError(ctx, field+": invalid request", err)
+	}
+
+	items, err := loadersFrom(ctx).active(ctx, p, asOf)
+	if err != nil {
+		return 0, nil, toQueryError(ctx, field+" failed", err)
+	}
+
+	total, months := service.Breakdown(filter(items, userID, serviceName), p)
+
+	out := make([]*monthlySpendResolver, len(months))
+	for i, m := range months {
+		out[i] = &monthlySpendResolver{m: m}
+	}
+	return total, out, nil
+}
+
+// filter returns the subscriptions of the optional user and service.
+func filter(items []domain.Subscription, userID *uuid.UUID, serviceName *string) []domain.Subscription {
+	out := make([]domain.Subscription, 0)
+	for _, s := range items {
+		if userID != nil && s.UserID != *userID {
+			continue
+		}
+		if serviceName != nil && s.ServiceName != *serviceName {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// subscriptionResolvers wraps subscriptions read at asOf, announcing
+// their users and services to the loaders of nested fields.
+func subscriptionResolvers(ctx context.Context, items []domain.Subscription, asOf *time.Time) []*subscriptionResolver {
+	l := loadersFrom(ctx)
+	out := make([]*subscriptionResolver, len(items))
+	for i, s := range items {
+		l.byUser(asOf).want(s.UserID)
+		l.byService(asOf).want(s.ServiceName)
+		out[i] = &subscriptionResolver{sub: s, asOf: asOf}
+	}
+	return out
+}
+
+// userResolvers returns the distinct users of subscriptions in ID order,
+// announcing them to the loader of nested subscriptions.
+func userResolvers(ctx context.Context, items []domain.Subscription, asOf *time.Time) []*userResolver {
+	ids := make([]uuid.UUID, 0)
+	for _, s := range items {
+		if !slices.Contains(ids, s.UserID) {
+			ids = append(ids, s.UserID)
+		}
+	}
+	slices.SortFunc(ids, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	loadersFrom(ctx).byUser(asOf).want(ids...)
+
+	out := make([]*userResolver, len(ids))
+	for i, id := range ids {
+		out[i] = &userResolver{id: id, asOf: asOf}
+	}
+	return out
+}
+
+// toInt converts a total to a GraphQL Int. Totals beyond its 32 bits are
+// an error rather than wrapping around.
+func toInt(total int) (int32, error) {
+	if total > math.MaxInt32 || total < math.MinInt32 {
+		return 0, &queryError{
+			msg:        "total exceeds the Int range, query a shorter period",
+			extensions: map[string]any{"code": "BAD_USER_INPUT"},
+		}
+	}
+	return int32(total), nil
+}
+
+// parseID parses a UUID argument.
+func parseID(field string, id graphql.ID) (uuid.UUID, error) {
+	v, err := uuid.Parse(strings.TrimSpace(string(id)))
+	if err != nil {
+		return uuid.Nil, domain.Invalid(field, "must be a UUID")
+	}
+	return v, nil
+}
+
+// optionalID parses an optional UUID argument.
+func optionalID(field string, id *graphql.ID) (*uuid.UUID, error) {
+	if id == nil {
+		return nil, nil
+	}
+	v, err := parseID(field, *id)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// timeArg converts an optional Time argument.
+func timeArg(t *graphql.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
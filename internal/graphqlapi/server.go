@@ -0,0 +1,100 @@
+// Package graphqlapi answers GraphQL queries that combine subscription
+// lists with nested cost totals, backed by the service layer.
+package graphqlapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"log/slog"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+// schema is the GraphQL schema served by Server.
+//
+//go:embed schema.graphql
+var schema string
+
+// maxDepth limits the nesting of queries.
+const maxDepth = 8
+
+// Server executes GraphQL queries.
+type Server struct {
+	schema *graphql.Schema
+	subs   *service.SubscriptionService
+	agg    *service.AggregationService
+}
+
+// NewServer creates a GraphQL server on the services.
+func NewServer(subs *service.SubscriptionService, agg *service.AggregationService) *Server {
+	r := &rootResolver{query: &queryResolver{subs: subs, agg: agg}}
+	return &Server{
+		schema: graphql.MustParseSchema(schema, r, graphql.MaxDepth(maxDepth)),
+		subs:   subs,
+		agg:    agg,
+	}
+}
+
+// Exec runs a query with fresh loaders, so lookups are shared by the
+// nodes of this query only.
+func (s *Server) Exec(
+	ctx context.Context,
+	query, operationName string,
+	variables map[string]any,
+) *graphql.Response {
+
+	ctx = withLoaders(ctx, s.subs, s.agg)
+	return s.schema.Exec(ctx, query, operationName, variables)
+}
+
+// queryError is a resolver error with a machine-readable code.
+type queryError struct {
+	msg        string
+	extensions map[string]any
+}
+
+func (e *queryError) Error() string { return e.msg }
+
+// Extensions is added to the GraphQL error.
+func (e *queryError) Extensions() map[string]any { return e.extensions }
+
+// toQueryError logs a failed service call and maps its error to a
+// GraphQL error. Validation errors carry the invalid fields.
+func toQueryError(ctx context.Context, msg string, err error) error {
+	var verr *domain.ValidationError
+
+	level := slog.LevelWarn
+	defer func() { slog.Log(ctx, level, msg, "error", err) }()
+
+	switch {
+	case errors.As(err, &verr):
+		return &queryError{msg: verr.Error(), extensions: map[string]any{
+			"code":   "BAD_USER_INPUT",
+			"errors": verr.Fields,
+		}}
+	case errors.Is(err, domain.ErrInvalidInput):
+		return &queryError{msg: err.Error(), extensions: map[string]any{"code": "BAD_USER_INPUT"}}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &queryError{msg: "timeout", extensions: map[string]any{"code": "TIMEOUT"}}
+	default:
+		level = slog.LevelError
+		return &queryError{msg: "db error", extensions: map[string]any{"code": "INTERNAL"}}
+	}
+}
+
+// requireScope rejects callers whose credentials do not grant scope.
+// Unauthenticated calls pass, the HTTP layer only lets them through when
+// anonymous access is allowed.
+func requireScope(ctx context.Context, scope string) error {
+	if p, ok := auth.PrincipalFrom(ctx); ok && !p.HasScope(scope) {
+		return &queryError{
+			msg:        "insufficient scope: " + scope + " required",
+			extensions: map[string]any{"code": "FORBIDDEN"},
+		}
+	}
+	return nil
+}
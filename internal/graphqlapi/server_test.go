@@ -0,0 +1,331 @@
+package graphqlapi
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/memory"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// countingStore counts the list calls made to the wrapped store and
+// records their filters.
+type countingStore struct {
+	domain.SubscriptionStore
+	lists       atomic.Int32
+	overlapping atomic.Int32
+
+	mu      sync.Mutex
+	filters []domain.ListFilter
+}
+
+func (s *countingStore) List(ctx context.Context, f domain.ListFilter) ([]domain.Subscription, error) {
+	s.lists.Add(1)
+	s.mu.Lock()
+	s.filters = append(s.filters, f)
+	s.mu.Unlock()
+	return s.SubscriptionStore.List(ctx, f)
+}
+
+func (s *countingStore) ListOverlapping(
+	ctx context.Context,
+	userID *uuid.UUID,
+	serviceName *string,
+	periodStart, periodEnd time.Time,
+	asOf *time.Time,
+) ([]domain.Subscription, error) {
+
+	s.overlapping.Add(1)
+	return s.SubscriptionStore.ListOverlapping(ctx, userID, serviceName, periodStart, periodEnd, asOf)
+}
+
+// newTestServer creates a server on a counting in-memory store with the
+// given subscriptions of tenant "acme".
+func newTestServer(t *testing.T, subs ...service.SubscriptionInput) (*Server, *countingStore, context.Context) {
+	t.Helper()
+
+	store := &countingStore{SubscriptionStore: memory.NewSubscriptionStore()}
+	subSvc := service.NewSubscriptionService(store)
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	for _, in := range subs {
+		if _, err := subSvc.Create(ctx, in); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	store.lists.Store(0)
+	store.filters = nil
+
+	return NewServer(subSvc, service.NewAggregationService(store)), store, ctx
+}
+
+// sub returns a subscription input.
+func sub(userID uuid.UUID, serviceName string, price int, start, end string) service.SubscriptionInput {
+	return service.SubscriptionInput{
+		ServiceName: serviceName,
+		Price:       &price,
+		UserID:      userID.String(),
+		StartDate:   start,
+		EndDate:     end,
+	}
+}
+
+// exec runs a query and decodes its data into out.
+func exec(t *testing.T, s *Server, ctx context.Context, query string, out any) {
+	t.Helper()
+
+	resp := s.Exec(ctx, query, "", nil)
+	if len(resp.Errors) > 0 {
+		t.Fatalf("query errors: %v", resp.Errors)
+	}
+	if err := json.Unmarshal(resp.Data, out); err != nil {
+		t.Fatalf("decode %s: %v", resp.Data, err)
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// queries
+// ==============================================================
+// ==============================================================
+func TestSubscriptions_WithNestedUserAndService(t *testing.T) {
+	// Arrange
+	alice := uuid.New()
+	s, _, ctx := newTestServer(t, sub(alice, "Netflix", 500, "01-2025", "03-2025"))
+
+	// Act
+	var data struct {
+		Subscriptions []struct {
+			ServiceName string
+			Price       int
+			StartDate   string
+			EndDate     *string
+			Total       int
+			User        struct{ ID string }
+			Service     struct{ Name string }
+		}
+	}
+	exec(t, s, ctx, `{
+		subscriptions(filter: {serviceName: "Netflix"}) {
+			serviceName price startDate endDate
+			total(startDate: "02-2025", endDate: "12-2025")
+			user { id }
+			service { name }
+		}
+	}`, &data)
+
+	// Assert
+	if len(data.Subscriptions) != 1 {
+		t.Fatalf("expected 1 subscription, got %+v", data.Subscriptions)
+	}
+	got := data.Subscriptions[0]
+	if got.Price != 500 || got.StartDate != "01-2025" || got.EndDate == nil || *got.EndDate != "03-2025" {
+		t.Errorf("unexpected subscription %+v", got)
+	}
+	if got.Total != 1000 {
+		t.Errorf("expected total 1000, got %d", got.Total)
+	}
+	if got.User.ID != alice.String() || got.Service.Name != "Netflix" {
+		t.Errorf("unexpected user or service %+v", got)
+	}
+}
+
+func TestUsers_NestedTotalsShareOneLookup(t *testing.T) {
+	// Arrange
+	alice, bob := uuid.New(), uuid.New()
+	s, store, ctx := newTestServer(t,
+		sub(alice, "Netflix", 500, "01-2025", "03-2025"),
+		sub(alice, "Spotify", 200, "01-2025", ""),
+		sub(bob, "Netflix", 700, "02-2025", ""),
+	)
+
+	// Act
+	var data struct {
+		Users []struct {
+			ID            string
+			Total         int
+			Subscriptions []struct{ ServiceName string }
+			MonthlySpend  []struct {
+				Month string
+				Total int
+			}
+		}
+		Services []struct {
+			Name        string
+			Total       int
+			Subscribers []struct{ ID string }
+		}
+	}
+	exec(t, s, ctx, `{
+		users {
+			id
+			total(startDate: "01-2025", endDate: "03-2025")
+			subscriptions { serviceName }
+			monthlySpend(startDate: "01-2025", endDate: "03-2025") { month total }
+		}
+		services {
+			name
+			total(startDate: "01-2025", endDate: "03-2025")
+			subscribers { id }
+		}
+	}`, &data)
+
+	// Assert
+	totals := map[string]int{}
+	for _, u := range data.Users {
+		totals[u.ID] = u.Total
+	}
+	if totals[alice.String()] != 2100 || totals[bob.String()] != 1400 {
+		t.Errorf("expected totals 2100 and 1400, got %v", totals)
+	}
+	for _, u := range data.Users {
+		if u.ID == alice.String() && (len(u.Subscriptions) != 2 || len(u.MonthlySpend) != 3 || u.MonthlySpend[0].Total != 700) {
+			t.Errorf("unexpected nested data of alice %+v", u)
+		}
+	}
+	if len(data.Services) != 2 || data.Services[0].Name != "Netflix" || data.Services[0].Total != 2900 ||
+		len(data.Services[0].Subscribers) != 2 {
+		t.Errorf("unexpected services %+v", data.Services)
+	}
+
+	// One list shared by the root fields, one for the nested
+	// subscriptions of all users and one for those of all services, and
+	// one overlap lookup for all nested totals of the same period
+	if n := store.lists.Load(); n != 3 {
+		t.Errorf("expected 3 List calls, got %d", n)
+	}
+	if n := store.overlapping.Load(); n != 1 {
+		t.Errorf("expected 1 ListOverlapping call, got %d", n)
+	}
+}
+
+func TestUser_LoadsOnlyRequestedSubscriptions(t *testing.T) {
+	// Arrange
+	alice, bob := uuid.New(), uuid.New()
+	s, store, ctx := newTestServer(t,
+		sub(alice, "Netflix", 500, "01-2025", ""),
+		sub(bob, "Netflix", 700, "01-2025", ""),
+	)
+
+	// Act
+	var data struct {
+		User struct {
+			Subscriptions []struct{ UserID string }
+		}
+	}
+	exec(t, s, ctx, `{ user(id: "`+alice.String()+`") { subscriptions { userId } } }`, &data)
+
+	// Assert
+	if len(data.User.Subscriptions) != 1 || data.User.Subscriptions[0].UserID != alice.String() {
+		t.Errorf("expected alice's subscription, got %+v", data.User.Subscriptions)
+	}
+	if len(store.filters) != 1 || len(store.filters[0].UserIDs) != 1 || store.filters[0].UserIDs[0] != alice {
+		t.Errorf("expected one list filtered by alice, got %+v", store.filters)
+	}
+}
+
+func TestSubscription_MissingIsNull(t *testing.T) {
+	// Arrange
+	s, _, ctx := newTestServer(t)
+
+	// Act
+	var data struct{ Subscription *struct{ ID string } }
+	exec(t, s, ctx, `{ subscription(id: "`+uuid.NewString()+`") { id } }`, &data)
+
+	// Assert
+	if data.Subscription != nil {
+		t.Errorf("expected null, got %+v", data.Subscription)
+	}
+}
+
+func TestScopedCaller_SeesOwnDataOnly(t *testing.T) {
+	// Arrange
+	alice, bob := uuid.New(), uuid.New()
+	s, _, ctx := newTestServer(t,
+		sub(alice, "Netflix", 500, "01-2025", "03-2025"),
+		sub(bob, "Netflix", 700, "01-2025", "03-2025"),
+	)
+	ctx = auth.WithPrincipal(ctx, auth.Principal{Subject: alice.String(), UserID: alice})
+
+	// Act
+	var data struct {
+		Users   []struct{ ID string }
+		Service struct{ Total int }
+		User    *struct{ ID string }
+	}
+	exec(t, s, ctx, `{
+		users { id }
+		service(name: "Netflix") { total(startDate: "01-2025", endDate: "01-2025") }
+		user(id: "`+bob.String()+`") { id }
+	}`, &data)
+
+	// Assert
+	if len(data.Users) != 1 || data.Users[0].ID != alice.String() {
+		t.Errorf("expected only alice, got %+v", data.Users)
+	}
+	if data.Service.Total != 500 {
+		t.Errorf("expected own total 500, got %d", data.Service.Total)
+	}
+	if data.User != nil {
+		t.Errorf("expected other user to be null, got %+v", data.User)
+	}
+}
+
+func TestTotals_BeyondIntAreErrors(t *testing.T) {
+	// Arrange
+	s, _, ctx := newTestServer(t, sub(uuid.New(), "Netflix", math.MaxInt32, "01-2025", ""))
+
+	// Act
+	resp := s.Exec(ctx, `{ service(name: "Netflix") { total(startDate: "01-2025", endDate: "02-2025") } }`, "", nil)
+
+	// Assert
+	if len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != "BAD_USER_INPUT" {
+		t.Fatalf("expected a BAD_USER_INPUT error instead of a wrapped total, got %+v", resp.Errors)
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// errors
+// ==============================================================
+// ==============================================================
+func TestErrors(t *testing.T) {
+	// Arrange
+	s, _, ctx := newTestServer(t)
+	apiKey := auth.WithPrincipal(ctx, auth.Principal{Admin: true, Scopes: []string{auth.ScopeSubscriptionsRead}})
+
+	cases := []struct {
+		name  string
+		ctx   context.Context
+		query string
+		code  string
+	}{
+		{"invalid period", ctx, `{ service(name: "Netflix") { total(startDate: "13-2025", endDate: "") } }`, "BAD_USER_INPUT"},
+		{"invalid id", ctx, `{ subscription(id: "nope") { id } }`, "BAD_USER_INPUT"},
+		{"missing scope", apiKey, `{ service(name: "Netflix") { total(startDate: "01-2025", endDate: "02-2025") } }`, "FORBIDDEN"},
+		{"unknown field", ctx, `{ nope }`, ""},
+	}
+
+	for _, tc := range cases {
+		// Act
+		resp := s.Exec(tc.ctx, tc.query, "", nil)
+
+		// Assert
+		if len(resp.Errors) == 0 {
+			t.Errorf("%s: expected errors", tc.name)
+			continue
+		}
+		if tc.code != "" && resp.Errors[0].Extensions["code"] != tc.code {
+			t.Errorf("%s: expected code %s, got %v", tc.name, tc.code, resp.Errors[0].Extensions)
+		}
+	}
+}
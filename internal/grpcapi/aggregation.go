@@ -0,0 +1,54 @@
+package grpcapi
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/DevSchmied/subscription-aggregation-service/internal/grpcapi/subscriptionsv1"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/utils"
+)
+
+// aggregationServer adapts AggregationService to gRPC.
+type aggregationServer struct {
+	pb.UnimplementedAggregationServiceServer
+
+	svc       *service.AggregationService
+	dbTimeout time.Duration
+}
+
+// Total returns the subscription cost of a period broken down by month.
+func (s *aggregationServer) Total(ctx context.Context, req *pb.TotalRequest) (*pb.TotalResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.dbTimeout)
+	defer cancel()
+
+	res, err := s.svc.Total(ctx, service.TotalQuery{
+		StartDate:   req.GetStartDate(),
+		EndDate:     req.GetEndDate(),
+		UserID:      req.GetUserId(),
+		ServiceName: req.GetServiceName(),
+		AsOf:        asOf(req.GetAsOf()),
+	})
+	if err != nil {
+		return nil, statusError(ctx, "Total failed", err)
+	}
+
+	resp := &pb.TotalResponse{
+		PeriodStart:   utils.FormatMonthYear(res.PeriodStart),
+		PeriodEnd:     utils.FormatMonthYear(res.PeriodEnd),
+		Subscriptions: int32(res.Subscriptions),
+		Total:         int64(res.Total),
+		Months:        make([]*pb.MonthlyTotal, len(res.Months)),
+	}
+	if res.UserID != nil {
+		resp.UserId = res.UserID.String()
+	}
+	if res.ServiceName != nil {
+		resp.ServiceName = *res.ServiceName
+	}
+	for i, m := range res.Months {
+		resp.Months[i] = &pb.MonthlyTotal{Month: utils.FormatMonthYear(m.Month), Total: int64(m.Total)}
+	}
+
+	return resp, nil
+}
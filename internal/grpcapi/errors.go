@@ -0,0 +1,63 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps a service error to a gRPC status. Validation errors carry
+// the invalid fields as BadRequest details.
+func toStatus(err error) *status.Status {
+	var verr *domain.ValidationError
+
+	switch {
+	case err == nil:
+		return status.New(codes.OK, "")
+	case errors.As(err, &verr):
+		st := status.New(codes.InvalidArgument, verr.Error())
+		br := &errdetails.BadRequest{}
+		for _, f := range verr.Fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       f.Field,
+				Description: f.Message,
+			})
+		}
+		if withDetails, err := st.WithDetails(br); err == nil {
+			st = withDetails
+		}
+		return st
+	case errors.Is(err, domain.ErrInvalidInput):
+		return status.New(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return status.New(codes.NotFound, "not found")
+	case errors.Is(err, domain.ErrConflict):
+		return status.New(codes.AlreadyExists, "subscription overlaps an existing one")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.New(codes.DeadlineExceeded, "timeout")
+	case errors.Is(err, context.Canceled):
+		return status.New(codes.Canceled, "canceled")
+	default:
+		return status.New(codes.Internal, "db error")
+	}
+}
+
+// statusError logs a failed service call and returns its gRPC error.
+// Failures mapped to client errors, such as not found, are warnings;
+// the rest are errors.
+func statusError(ctx context.Context, msg string, err error) error {
+	st := toStatus(err)
+
+	level := slog.LevelWarn
+	if st.Code() == codes.Internal {
+		level = slog.LevelError
+	}
+	slog.Log(ctx, level, msg, "error", err)
+
+	return st.Err()
+}
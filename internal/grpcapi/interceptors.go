@@ -0,0 +1,234 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"unicode"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/audit"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	pb "github.com/DevSchmied/subscription-aggregation-service/internal/grpcapi/subscriptionsv1"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys read from incoming calls, the lower-case counterparts of
+// the REST headers.
+const (
+	requestIDKey     = "x-request-id"
+	authorizationKey = "authorization"
+	apiKeyKey        = "x-api-key"
+	tenantKey        = "x-tenant-id"
+	actorKey         = "x-actor"
+)
+
+// maxMetadataLen limits client supplied actor and request ID values.
+const maxMetadataLen = 128
+
+// methodScopes lists the API methods and the scope each requires.
+// Methods not listed, such as health checks, skip authentication.
+var methodScopes = map[string]string{
+	pb.SubscriptionService_CreateSubscription_FullMethodName: auth.ScopeSubscriptionsWrite,
+	pb.SubscriptionService_GetSubscription_FullMethodName:    auth.ScopeSubscriptionsRead,
+	pb.SubscriptionService_UpdateSubscription_FullMethodName: auth.ScopeSubscriptionsWrite,
+	pb.SubscriptionService_DeleteSubscription_FullMethodName: auth.ScopeSubscriptionsWrite,
+	pb.SubscriptionService_ListSubscriptions_FullMethodName:  auth.ScopeSubscriptionsRead,
+	pb.AggregationService_Total_FullMethodName:               auth.ScopeAggregationRead,
+}
+
+// logCalls assigns a request ID, or propagates a valid one from the
+// client, returns it in the response header and writes one record per
+// call once it completes.
+func logCalls() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		id := firstValue(ctx, requestIDKey)
+		if id == "" || !printable(id) {
+			id = uuid.NewString()
+		}
+		_ = grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+
+		ctx = logging.NewRequest(ctx, id)
+		logging.Add(ctx, slog.String("route", info.FullMethod))
+
+		resp, err := next(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		switch code {
+		case codes.OK:
+		case codes.Internal, codes.Unknown, codes.Unavailable, codes.DataLoss:
+			level = slog.LevelError
+		default:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", info.FullMethod),
+			slog.String("code", code.String()),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		slog.LogAttrs(ctx, level, "grpc call", attrs...)
+
+		return resp, err
+	}
+}
+
+// recoverPanics turns handler panics into Internal errors and logs them
+// with the stack.
+func recoverPanics() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				slog.ErrorContext(ctx, "panic recovered",
+					"panic", rec,
+					"stack", string(debug.Stack()),
+				)
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return next(ctx, req)
+	}
+}
+
+// authenticate resolves the caller from x-api-key or a JWT bearer token
+// in authorization metadata, as the REST API does. Calls without
+// credentials are rejected unless allowAnonymous is set.
+func authenticate(v *auth.Verifier, keys auth.APIKeyStore, allowAnonymous bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		if _, ok := methodScopes[info.FullMethod]; !ok {
+			return next(ctx, req)
+		}
+
+		var (
+			p   auth.Principal
+			err error
+		)
+
+		switch key, bearer := firstValue(ctx, apiKeyKey), firstValue(ctx, authorizationKey); {
+		case key != "" && keys != nil:
+			p, err = auth.AuthenticateAPIKey(ctx, keys, key)
+		case v != nil && (bearer != "" || !allowAnonymous):
+			p, err = auth.AuthenticateBearer(v, bearer)
+		case !allowAnonymous:
+			err = auth.ErrMissingCredentials
+		default:
+			// Authentication disabled
+			return next(ctx, req)
+		}
+
+		if err != nil {
+			slog.WarnContext(ctx, "authentication rejected", "error", err)
+
+			switch {
+			case errors.Is(err, auth.ErrUnavailable):
+				return nil, status.Error(codes.Unavailable, auth.ErrUnavailable.Error())
+			case errors.Is(err, auth.ErrMissingCredentials):
+				return nil, status.Error(codes.Unauthenticated, auth.ErrMissingCredentials.Error())
+			case errors.Is(err, auth.ErrInvalidAPIKey):
+				return nil, status.Error(codes.Unauthenticated, auth.ErrInvalidAPIKey.Error())
+			default:
+				return nil, status.Error(codes.Unauthenticated, auth.ErrInvalidToken.Error())
+			}
+		}
+
+		// Make the caller visible to services and log records
+		if p.UserID != uuid.Nil {
+			logging.Add(ctx, slog.String("user_id", p.UserID.String()))
+		}
+		return next(auth.WithPrincipal(ctx, p), req)
+	}
+}
+
+// resolveTenant stores the call tenant and the audit metadata in the
+// context. The actor metadata is only trusted when authentication is
+// disabled.
+func resolveTenant(defaultTenant string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		if _, ok := methodScopes[info.FullMethod]; !ok {
+			return next(ctx, req)
+		}
+
+		p, authenticated := auth.PrincipalFrom(ctx)
+
+		id, err := tenant.Resolve(p.TenantID, firstValue(ctx, tenantKey), defaultTenant, authenticated)
+		if err != nil {
+			if errors.Is(err, tenant.ErrMismatch) || errors.Is(err, tenant.ErrUnbound) {
+				return nil, status.Error(codes.PermissionDenied, err.Error())
+			}
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		m := audit.Meta{Actor: "anonymous", RequestID: logging.RequestID(ctx)}
+		switch {
+		case authenticated:
+			m.Actor = p.Subject
+		case firstValue(ctx, actorKey) != "":
+			m.Actor = firstValue(ctx, actorKey)
+		}
+
+		ctx = tenant.WithID(ctx, id)
+		return next(audit.WithMeta(ctx, m), req)
+	}
+}
+
+// requireScope rejects callers whose credentials do not grant the scope
+// of the method. Unauthenticated calls pass only when allowAnonymous is
+// set.
+func requireScope(allowAnonymous bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			return next(ctx, req)
+		}
+
+		p, ok := auth.PrincipalFrom(ctx)
+		if !ok && !allowAnonymous {
+			return nil, status.Error(codes.Unauthenticated, auth.ErrMissingCredentials.Error())
+		}
+		if ok && !p.HasScope(scope) {
+			return nil, status.Error(codes.PermissionDenied, "insufficient scope: "+scope+" required")
+		}
+		return next(ctx, req)
+	}
+}
+
+// firstValue returns the first trimmed, length-capped value of an
+// incoming metadata key.
+func firstValue(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	vals := md.Get(key)
+	if len(vals) == 0 {
+		return ""
+	}
+
+	v := strings.TrimSpace(vals[0])
+	if key != authorizationKey && key != apiKeyKey && len(v) > maxMetadataLen {
+		v = v[:maxMetadataLen]
+	}
+	return v
+}
+
+// printable reports whether s contains printable ASCII only,
+// so client IDs cannot inject control characters into logs.
+func printable(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
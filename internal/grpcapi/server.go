@@ -0,0 +1,107 @@
+// Package grpcapi serves the subscription and aggregation services over
+// gRPC, next to the REST API and backed by the same service layer.
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	pb "github.com/DevSchmied/subscription-aggregation-service/internal/grpcapi/subscriptionsv1"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Dependencies groups the services and settings of the gRPC server.
+type Dependencies struct {
+	Subscriptions *service.SubscriptionService
+	Aggregation   *service.AggregationService
+
+	// Auth verifies bearer tokens; nil disables JWT authentication
+	Auth *auth.Verifier
+
+	// APIKeys authenticates x-api-key callers; nil disables API keys
+	APIKeys auth.APIKeyStore
+
+	// AllowAnonymous lets calls without credentials through, for
+	// deployments that run with authentication disabled
+	AllowAnonymous bool
+
+	// DefaultTenantID is used for calls without a tenant
+	DefaultTenantID string
+
+	// DBTimeout bounds the storage work of one call
+	DBTimeout time.Duration
+}
+
+// Server is the gRPC server with health checking and reflection.
+type Server struct {
+	grpc   *grpc.Server
+	health *health.Server
+}
+
+// New creates a gRPC server for the subscription and aggregation services.
+func New(d Dependencies) *Server {
+	s := grpc.NewServer(grpc.ChainUnaryInterceptor(
+		logCalls(),
+		recoverPanics(),
+		authenticate(d.Auth, d.APIKeys, d.AllowAnonymous),
+		resolveTenant(d.DefaultTenantID),
+		requireScope(d.AllowAnonymous),
+	))
+
+	pb.RegisterSubscriptionServiceServer(s, &subscriptionServer{svc: d.Subscriptions, dbTimeout: d.DBTimeout})
+	pb.RegisterAggregationServiceServer(s, &aggregationServer{svc: d.Aggregation, dbTimeout: d.DBTimeout})
+
+	// Standard health checking, SERVING until shutdown starts
+	hs := health.NewServer()
+	healthpb.RegisterHealthServer(s, hs)
+	for _, name := range []string{
+		"",
+		pb.SubscriptionService_ServiceDesc.ServiceName,
+		pb.AggregationService_ServiceDesc.ServiceName,
+	} {
+		hs.SetServingStatus(name, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	// Let grpcurl and similar tools discover the services
+	reflection.Register(s)
+
+	return &Server{grpc: s, health: hs}
+}
+
+// Serve serves on ln until Shutdown is called.
+func (s *Server) Serve(ln net.Listener) error {
+	return s.grpc.Serve(ln)
+}
+
+// SetShuttingDown reports NOT_SERVING to health checks, so clients stop
+// routing new calls to this instance.
+func (s *Server) SetShuttingDown() {
+	s.health.Shutdown()
+}
+
+// Shutdown waits for in-flight calls to finish, cutting them off when
+// ctx is done.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.health.Shutdown()
+
+	done := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		<-done
+		return ctx.Err()
+	}
+}
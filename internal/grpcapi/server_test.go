@@ -0,0 +1,462 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	pb "github.com/DevSchmied/subscription-aggregation-service/internal/grpcapi/subscriptionsv1"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/memory"
+	"github.com/google/uuid"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// testSecret signs the bearer tokens of authenticated tests.
+var testSecret = []byte("test-secret")
+
+// testClient is a connection to an in-process server.
+type testClient struct {
+	srv  *Server
+	subs pb.SubscriptionServiceClient
+	agg  pb.AggregationServiceClient
+	conn *grpc.ClientConn
+}
+
+// newTestClient serves d, completed with in-memory services, over bufconn.
+func newTestClient(t *testing.T, d Dependencies) *testClient {
+	t.Helper()
+
+	store := memory.NewSubscriptionStore()
+	d.Subscriptions = service.NewSubscriptionService(store)
+	d.Aggregation = service.NewAggregationService(store)
+	d.DefaultTenantID = "acme"
+	d.DBTimeout = time.Second
+
+	srv := New(d)
+	ln := bufconn.Listen(1 << 20)
+	go func() { _ = srv.Serve(ln) }()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	})
+
+	return &testClient{
+		srv:  srv,
+		subs: pb.NewSubscriptionServiceClient(conn),
+		agg:  pb.NewAggregationServiceClient(conn),
+		conn: conn,
+	}
+}
+
+// netflix returns a valid subscription input for userID.
+func netflix(userID string) *pb.SubscriptionInput {
+	return &pb.SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       500,
+		UserId:      userID,
+		StartDate:   "01-2025",
+		EndDate:     "03-2025",
+	}
+}
+
+// bearer returns a context sending an HS256 token for sub in tenant acme.
+func bearer(t *testing.T, sub string) context.Context {
+	t.Helper()
+
+	segment := func(v any) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+
+	unsigned := segment(map[string]string{"alg": "HS256", "typ": "JWT"}) + "." +
+		segment(map[string]any{
+			"sub":       sub,
+			"tenant_id": "acme",
+			"scope":     auth.ScopeSubscriptionsRead + " " + auth.ScopeSubscriptionsWrite,
+			"exp":       time.Now().Add(time.Hour).Unix(),
+		})
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(unsigned))
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+// expectCode fails the test unless err has the given status code.
+func expectCode(t *testing.T, err error, code codes.Code) {
+	t.Helper()
+	if status.Code(err) != code {
+		t.Fatalf("expected %s, got %v", code, err)
+	}
+}
+
+// fakeAPIKeys serves a single API key.
+type fakeAPIKeys struct {
+	key domain.APIKey
+}
+
+func (f fakeAPIKeys) GetByHash(_ context.Context, hash string) (domain.APIKey, error) {
+	if hash != f.key.Hash {
+		return domain.APIKey{}, domain.ErrNotFound
+	}
+	return f.key, nil
+}
+
+func (f fakeAPIKeys) TouchLastUsed(context.Context, uuid.UUID) error { return nil }
+
+// ==============================================================
+// ==============================================================
+// SubscriptionService
+// ==============================================================
+// ==============================================================
+func TestSubscriptions_CRUD(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{AllowAnonymous: true})
+	ctx := context.Background()
+	userID := uuid.NewString()
+
+	// Act
+	created, err := c.subs.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{Subscription: netflix(userID)})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	in := netflix(userID)
+	in.Price = 700
+	updated, err := c.subs.UpdateSubscription(ctx, &pb.UpdateSubscriptionRequest{Id: created.GetId(), Subscription: in})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	got, err := c.subs.GetSubscription(ctx, &pb.GetSubscriptionRequest{Id: created.GetId()})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	_, delErr := c.subs.DeleteSubscription(ctx, &pb.DeleteSubscriptionRequest{Id: created.GetId()})
+	_, getErr := c.subs.GetSubscription(ctx, &pb.GetSubscriptionRequest{Id: created.GetId()})
+
+	// Assert
+	if created.GetUserId() != userID || created.GetStartDate() != "01-2025" || created.GetEndDate() != "03-2025" {
+		t.Errorf("unexpected created subscription %v", created)
+	}
+	if updated.GetPrice() != 700 || got.GetPrice() != 700 {
+		t.Errorf("expected price 700, got %d and %d", updated.GetPrice(), got.GetPrice())
+	}
+	if delErr != nil {
+		t.Fatalf("delete: %v", delErr)
+	}
+	expectCode(t, getErr, codes.NotFound)
+}
+
+func TestSubscriptions_ValidationDetails(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{AllowAnonymous: true})
+
+	// Act
+	_, err := c.subs.CreateSubscription(context.Background(), &pb.CreateSubscriptionRequest{
+		Subscription: &pb.SubscriptionInput{Price: -1, StartDate: "13-2025"},
+	})
+
+	// Assert
+	expectCode(t, err, codes.InvalidArgument)
+
+	var fields []string
+	for _, d := range status.Convert(err).Details() {
+		if br, ok := d.(*errdetails.BadRequest); ok {
+			for _, v := range br.GetFieldViolations() {
+				fields = append(fields, v.GetField())
+			}
+		}
+	}
+	if len(fields) != 4 {
+		t.Errorf("expected 4 field violations, got %v", fields)
+	}
+}
+
+func TestSubscriptions_Conflict(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{AllowAnonymous: true})
+	req := &pb.CreateSubscriptionRequest{Subscription: netflix(uuid.NewString())}
+	if _, err := c.subs.CreateSubscription(context.Background(), req); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Act
+	_, err := c.subs.CreateSubscription(context.Background(), req)
+
+	// Assert
+	expectCode(t, err, codes.AlreadyExists)
+}
+
+func TestSubscriptions_InvalidID(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{AllowAnonymous: true})
+
+	// Act
+	_, err := c.subs.GetSubscription(context.Background(), &pb.GetSubscriptionRequest{Id: "nope"})
+
+	// Assert
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+func TestSubscriptions_ListPages(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{AllowAnonymous: true})
+	ctx := context.Background()
+	for range 5 {
+		if _, err := c.subs.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{
+			Subscription: netflix(uuid.NewString()),
+		}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	// Act
+	seen := map[string]bool{}
+	pages := 0
+	token := ""
+	for {
+		resp, err := c.subs.ListSubscriptions(ctx, &pb.ListSubscriptionsRequest{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		pages++
+		for _, s := range resp.GetSubscriptions() {
+			seen[s.GetId()] = true
+		}
+		token = resp.GetNextPageToken()
+		if token == "" {
+			break
+		}
+	}
+
+	// Assert
+	if pages != 3 || len(seen) != 5 {
+		t.Errorf("expected 5 subscriptions on 3 pages, got %d on %d", len(seen), pages)
+	}
+}
+
+func TestSubscriptions_ListInvalidRequest(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{AllowAnonymous: true})
+
+	// Act
+	_, err := c.subs.ListSubscriptions(context.Background(), &pb.ListSubscriptionsRequest{
+		UserId:    "nope",
+		PageSize:  maxPageSize + 1,
+		PageToken: "!!",
+	})
+
+	// Assert
+	expectCode(t, err, codes.InvalidArgument)
+}
+
+// ==============================================================
+// ==============================================================
+// AggregationService
+// ==============================================================
+// ==============================================================
+func TestAggregation_Total(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{AllowAnonymous: true})
+	ctx := context.Background()
+	userID := uuid.NewString()
+	if _, err := c.subs.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{Subscription: netflix(userID)}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Act
+	resp, err := c.agg.Total(ctx, &pb.TotalRequest{StartDate: "02-2025", EndDate: "04-2025", UserId: userID})
+	_, invalidErr := c.agg.Total(ctx, &pb.TotalRequest{StartDate: "02-2025"})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("total: %v", err)
+	}
+	if resp.GetTotal() != 1000 || resp.GetSubscriptions() != 1 || resp.GetUserId() != userID {
+		t.Errorf("expected 1000 from 1 subscription of %s, got %v", userID, resp)
+	}
+	if len(resp.GetMonths()) != 3 || resp.GetMonths()[2].GetMonth() != "04-2025" || resp.GetMonths()[2].GetTotal() != 0 {
+		t.Errorf("unexpected months %v", resp.GetMonths())
+	}
+	expectCode(t, invalidErr, codes.InvalidArgument)
+}
+
+// ==============================================================
+// ==============================================================
+// authentication
+// ==============================================================
+// ==============================================================
+func TestAuth_BearerScopesToUser(t *testing.T) {
+	// Arrange
+	v, err := auth.NewVerifier(auth.VerifierConfig{HMACSecret: testSecret, AdminScope: "admin"})
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	c := newTestClient(t, Dependencies{Auth: v})
+	owner, other := uuid.NewString(), uuid.NewString()
+
+	// Act
+	_, anonErr := c.subs.ListSubscriptions(context.Background(), &pb.ListSubscriptionsRequest{})
+	created, err := c.subs.CreateSubscription(bearer(t, owner), &pb.CreateSubscriptionRequest{
+		Subscription: netflix(other),
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	_, foreignErr := c.subs.GetSubscription(bearer(t, other), &pb.GetSubscriptionRequest{Id: created.GetId()})
+
+	// Assert
+	expectCode(t, anonErr, codes.Unauthenticated)
+	if created.GetUserId() != owner {
+		t.Errorf("expected subscription of %s, got %s", owner, created.GetUserId())
+	}
+	expectCode(t, foreignErr, codes.NotFound)
+}
+
+func TestAuth_APIKeyScopes(t *testing.T) {
+	// Arrange
+	key := "sk_test"
+	tenantID := "acme"
+	c := newTestClient(t, Dependencies{APIKeys: fakeAPIKeys{key: domain.APIKey{
+		ID:       uuid.New(),
+		Hash:     auth.HashAPIKey(key),
+		Scopes:   []string{auth.ScopeSubscriptionsRead},
+		TenantID: &tenantID,
+	}}})
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-api-key", key)
+
+	// Act
+	_, listErr := c.subs.ListSubscriptions(ctx, &pb.ListSubscriptionsRequest{})
+	_, createErr := c.subs.CreateSubscription(ctx, &pb.CreateSubscriptionRequest{Subscription: netflix(uuid.NewString())})
+	_, tenantErr := c.subs.ListSubscriptions(
+		metadata.AppendToOutgoingContext(ctx, "x-tenant-id", "globex"),
+		&pb.ListSubscriptionsRequest{},
+	)
+	_, badKeyErr := c.subs.ListSubscriptions(
+		metadata.AppendToOutgoingContext(context.Background(), "x-api-key", "sk_wrong"),
+		&pb.ListSubscriptionsRequest{},
+	)
+
+	// Assert
+	if listErr != nil {
+		t.Errorf("list: %v", listErr)
+	}
+	expectCode(t, createErr, codes.PermissionDenied)
+	expectCode(t, tenantErr, codes.PermissionDenied)
+	expectCode(t, badKeyErr, codes.Unauthenticated)
+}
+
+func TestAuth_RejectsAnonymousCalls(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{APIKeys: fakeAPIKeys{}})
+
+	// Act
+	_, err := c.subs.ListSubscriptions(context.Background(), &pb.ListSubscriptionsRequest{})
+
+	// Assert
+	expectCode(t, err, codes.Unauthenticated)
+}
+
+// ==============================================================
+// ==============================================================
+// health checking and reflection
+// ==============================================================
+// ==============================================================
+func TestHealth_ServingUntilShutdown(t *testing.T) {
+	// Arrange
+	v, err := auth.NewVerifier(auth.VerifierConfig{HMACSecret: testSecret})
+	if err != nil {
+		t.Fatalf("verifier: %v", err)
+	}
+	c := newTestClient(t, Dependencies{Auth: v})
+	health := healthpb.NewHealthClient(c.conn)
+	req := &healthpb.HealthCheckRequest{Service: pb.SubscriptionService_ServiceDesc.ServiceName}
+
+	// Act
+	before, err := health.Check(context.Background(), req)
+	if err != nil {
+		t.Fatalf("check: %v", err)
+	}
+	c.srv.SetShuttingDown()
+	after, err := health.Check(context.Background(), req)
+	if err != nil {
+		t.Fatalf("check after shutdown: %v", err)
+	}
+
+	// Assert
+	if before.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("expected SERVING without credentials, got %s", before.GetStatus())
+	}
+	if after.GetStatus() != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("expected NOT_SERVING, got %s", after.GetStatus())
+	}
+}
+
+func TestReflection_ListsServices(t *testing.T) {
+	// Arrange
+	c := newTestClient(t, Dependencies{AllowAnonymous: true})
+	stream, err := reflectionpb.NewServerReflectionClient(c.conn).ServerReflectionInfo(context.Background())
+	if err != nil {
+		t.Fatalf("reflection: %v", err)
+	}
+
+	// Act
+	if err := stream.Send(&reflectionpb.ServerReflectionRequest{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		t.Fatalf("send: %v", err)
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("recv: %v", err)
+	}
+
+	// Assert
+	names := map[string]bool{}
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names[s.GetName()] = true
+	}
+	for _, want := range []string{
+		pb.SubscriptionService_ServiceDesc.ServiceName,
+		pb.AggregationService_ServiceDesc.ServiceName,
+		healthpb.Health_ServiceDesc.ServiceName,
+	} {
+		if !names[want] {
+			t.Errorf("expected %s in %v", want, names)
+		}
+	}
+}
@@ -0,0 +1,252 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	pb "github.com/DevSchmied/subscription-aggregation-service/internal/grpcapi/subscriptionsv1"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/utils"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Page sizes of ListSubscriptions.
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// subscriptionServer adapts SubscriptionService to gRPC.
+type subscriptionServer struct {
+	pb.UnimplementedSubscriptionServiceServer
+
+	svc       *service.SubscriptionService
+	dbTimeout time.Duration
+}
+
+// CreateSubscription validates and stores a new subscription.
+func (s *subscriptionServer) CreateSubscription(
+	ctx context.Context,
+	req *pb.CreateSubscriptionRequest,
+) (*pb.Subscription, error) {
+
+	ctx, cancel := context.WithTimeout(ctx, s.dbTimeout)
+	defer cancel()
+
+	sub, err := s.svc.Create(ctx, toInput(req.GetSubscription()))
+	if err != nil {
+		return nil, statusError(ctx, "CreateSubscription failed", err)
+	}
+
+	return toProto(sub), nil
+}
+
+// GetSubscription returns a subscription, as it was at as_of when set.
+func (s *subscriptionServer) GetSubscription(
+	ctx context.Context,
+	req *pb.GetSubscriptionRequest,
+) (*pb.Subscription, error) {
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, "GetSubscription: invalid request", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.dbTimeout)
+	defer cancel()
+
+	sub, err := s.svc.Get(ctx, id, asOf(req.GetAsOf()))
+	if err != nil {
+		return nil, statusError(ctx, "GetSubscription failed", err)
+	}
+
+	return toProto(sub), nil
+}
+
+// UpdateSubscription validates and replaces an existing subscription.
+func (s *subscriptionServer) UpdateSubscription(
+	ctx context.Context,
+	req *pb.UpdateSubscriptionRequest,
+) (*pb.Subscription, error) {
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, "UpdateSubscription: invalid request", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.dbTimeout)
+	defer cancel()
+
+	sub, err := s.svc.Update(ctx, id, toInput(req.GetSubscription()))
+	if err != nil {
+		return nil, statusError(ctx, "UpdateSubscription failed", err)
+	}
+
+	return toProto(sub), nil
+}
+
+// DeleteSubscription removes a subscription.
+func (s *subscriptionServer) DeleteSubscription(
+	ctx context.Context,
+	req *pb.DeleteSubscriptionRequest,
+) (*pb.DeleteSubscriptionResponse, error) {
+
+	id, err := parseID(req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, "DeleteSubscription: invalid request", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.dbTimeout)
+	defer cancel()
+
+	if err := s.svc.Delete(ctx, id); err != nil {
+		return nil, statusError(ctx, "DeleteSubscription failed", err)
+	}
+
+	return &pb.DeleteSubscriptionResponse{}, nil
+}
+
+// ListSubscriptions returns one page of subscriptions, newest first.
+func (s *subscriptionServer) ListSubscriptions(
+	ctx context.Context,
+	req *pb.ListSubscriptionsRequest,
+) (*pb.ListSubscriptionsResponse, error) {
+
+	// Collect all invalid parameters
+	var verr domain.ValidationError
+
+	f := domain.ListFilter{AsOf: asOf(req.GetAsOf())}
+	if v := strings.TrimSpace(req.GetUserId()); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			verr.Add("user_id", "must be a UUID")
+		} else {
+			f.UserID = &id
+		}
+	}
+	if v := strings.TrimSpace(req.GetServiceName()); v != "" {
+		f.ServiceName = &v
+	}
+
+	size := int(req.GetPageSize())
+	switch {
+	case size == 0:
+		size = defaultPageSize
+	case size < 0 || size > maxPageSize:
+		verr.Add("page_size", "must be between 1 and "+strconv.Itoa(maxPageSize))
+	}
+
+	after, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		verr.Add("page_token", "is invalid")
+	}
+	f.After = after
+
+	if err := verr.Err(); err != nil {
+		return nil, statusError(ctx, "ListSubscriptions: invalid request", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.dbTimeout)
+	defer cancel()
+
+	page, err := s.svc.ListPage(ctx, f, size)
+	if err != nil {
+		return nil, statusError(ctx, "ListSubscriptions failed", err)
+	}
+
+	resp := &pb.ListSubscriptionsResponse{
+		Subscriptions: make([]*pb.Subscription, 0, len(page.Items)),
+	}
+	for _, sub := range page.Items {
+		resp.Subscriptions = append(resp.Subscriptions, toProto(sub))
+	}
+	if page.Next != nil {
+		resp.NextPageToken = encodePageToken(*page.Next)
+	}
+
+	return resp, nil
+}
+
+// toInput converts a protobuf subscription to the service input.
+func toInput(in *pb.SubscriptionInput) service.SubscriptionInput {
+	price := int(in.GetPrice())
+	return service.SubscriptionInput{
+		ServiceName: in.GetServiceName(),
+		Price:       &price,
+		UserID:      in.GetUserId(),
+		StartDate:   in.GetStartDate(),
+		EndDate:     in.GetEndDate(),
+	}
+}
+
+// toProto converts a domain subscription to its protobuf message.
+func toProto(s domain.Subscription) *pb.Subscription {
+	out := &pb.Subscription{
+		Id:          s.ID.String(),
+		ServiceName: s.ServiceName,
+		Price:       int64(s.Price),
+		UserId:      s.UserID.String(),
+		StartDate:   utils.FormatMonthYear(s.StartDate),
+	}
+	if s.EndDate != nil {
+		out.EndDate = utils.FormatMonthYear(*s.EndDate)
+	}
+	return out
+}
+
+// parseID parses a subscription ID.
+func parseID(v string) (uuid.UUID, error) {
+	id, err := uuid.Parse(strings.TrimSpace(v))
+	if err != nil {
+		return uuid.Nil, domain.Invalid("id", "must be a UUID")
+	}
+	return id, nil
+}
+
+// asOf converts an optional timestamp.
+func asOf(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+// encodePageToken returns the opaque token of the page following c. The
+// position rather than an offset keeps pages stable while rows are
+// added or removed in front of them.
+func encodePageToken(c domain.Cursor) string {
+	v := c.CreatedAt.UTC().Format(time.RFC3339Nano) + " " + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(v))
+}
+
+// decodePageToken returns the position of a page token, nil for no token.
+func decodePageToken(token string) (*domain.Cursor, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt, id, ok := strings.Cut(string(b), " ")
+	if !ok {
+		return nil, domain.ErrInvalidInput
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return nil, err
+	}
+	uid, err := uuid.Parse(id)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.Cursor{CreatedAt: t, ID: uid}, nil
+}
@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"embed"
+	"encoding/csv"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Report formats supported by aggregation endpoints.
+const (
+	reportFormatJSON = "json"
+	reportFormatCSV  = "csv"
+	reportFormatHTML = "html"
+)
+
+// MIME types used for report content negotiation.
+const (
+	mimeCSV  = "text/csv"
+	mimeHTML = "text/html"
+)
+
+// Chart geometry in pixels.
+const (
+	chartBarWidth  = 28
+	chartBarGap    = 8
+	chartMaxHeight = 200
+	chartLabelArea = 20
+)
+
+//go:embed templates/aggregation_report.html
+var reportTemplates embed.FS
+
+// reportTemplate renders the printable HTML report.
+var reportTemplate = template.Must(
+	template.ParseFS(reportTemplates, "templates/aggregation_report.html"),
+)
+
+// MonthlyTotal is the subscription cost of a single month.
+type MonthlyTotal struct {
+	Month string `json:"month"` // MM-YYYY
+	Total int    `json:"total"`
+}
+
+// aggregationReport holds everything needed to render an aggregation result.
+type aggregationReport struct {
+	PeriodStart   string
+	PeriodEnd     string
+	UserID        string
+	ServiceName   string
+	Subscriptions int
+	Total         int
+	Months        []MonthlyTotal
+}
+
+// newAggregationReport formats an aggregation result for rendering.
+func newAggregationReport(res service.TotalResult) aggregationReport {
+	r := aggregationReport{
+		PeriodStart:   utils.FormatMonthYear(res.PeriodStart),
+		PeriodEnd:     utils.FormatMonthYear(res.PeriodEnd),
+		Subscriptions: res.Subscriptions,
+		Total:         res.Total,
+		Months:        make([]MonthlyTotal, len(res.Months)),
+	}
+	if res.UserID != nil {
+		r.UserID = res.UserID.String()
+	}
+	if res.ServiceName != nil {
+		r.ServiceName = *res.ServiceName
+	}
+	for i, m := range res.Months {
+		r.Months[i] = MonthlyTotal{Month: utils.FormatMonthYear(m.Month), Total: m.Total}
+	}
+
+	return r
+}
+
+// negotiateReportFormat picks the report format from ?format= or the Accept header.
+// JSON is used when nothing more specific is requested.
+func negotiateReportFormat(c *gin.Context) (string, error) {
+	if f := strings.ToLower(strings.TrimSpace(c.Query("format"))); f != "" {
+		switch f {
+		case reportFormatJSON, reportFormatCSV, reportFormatHTML:
+			return f, nil
+		default:
+			return "", domain.Invalid("format", "must be json, csv or html")
+		}
+	}
+
+	switch c.NegotiateFormat(gin.MIMEJSON, mimeCSV, mimeHTML) {
+	case mimeCSV:
+		return reportFormatCSV, nil
+	case mimeHTML:
+		return reportFormatHTML, nil
+	default:
+		return reportFormatJSON, nil
+	}
+}
+
+// writeReportCSV writes one row per month followed by the period total.
+func writeReportCSV(w io.Writer, r aggregationReport) error {
+	cw := csv.NewWriter(w)
+
+	_ = cw.Write([]string{"month", "total"})
+	for _, m := range r.Months {
+		_ = cw.Write([]string{m.Month, strconv.Itoa(m.Total)})
+	}
+	_ = cw.Write([]string{"total", strconv.Itoa(r.Total)})
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// chartBar is a single bar of the inline SVG chart.
+type chartBar struct {
+	X, Y, Width, Height, LabelX int
+	Label                       string
+	Value                       int
+}
+
+// chart is the inline SVG bar chart of monthly spend.
+type chart struct {
+	Width, Height, LabelY int
+	Bars                  []chartBar
+}
+
+// buildChart scales monthly totals into SVG bars.
+func buildChart(months []MonthlyTotal) chart {
+	maxTotal := 0
+	for _, m := range months {
+		if m.Total > maxTotal {
+			maxTotal = m.Total
+		}
+	}
+
+	ch := chart{
+		Width:  len(months)*(chartBarWidth+chartBarGap) + chartBarGap,
+		Height: chartMaxHeight + chartLabelArea,
+		LabelY: chartMaxHeight + chartLabelArea - 6,
+	}
+
+	for i, m := range months {
+		height := 0
+		if maxTotal > 0 {
+			height = m.Total * chartMaxHeight / maxTotal
+		}
+		x := chartBarGap + i*(chartBarWidth+chartBarGap)
+
+		ch.Bars = append(ch.Bars, chartBar{
+			X:      x,
+			Y:      chartMaxHeight - height,
+			Width:  chartBarWidth,
+			Height: height,
+			LabelX: x + chartBarWidth/2,
+			Label:  m.Month[:2] + "/" + m.Month[5:],
+			Value:  m.Total,
+		})
+	}
+
+	return ch
+}
+
+// renderReportHTML renders a self-contained printable report.
+func renderReportHTML(w io.Writer, r aggregationReport) error {
+	avg := 0
+	if len(r.Months) > 0 {
+		avg = r.Total / len(r.Months)
+	}
+
+	return reportTemplate.Execute(w, struct {
+		aggregationReport
+		GeneratedAt     string
+		AveragePerMonth int
+		Chart           chart
+	}{
+		aggregationReport: r,
+		GeneratedAt:       time.Now().UTC().Format(time.RFC3339),
+		AveragePerMonth:   avg,
+		Chart:             buildChart(r.Months),
+	})
+}
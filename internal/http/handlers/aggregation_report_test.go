@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ====================================
+// negotiateReportFormat
+// ====================================
+
+// TestNegotiateReportFormat checks query parameter and Accept header handling.
+func TestNegotiateReportFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cases := []struct {
+		url, accept, expected string
+		wantErr               bool
+	}{
+		{url: "/", expected: reportFormatJSON},
+		{url: "/", accept: "*/*", expected: reportFormatJSON},
+		{url: "/", accept: "text/csv", expected: reportFormatCSV},
+		{url: "/", accept: "text/html,application/xhtml+xml", expected: reportFormatHTML},
+		{url: "/?format=CSV", accept: "text/html", expected: reportFormatCSV},
+		{url: "/?format=pdf", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		// Arrange
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, tc.url, nil)
+		if tc.accept != "" {
+			c.Request.Header.Set("Accept", tc.accept)
+		}
+
+		// Act
+		format, err := negotiateReportFormat(c)
+
+		// Assert
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected error, got nil", tc.url)
+			}
+			continue
+		}
+		if format != tc.expected {
+			t.Errorf("%s (Accept %q): expected %s, got %s", tc.url, tc.accept, tc.expected, format)
+		}
+	}
+}
+
+// ====================================
+// report rendering
+// ====================================
+
+// TestWriteReportCSV verifies one row per month and a total row.
+func TestWriteReportCSV(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	report := aggregationReport{
+		Total:  300,
+		Months: []MonthlyTotal{{"01-2025", 100}, {"02-2025", 200}},
+	}
+
+	// Act
+	err := writeReportCSV(&buf, report)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := "month,total\n01-2025,100\n02-2025,200\ntotal,300\n"
+	if buf.String() != expected {
+		t.Errorf("expected %q, got %q", expected, buf.String())
+	}
+}
+
+// TestRenderReportHTML verifies the summary table and chart are rendered and escaped.
+func TestRenderReportHTML(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	report := aggregationReport{
+		PeriodStart: "01-2025",
+		PeriodEnd:   "02-2025",
+		ServiceName: "<script>",
+		Total:       300,
+		Months:      []MonthlyTotal{{"01-2025", 100}, {"02-2025", 200}},
+	}
+
+	// Act
+	err := renderReportHTML(&buf, report)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	html := buf.String()
+	if strings.Count(html, "<rect ") != 2 {
+		t.Errorf("expected 2 chart bars, got %s", html)
+	}
+	if strings.Contains(html, "<script>") {
+		t.Error("expected service name to be escaped")
+	}
+	if !strings.Contains(html, "150") {
+		t.Error("expected average per month in summary")
+	}
+}
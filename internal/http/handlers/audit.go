@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/audit"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Audit search page sizes.
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// AuditHandler handles audit log HTTP endpoints.
+type AuditHandler struct {
+	repo      *postgres.AuditRepo
+	dbTimeout time.Duration
+}
+
+// NewAuditHandler creates audit handler.
+func NewAuditHandler(repo *postgres.AuditRepo, dbTimeout time.Duration) *AuditHandler {
+	return &AuditHandler{
+		repo:      repo,
+		dbTimeout: dbTimeout,
+	}
+}
+
+// AuditEntryResponse defines API response for one audit entry.
+type AuditEntryResponse struct {
+	ID             int64           `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	UserID         string          `json:"user_id"`
+	Action         string          `json:"action"`
+	Actor          string          `json:"actor"`
+	RequestID      string          `json:"request_id"`
+	Before         json.RawMessage `json:"before" swaggertype:"object"`
+	After          json.RawMessage `json:"after" swaggertype:"object"`
+	CreatedAt      string          `json:"created_at"`
+}
+
+// toAuditResponse maps domain audit entry to API response.
+func toAuditResponse(e domain.AuditEntry) AuditEntryResponse {
+	// Missing snapshots are rendered as null
+	before, after := e.Before, e.After
+	if before == nil {
+		before = json.RawMessage("null")
+	}
+	if after == nil {
+		after = json.RawMessage("null")
+	}
+
+	return AuditEntryResponse{
+		ID:             e.ID,
+		SubscriptionID: e.SubscriptionID.String(),
+		UserID:         e.UserID.String(),
+		Action:         e.Action,
+		Actor:          e.Actor,
+		RequestID:      e.RequestID,
+		Before:         before,
+		After:          after,
+		CreatedAt:      e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// parseAuditFilter reads audit search filters from query parameters.
+// All invalid parameters are reported in one *domain.ValidationError.
+func parseAuditFilter(c *gin.Context) (postgres.AuditFilter, error) {
+	f := postgres.AuditFilter{Limit: defaultAuditLimit}
+
+	var verr domain.ValidationError
+
+	for _, p := range []struct {
+		name string
+		dst  **uuid.UUID
+	}{
+		{"subscription_id", &f.SubscriptionID},
+		{"user_id", &f.UserID},
+	} {
+		if v := strings.TrimSpace(c.Query(p.name)); v != "" {
+			id, err := uuid.Parse(v)
+			if err != nil {
+				verr.Add(p.name, "must be a UUID")
+				continue
+			}
+			*p.dst = &id
+		}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"from", &f.From},
+		{"to", &f.To},
+	} {
+		if v := strings.TrimSpace(c.Query(p.name)); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				verr.Add(p.name, "must be an RFC3339 time")
+				continue
+			}
+			*p.dst = &t
+		}
+	}
+	if f.From != nil && f.To != nil && !f.From.Before(*f.To) {
+		verr.Add("from", "must be before to")
+	}
+
+	if actor := strings.TrimSpace(c.Query("actor")); actor != "" {
+		f.Actor = &actor
+	}
+
+	if action := strings.ToLower(strings.TrimSpace(c.Query("action"))); action != "" {
+		switch action {
+		case audit.ActionCreate, audit.ActionUpdate, audit.ActionDelete:
+			f.Action = &action
+		default:
+			verr.Add("action", "must be create, update or delete")
+		}
+	}
+
+	if v := strings.TrimSpace(c.Query("limit")); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			verr.Add("limit", "must be between 1 and 1000")
+		} else {
+			f.Limit = limit
+		}
+	}
+
+	if err := verr.Err(); err != nil {
+		return postgres.AuditFilter{}, err
+	}
+
+	// Non-admin callers only see changes of their own subscriptions
+	f.UserID = service.ScopeUserFilter(c.Request.Context(), f.UserID)
+
+	return f, nil
+}
+
+// History returns the audit trail of a subscription, newest first.
+//
+// @Summary Subscription history
+// @Description Lists create, update and delete entries of a subscription with before/after snapshots
+// @Tags audit
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Param limit query int false "Maximum number of entries (1-1000, default 100)"
+// @Success 200 {array} AuditEntryResponse
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/{id}/history [get]
+func (h *AuditHandler) History(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "History: invalid id", "error", err)
+		respondError(c, domain.Invalid("id", "must be a UUID"))
+		return
+	}
+	logging.Add(c.Request.Context(), slog.String("subscription_id", id.String()))
+
+	f, err := parseAuditFilter(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "History: invalid request", "error", err)
+		respondError(c, err)
+		return
+	}
+	f.SubscriptionID = &id
+
+	h.respond(c, f, true)
+}
+
+// Search returns audit entries across subscriptions, newest first.
+//
+// @Summary Search audit log
+// @Description Searches subscription changes by time range, actor, action, user or subscription
+// @Tags audit
+// @Produce json
+// @Param from query string false "Start of time range, RFC3339 (inclusive)"
+// @Param to query string false "End of time range, RFC3339 (exclusive)"
+// @Param subscription_id query string false "Subscription UUID"
+// @Param user_id query string false "User UUID"
+// @Param actor query string false "Actor"
+// @Param action query string false "Action: create, update or delete"
+// @Param limit query int false "Maximum number of entries (1-1000, default 100)"
+// @Success 200 {array} AuditEntryResponse
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /audit [get]
+func (h *AuditHandler) Search(c *gin.Context) {
+	f, err := parseAuditFilter(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Audit search: invalid request", "error", err)
+		respondError(c, err)
+		return
+	}
+
+	h.respond(c, f, false)
+}
+
+// respond runs the audit query and writes the entries.
+// With notFoundIfEmpty an empty result is reported as 404.
+func (h *AuditHandler) respond(c *gin.Context, f postgres.AuditFilter, notFoundIfEmpty bool) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	entries, err := h.repo.Search(ctx, f)
+	if err == nil && notFoundIfEmpty && len(entries) == 0 {
+		err = postgres.ErrNotFound
+	}
+	if err != nil {
+		logFailure(c, "Audit failed", err)
+
+		respondError(c, err)
+		return
+	}
+
+	resp := make([]AuditEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		resp = append(resp, toAuditResponse(e))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// auditContext builds a gin context for url, optionally made by p.
+func auditContext(url string, p *auth.Principal) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, url, nil)
+	if p != nil {
+		c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), *p))
+	}
+	return c
+}
+
+// ==============================================================
+// ==============================================================
+// parseAuditFilter
+// ==============================================================
+// ==============================================================
+func TestParseAuditFilter_AllFilters(t *testing.T) {
+	// Arrange
+	subID := uuid.New()
+	c := auditContext("/api/audit?from=2025-01-01T00:00:00Z&to=2025-04-01T00:00:00Z"+
+		"&subscription_id="+subID.String()+"&actor=alice&action=UPDATE&limit=10", nil)
+
+	// Act
+	f, err := parseAuditFilter(c)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.From == nil || !f.From.Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected from: %v", f.From)
+	}
+	if f.To == nil || !f.To.Equal(time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected to: %v", f.To)
+	}
+	if f.SubscriptionID == nil || *f.SubscriptionID != subID {
+		t.Errorf("unexpected subscription_id: %v", f.SubscriptionID)
+	}
+	if f.Actor == nil || *f.Actor != "alice" || f.Action == nil || *f.Action != "update" {
+		t.Errorf("unexpected actor/action: %v %v", f.Actor, f.Action)
+	}
+	if f.Limit != 10 {
+		t.Errorf("expected limit 10, got %d", f.Limit)
+	}
+}
+
+func TestParseAuditFilter_Defaults(t *testing.T) {
+	// Act
+	f, err := parseAuditFilter(auditContext("/api/audit", nil))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.Limit != defaultAuditLimit || f.From != nil || f.To != nil || f.UserID != nil {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+}
+
+func TestParseAuditFilter_Invalid(t *testing.T) {
+	urls := []string{
+		"/api/audit?from=2025-01-01",
+		"/api/audit?from=2025-02-01T00:00:00Z&to=2025-01-01T00:00:00Z",
+		"/api/audit?action=read",
+		"/api/audit?limit=0",
+		"/api/audit?limit=5000",
+		"/api/audit?user_id=nope",
+	}
+
+	for _, url := range urls {
+		// Act
+		_, err := parseAuditFilter(auditContext(url, nil))
+
+		// Assert
+		if err == nil {
+			t.Errorf("%s: expected error", url)
+		}
+	}
+}
+
+func TestParseAuditFilter_ScopedToCaller(t *testing.T) {
+	// Arrange
+	userID := uuid.New()
+	c := auditContext("/api/audit?user_id="+uuid.NewString(), &auth.Principal{UserID: userID})
+
+	// Act
+	f, err := parseAuditFilter(c)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if f.UserID == nil || *f.UserID != userID {
+		t.Errorf("expected caller user %s, got %v", userID, f.UserID)
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// toAuditResponse
+// ==============================================================
+// ==============================================================
+func TestToAuditResponse_MissingSnapshotIsNull(t *testing.T) {
+	// Arrange
+	e := domain.AuditEntry{
+		ID:             1,
+		SubscriptionID: uuid.New(),
+		Action:         "create",
+		After:          json.RawMessage(`{"price":400}`),
+		CreatedAt:      time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	body, err := json.Marshal(toAuditResponse(e))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var got map[string]any
+	_ = json.Unmarshal(body, &got)
+	if got["before"] != nil {
+		t.Errorf("expected null before, got %v", got["before"])
+	}
+	if after, ok := got["after"].(map[string]any); !ok || after["price"] != float64(400) {
+		t.Errorf("unexpected after: %v", got["after"])
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// BatchOperation defines a single create, update or delete operation.
+type BatchOperation struct {
+	Op           string               `json:"op"` // create, update or delete
+	ID           string               `json:"id"` // required for update and delete
+	Subscription *SubscriptionRequest `json:"subscription"`
+}
+
+// BatchRequest defines batch payload.
+type BatchRequest struct {
+	Mode       string           `json:"mode"` // all_or_nothing (default) or best_effort
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchItemResult describes the outcome of one batch operation.
+type BatchItemResult struct {
+	Index        int                   `json:"index"`
+	Op           string                `json:"op"`
+	Status       int                   `json:"status"`
+	ID           string                `json:"id,omitempty"`
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Error        string                `json:"error,omitempty"`
+	Errors       []domain.FieldError   `json:"errors,omitempty"`
+}
+
+// BatchResponse defines batch API response.
+type BatchResponse struct {
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Results   []BatchItemResult `json:"results"`
+}
+
+// batchSuccessStatus is the status of each applied operation kind.
+var batchSuccessStatus = map[string]int{
+	service.BatchOpCreate: http.StatusCreated,
+	service.BatchOpUpdate: http.StatusOK,
+	service.BatchOpDelete: http.StatusNoContent,
+}
+
+// toBatchItemResult maps the outcome of one operation to its HTTP result.
+// Operations undone or skipped because of another one get 424.
+func toBatchItemResult(i int, r service.BatchItemResult) BatchItemResult {
+	out := BatchItemResult{Index: i, Op: r.Op}
+	if r.ID != uuid.Nil {
+		out.ID = r.ID.String()
+	}
+
+	switch {
+	case r.Err == nil:
+		out.Status = batchSuccessStatus[r.Op]
+		if r.Subscription != nil {
+			resp := toResponse(*r.Subscription)
+			out.Subscription = &resp
+		}
+	case errors.Is(r.Err, service.ErrNotExecuted), errors.Is(r.Err, service.ErrRolledBack):
+		out.Status, out.Error = http.StatusFailedDependency, r.Err.Error()
+	default:
+		p := mapErrorToHTTP(r.Err)
+		out.Status, out.Error, out.Errors = p.Status, p.Detail, p.Errors
+	}
+
+	return out
+}
+
+// Batch applies several subscription operations in one transaction.
+//
+// See service.SubscriptionService.Batch for the execution modes.
+//
+// @Summary Batch subscription operations
+// @Description Create, update and delete subscriptions in one transaction
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param batch body BatchRequest true "Batch operations"
+// @Success 200 {object} BatchResponse
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/batch [post]
+func (h *SubscriptionsHandler) Batch(c *gin.Context) {
+	var req BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.WarnContext(c.Request.Context(), "Batch: invalid json", "error", err)
+		respondError(c, domain.Invalid("body", "invalid json"))
+		return
+	}
+
+	ops := make([]service.BatchOperation, len(req.Operations))
+	for i, op := range req.Operations {
+		ops[i] = service.BatchOperation{Op: op.Op, ID: op.ID}
+		if op.Subscription != nil {
+			in := op.Subscription.input()
+			ops[i].Subscription = &in
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	res, err := h.svc.Batch(ctx, req.Mode, ops)
+	if err != nil {
+		logFailure(c, "Batch failed", err)
+
+		respondError(c, err)
+		return
+	}
+
+	resp := BatchResponse{
+		Mode:      res.Mode,
+		Committed: res.Committed,
+		Results:   make([]BatchItemResult, len(res.Items)),
+	}
+	for i, item := range res.Items {
+		resp.Results[i] = toBatchItemResult(i, item)
+	}
+
+	// All-or-nothing batches with invalid operations are not executed
+	if res.Rejected {
+		c.JSON(http.StatusBadRequest, resp)
+		return
+	}
+
+	if !res.Committed {
+		slog.WarnContext(c.Request.Context(), "Batch: aborted, rolled back", "operations", len(ops))
+	}
+	slog.InfoContext(c.Request.Context(), "Batch processed",
+		"mode", res.Mode, "operations", len(ops), "committed", res.Committed)
+
+	c.JSON(http.StatusOK, resp)
+}
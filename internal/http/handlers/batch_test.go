@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/google/uuid"
+)
+
+// ==============================================================
+// ==============================================================
+// toBatchItemResult
+// ==============================================================
+// ==============================================================
+func TestToBatchItemResult_Success(t *testing.T) {
+	// Arrange
+	sub := domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+	}
+	cases := []struct {
+		r      service.BatchItemResult
+		status int
+	}{
+		{service.BatchItemResult{Op: service.BatchOpCreate, ID: sub.ID, Subscription: &sub}, http.StatusCreated},
+		{service.BatchItemResult{Op: service.BatchOpUpdate, ID: sub.ID, Subscription: &sub}, http.StatusOK},
+		{service.BatchItemResult{Op: service.BatchOpDelete, ID: sub.ID}, http.StatusNoContent},
+	}
+
+	for i, tc := range cases {
+		// Act
+		out := toBatchItemResult(i, tc.r)
+
+		// Assert
+		if out.Index != i || out.Status != tc.status {
+			t.Errorf("op %d: expected status %d, got %+v", i, tc.status, out)
+		}
+		if out.ID != sub.ID.String() || out.Error != "" {
+			t.Errorf("op %d: unexpected result %+v", i, out)
+		}
+		if (tc.r.Subscription != nil) != (out.Subscription != nil) {
+			t.Errorf("op %d: subscription mismatch", i)
+		}
+	}
+}
+
+func TestToBatchItemResult_Errors(t *testing.T) {
+	// Arrange
+	cases := []struct {
+		err    error
+		status int
+	}{
+		{fmt.Errorf("%w: batch aborted", service.ErrNotExecuted), http.StatusFailedDependency},
+		{service.ErrRolledBack, http.StatusFailedDependency},
+		{domain.Invalid("price", "must be >= 0"), http.StatusBadRequest},
+		{domain.ErrNotFound, http.StatusNotFound},
+		{domain.ErrConflict, http.StatusConflict},
+	}
+
+	for i, tc := range cases {
+		// Act
+		out := toBatchItemResult(i, service.BatchItemResult{Op: service.BatchOpCreate, Err: tc.err})
+
+		// Assert
+		if out.Status != tc.status {
+			t.Errorf("case %d: expected status %d, got %d", i, tc.status, out.Status)
+		}
+		if out.Error == "" {
+			t.Errorf("case %d: expected error message", i)
+		}
+		if out.ID != "" {
+			t.Errorf("case %d: expected no id, got %s", i, out.ID)
+		}
+	}
+}
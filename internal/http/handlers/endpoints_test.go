@@ -0,0 +1,567 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/graphqlapi"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/memory"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// testUserHeader makes the request a scoped caller with this user ID.
+const testUserHeader = "X-Test-User"
+
+// testServer serves the subscription endpoints from an in-memory store.
+type testServer struct {
+	t *testing.T
+	r *gin.Engine
+}
+
+// newTestServer registers the subscription and aggregation routes as the
+// router does, with a fixed tenant and optional scoped callers.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store := memory.NewSubscriptionStore()
+	subSvc := service.NewSubscriptionService(store)
+	aggSvc := service.NewAggregationService(store)
+	subH := NewSubscriptionsHandler(subSvc, time.Second)
+	aggH := NewAggregationHandler(aggSvc, time.Second)
+	gqlH := NewGraphQLHandler(graphqlapi.NewServer(subSvc, aggSvc), time.Second)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx := tenant.WithID(c.Request.Context(), "acme")
+		if v := c.GetHeader(testUserHeader); v != "" {
+			ctx = auth.WithPrincipal(ctx, auth.Principal{Subject: v, UserID: uuid.MustParse(v)})
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+
+	api := r.Group("/api")
+	api.POST("/subscriptions", subH.Create)
+	api.POST("/subscriptions/batch", subH.Batch)
+	api.POST("/subscriptions/import", subH.Import)
+	api.GET("/subscriptions/:id", subH.Get)
+	api.PUT("/subscriptions/:id", subH.Update)
+	api.DELETE("/subscriptions/:id", subH.Delete)
+	api.GET("/subscriptions", subH.List)
+	api.GET("/subscriptions/export", subH.Export)
+	api.GET("/subscriptions/total", aggH.Total)
+	r.GET("/graphql", gqlH.Query)
+	r.POST("/graphql", gqlH.Query)
+
+	return &testServer{t: t, r: r}
+}
+
+// do sends a request with an optional JSON body and headers as
+// alternating names and values.
+func (s *testServer) do(method, url string, body any, headers ...string) *httptest.ResponseRecorder {
+	s.t.Helper()
+
+	var buf bytes.Buffer
+	switch b := body.(type) {
+	case nil:
+	case string:
+		buf.WriteString(b)
+	default:
+		if err := json.NewEncoder(&buf).Encode(b); err != nil {
+			s.t.Fatalf("encode body: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(method, url, &buf)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+	return w
+}
+
+// create adds a subscription through the API and returns it.
+func (s *testServer) create(req SubscriptionRequest) SubscriptionResponse {
+	s.t.Helper()
+
+	w := s.do(http.MethodPost, "/api/subscriptions", req)
+	if w.Code != http.StatusCreated {
+		s.t.Fatalf("create: status %d: %s", w.Code, w.Body.String())
+	}
+	return decode[SubscriptionResponse](s.t, w)
+}
+
+// intPtr returns a pointer to n.
+func intPtr(n int) *int { return &n }
+
+// decode unmarshals the response body.
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+// expectProblem checks the status and problem type of an error response.
+func expectProblem(t *testing.T, w *httptest.ResponseRecorder, status int, typ string) problem.Details {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("expected %d, got %d: %s", status, w.Code, w.Body.String())
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, problem.ContentType) {
+		t.Errorf("expected problem content type, got %q", ct)
+	}
+	p := decode[problem.Details](t, w)
+	if p.Type != typ {
+		t.Errorf("expected type %s, got %s", typ, p.Type)
+	}
+	return p
+}
+
+// ==============================================================
+// ==============================================================
+// CRUD endpoints
+// ==============================================================
+// ==============================================================
+func TestEndpoints_CreateGetUpdateDelete(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	user := uuid.NewString()
+
+	// Act
+	created := s.create(SubscriptionRequest{
+		ServiceName: "Netflix", Price: intPtr(400), UserID: user, StartDate: "01-2025",
+	})
+	got := s.do(http.MethodGet, "/api/subscriptions/"+created.ID, nil)
+	updated := s.do(http.MethodPut, "/api/subscriptions/"+created.ID, SubscriptionRequest{
+		ServiceName: "Netflix", Price: intPtr(500), UserID: user, StartDate: "01-2025", EndDate: "06-2025",
+	})
+	deleted := s.do(http.MethodDelete, "/api/subscriptions/"+created.ID, nil)
+	gone := s.do(http.MethodGet, "/api/subscriptions/"+created.ID, nil)
+
+	// Assert
+	if created.ServiceName != "Netflix" || created.Price != 400 || created.StartDate != "01-2025" {
+		t.Errorf("unexpected created subscription: %+v", created)
+	}
+	if got.Code != http.StatusOK || decode[SubscriptionResponse](t, got).ID != created.ID {
+		t.Errorf("unexpected get response %d: %s", got.Code, got.Body.String())
+	}
+	if updated.Code != http.StatusOK {
+		t.Fatalf("unexpected update status %d: %s", updated.Code, updated.Body.String())
+	}
+	if u := decode[SubscriptionResponse](t, updated); u.Price != 500 || u.EndDate != "06-2025" {
+		t.Errorf("unexpected updated subscription: %+v", u)
+	}
+	if deleted.Code != http.StatusNoContent {
+		t.Errorf("expected 204, got %d", deleted.Code)
+	}
+	expectProblem(t, gone, http.StatusNotFound, problem.TypeNotFound)
+}
+
+func TestEndpoints_CreateValidationAndConflict(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	user := uuid.NewString()
+	s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(400), UserID: user, StartDate: "01-2025"})
+
+	// Act
+	invalid := s.do(http.MethodPost, "/api/subscriptions", map[string]any{
+		"service_name": "Netflix", "price": -1, "user_id": "nope", "start_date": "2025-01",
+	})
+	badJSON := s.do(http.MethodPost, "/api/subscriptions", "{")
+	conflict := s.do(http.MethodPost, "/api/subscriptions", SubscriptionRequest{
+		ServiceName: "Netflix", Price: intPtr(100), UserID: user, StartDate: "03-2025",
+	})
+
+	// Assert
+	p := expectProblem(t, invalid, http.StatusBadRequest, problem.TypeValidation)
+	if len(p.Errors) != 3 {
+		t.Errorf("expected price, user_id and start_date errors, got %+v", p.Errors)
+	}
+	expectProblem(t, badJSON, http.StatusBadRequest, problem.TypeValidation)
+	expectProblem(t, conflict, http.StatusConflict, problem.TypeConflict)
+}
+
+func TestEndpoints_CreateEmptyBodyListsEveryMissingField(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+
+	// Act
+	w := s.do(http.MethodPost, "/api/subscriptions", map[string]any{})
+
+	// Assert
+	p := expectProblem(t, w, http.StatusBadRequest, problem.TypeValidation)
+	fields := make(map[string]bool)
+	for _, e := range p.Errors {
+		fields[e.Field] = true
+	}
+	for _, f := range []string{"service_name", "price", "start_date"} {
+		if !fields[f] {
+			t.Errorf("expected an error for %s, got %+v", f, p.Errors)
+		}
+	}
+}
+
+func TestEndpoints_InvalidAndMissingIDs(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	missing := "/api/subscriptions/" + uuid.NewString()
+	body := SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(1), UserID: uuid.NewString(), StartDate: "01-2025"}
+
+	// Act / Assert
+	expectProblem(t, s.do(http.MethodGet, "/api/subscriptions/nope", nil), http.StatusBadRequest, problem.TypeValidation)
+	expectProblem(t, s.do(http.MethodPut, "/api/subscriptions/nope", body), http.StatusBadRequest, problem.TypeValidation)
+	expectProblem(t, s.do(http.MethodDelete, "/api/subscriptions/nope", nil), http.StatusBadRequest, problem.TypeValidation)
+	expectProblem(t, s.do(http.MethodGet, missing, nil), http.StatusNotFound, problem.TypeNotFound)
+	expectProblem(t, s.do(http.MethodPut, missing, body), http.StatusNotFound, problem.TypeNotFound)
+	expectProblem(t, s.do(http.MethodDelete, missing, nil), http.StatusNotFound, problem.TypeNotFound)
+}
+
+func TestEndpoints_GetAsOf(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	user := uuid.NewString()
+	created := s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(400), UserID: user, StartDate: "01-2025"})
+	afterCreate := time.Now().Add(time.Second)
+	tooEarly := time.Now().Add(-time.Hour)
+
+	// Act
+	then := s.do(http.MethodGet, "/api/subscriptions/"+created.ID+"?as_of="+afterCreate.Format(time.RFC3339), nil)
+	before := s.do(http.MethodGet, "/api/subscriptions/"+created.ID+"?as_of="+tooEarly.Format(time.RFC3339), nil)
+	invalid := s.do(http.MethodGet, "/api/subscriptions/"+created.ID+"?as_of=yesterday", nil)
+
+	// Assert
+	if then.Code != http.StatusOK {
+		t.Errorf("expected 200, got %d: %s", then.Code, then.Body.String())
+	}
+	expectProblem(t, before, http.StatusNotFound, problem.TypeNotFound)
+	expectProblem(t, invalid, http.StatusBadRequest, problem.TypeValidation)
+}
+
+func TestEndpoints_List(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	alice, bob := uuid.NewString(), uuid.NewString()
+	s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(1), UserID: alice, StartDate: "01-2025"})
+	s.create(SubscriptionRequest{ServiceName: "Spotify", Price: intPtr(2), UserID: alice, StartDate: "01-2025"})
+	s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(3), UserID: bob, StartDate: "01-2025"})
+
+	// Act
+	all := s.do(http.MethodGet, "/api/subscriptions", nil)
+	byUser := s.do(http.MethodGet, "/api/subscriptions?user_id="+alice, nil)
+	byService := s.do(http.MethodGet, "/api/subscriptions?service_name=Netflix", nil)
+	invalid := s.do(http.MethodGet, "/api/subscriptions?user_id=nope", nil)
+
+	// Assert
+	if n := len(decode[[]SubscriptionResponse](t, all)); n != 3 {
+		t.Errorf("expected 3 subscriptions, got %d", n)
+	}
+	if n := len(decode[[]SubscriptionResponse](t, byUser)); n != 2 {
+		t.Errorf("expected 2 subscriptions of alice, got %d", n)
+	}
+	if n := len(decode[[]SubscriptionResponse](t, byService)); n != 2 {
+		t.Errorf("expected 2 Netflix subscriptions, got %d", n)
+	}
+	expectProblem(t, invalid, http.StatusBadRequest, problem.TypeValidation)
+}
+
+func TestEndpoints_ScopedCallerOnlySeesOwnData(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	alice, bob := uuid.NewString(), uuid.NewString()
+	foreign := s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(1), UserID: bob, StartDate: "01-2025"})
+
+	// Act
+	own := s.do(http.MethodPost, "/api/subscriptions", SubscriptionRequest{
+		ServiceName: "Spotify", Price: intPtr(2), UserID: bob, StartDate: "01-2025",
+	}, testUserHeader, alice)
+	listed := s.do(http.MethodGet, "/api/subscriptions", nil, testUserHeader, alice)
+	get := s.do(http.MethodGet, "/api/subscriptions/"+foreign.ID, nil, testUserHeader, alice)
+	del := s.do(http.MethodDelete, "/api/subscriptions/"+foreign.ID, nil, testUserHeader, alice)
+
+	// Assert
+	if own.Code != http.StatusCreated || decode[SubscriptionResponse](t, own).UserID != alice {
+		t.Errorf("expected subscription created for the caller: %d %s", own.Code, own.Body.String())
+	}
+	if items := decode[[]SubscriptionResponse](t, listed); len(items) != 1 || items[0].UserID != alice {
+		t.Errorf("expected only the caller's subscription, got %+v", items)
+	}
+	expectProblem(t, get, http.StatusNotFound, problem.TypeNotFound)
+	expectProblem(t, del, http.StatusNotFound, problem.TypeNotFound)
+}
+
+// ==============================================================
+// ==============================================================
+// Bulk endpoints
+// ==============================================================
+// ==============================================================
+func TestEndpoints_BatchAllOrNothing(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	user := uuid.NewString()
+	existing := s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(1), UserID: user, StartDate: "01-2025"})
+
+	ops := BatchRequest{Operations: []BatchOperation{
+		{Op: "create", Subscription: &SubscriptionRequest{ServiceName: "Spotify", Price: intPtr(2), UserID: user, StartDate: "01-2025"}},
+		{Op: "delete", ID: existing.ID},
+		{Op: "update", ID: uuid.NewString(), Subscription: &SubscriptionRequest{ServiceName: "X", Price: intPtr(1), UserID: user, StartDate: "01-2025"}},
+	}}
+
+	// Act
+	w := s.do(http.MethodPost, "/api/subscriptions/batch", ops)
+	after := s.do(http.MethodGet, "/api/subscriptions", nil)
+
+	// Assert
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	resp := decode[BatchResponse](t, w)
+	if resp.Committed {
+		t.Error("expected batch to be rolled back")
+	}
+	if resp.Results[2].Status != http.StatusNotFound || resp.Results[0].Status != http.StatusFailedDependency {
+		t.Errorf("unexpected results: %+v", resp.Results)
+	}
+	if items := decode[[]SubscriptionResponse](t, after); len(items) != 1 || items[0].ID != existing.ID {
+		t.Errorf("expected store unchanged, got %+v", items)
+	}
+}
+
+func TestEndpoints_BatchBestEffort(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	user := uuid.NewString()
+
+	ops := BatchRequest{Mode: "best_effort", Operations: []BatchOperation{
+		{Op: "create", Subscription: &SubscriptionRequest{ServiceName: "Spotify", Price: intPtr(2), UserID: user, StartDate: "01-2025"}},
+		{Op: "create", Subscription: &SubscriptionRequest{ServiceName: "Spotify", Price: intPtr(3), UserID: user, StartDate: "02-2025"}},
+		{Op: "rename"},
+	}}
+
+	// Act
+	w := s.do(http.MethodPost, "/api/subscriptions/batch", ops)
+	invalid := s.do(http.MethodPost, "/api/subscriptions/batch", BatchRequest{Mode: "sometimes"})
+
+	// Assert
+	resp := decode[BatchResponse](t, w)
+	if w.Code != http.StatusOK || !resp.Committed {
+		t.Fatalf("expected committed batch, got %d: %s", w.Code, w.Body.String())
+	}
+	got := []int{resp.Results[0].Status, resp.Results[1].Status, resp.Results[2].Status}
+	want := []int{http.StatusCreated, http.StatusConflict, http.StatusBadRequest}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("result %d: expected %d, got %d", i, want[i], got[i])
+		}
+	}
+	expectProblem(t, invalid, http.StatusBadRequest, problem.TypeValidation)
+}
+
+func TestEndpoints_Import(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	user := uuid.NewString()
+	csv := "service_name,price,user_id,start_date,end_date\n" +
+		"Netflix,400," + user + ",01-2025,\n" +
+		"Spotify,abc," + user + ",01-2025,\n"
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "subs.csv")
+	_, _ = fw.Write([]byte(csv))
+	_ = mw.Close()
+
+	// Act
+	dry := s.do(http.MethodPost, "/api/subscriptions/import?dry_run=true", nil)
+	req := httptest.NewRequest(http.MethodPost, "/api/subscriptions/import", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w := httptest.NewRecorder()
+	s.r.ServeHTTP(w, req)
+	listed := s.do(http.MethodGet, "/api/subscriptions", nil)
+
+	// Assert
+	if dry.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for empty upload, got %d", dry.Code)
+	}
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	resp := decode[ImportResponse](t, w)
+	if resp.TotalRows != 2 || resp.Accepted != 1 || resp.Inserted != 1 || len(resp.Errors) != 1 {
+		t.Errorf("unexpected import result: %+v", resp)
+	}
+	if n := len(decode[[]SubscriptionResponse](t, listed)); n != 1 {
+		t.Errorf("expected 1 stored subscription, got %d", n)
+	}
+}
+
+func TestEndpoints_ImportReportsOverlapsAsRowErrors(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	user := uuid.NewString()
+	s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(400), UserID: user, StartDate: "01-2025"})
+	csv := "service_name,price,user_id,start_date,end_date\n" +
+		"Netflix,100," + user + ",03-2025,\n" +
+		"Spotify,200," + user + ",01-2025,03-2025\n" +
+		"Spotify,300," + user + ",02-2025,\n" +
+		"Okko,400," + user + ",01-2025,\n"
+
+	// Act
+	dry := s.do(http.MethodPost, "/api/subscriptions/import?dry_run=true", csv)
+	applied := s.do(http.MethodPost, "/api/subscriptions/import", csv)
+
+	// Assert
+	for _, w := range []*httptest.ResponseRecorder{dry, applied} {
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		resp := decode[ImportResponse](t, w)
+		if resp.Accepted != 2 || len(resp.Errors) != 2 {
+			t.Fatalf("unexpected import result: %+v", resp)
+		}
+		if resp.Errors[0].Row != 2 || resp.Errors[1].Row != 4 || resp.Errors[1].Error != "overlaps row 3" {
+			t.Errorf("expected overlaps on rows 2 and 4, got %+v", resp.Errors)
+		}
+	}
+	if n := decode[ImportResponse](t, applied).Inserted; n != 2 {
+		t.Errorf("expected 2 inserted rows, got %d", n)
+	}
+}
+
+func TestEndpoints_Export(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	user := uuid.NewString()
+	s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(400), UserID: user, StartDate: "01-2025"})
+	s.create(SubscriptionRequest{ServiceName: "Spotify", Price: intPtr(200), UserID: user, StartDate: "01-2025"})
+
+	// Act
+	csv := s.do(http.MethodGet, "/api/subscriptions/export", nil)
+	ndjson := s.do(http.MethodGet, "/api/subscriptions/export?format=ndjson&service_name=Spotify", nil)
+	invalid := s.do(http.MethodGet, "/api/subscriptions/export?format=pdf", nil)
+
+	// Assert
+	if csv.Code != http.StatusOK || !strings.HasPrefix(csv.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("unexpected csv export %d %q", csv.Code, csv.Header().Get("Content-Type"))
+	}
+	if lines := strings.Count(strings.TrimSpace(csv.Body.String()), "\n") + 1; lines != 3 {
+		t.Errorf("expected header and 2 rows, got %d lines", lines)
+	}
+	if ndjson.Code != http.StatusOK || !strings.Contains(ndjson.Body.String(), `"Spotify"`) ||
+		strings.Contains(ndjson.Body.String(), "Netflix") {
+		t.Errorf("unexpected ndjson export: %s", ndjson.Body.String())
+	}
+	expectProblem(t, invalid, http.StatusBadRequest, problem.TypeValidation)
+}
+
+// ==============================================================
+// ==============================================================
+// Aggregation endpoint
+// ==============================================================
+// ==============================================================
+func TestEndpoints_Total(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	alice, bob := uuid.NewString(), uuid.NewString()
+	s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(400), UserID: alice, StartDate: "01-2025", EndDate: "03-2025"})
+	s.create(SubscriptionRequest{ServiceName: "Spotify", Price: intPtr(100), UserID: alice, StartDate: "03-2025"})
+	s.create(SubscriptionRequest{ServiceName: "Netflix", Price: intPtr(1000), UserID: bob, StartDate: "01-2025"})
+
+	// Act
+	w := s.do(http.MethodGet, "/api/subscriptions/total?start_date=02-2025&end_date=04-2025&user_id="+alice, nil)
+	byService := s.do(http.MethodGet, "/api/subscriptions/total?start_date=02-2025&end_date=02-2025&service_name=Netflix", nil)
+	csv := s.do(http.MethodGet, "/api/subscriptions/total?start_date=02-2025&end_date=04-2025&format=csv", nil)
+	invalid := s.do(http.MethodGet, "/api/subscriptions/total?start_date=13-2025", nil)
+
+	// Assert
+	// Netflix 02-03 (2 x 400) and Spotify 03-04 (2 x 100)
+	if total := decode[map[string]any](t, w)["total"]; total != float64(1000) {
+		t.Errorf("expected total 1000, got %v", total)
+	}
+	if total := decode[map[string]any](t, byService)["total"]; total != float64(1400) {
+		t.Errorf("expected total 1400, got %v", total)
+	}
+	if csv.Code != http.StatusOK || !strings.HasPrefix(csv.Header().Get("Content-Type"), "text/csv") {
+		t.Errorf("unexpected csv report %d %q", csv.Code, csv.Header().Get("Content-Type"))
+	}
+	p := expectProblem(t, invalid, http.StatusBadRequest, problem.TypeValidation)
+	if len(p.Errors) != 2 {
+		t.Errorf("expected start_date and end_date errors, got %+v", p.Errors)
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// GraphQL
+// ==============================================================
+// ==============================================================
+func TestGraphQL_Query(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+	created := s.create(SubscriptionRequest{
+		ServiceName: "Netflix",
+		Price:       intPtr(500),
+		UserID:      uuid.NewString(),
+		StartDate:   "01-2025",
+	})
+	query := `{ users { id total(startDate: "01-2025", endDate: "03-2025") } }`
+
+	// Act
+	post := s.do(http.MethodPost, "/graphql", GraphQLRequest{Query: query})
+	get := s.do(http.MethodGet, "/graphql?query="+url.QueryEscape(query), nil)
+
+	// Assert
+	for _, w := range []*httptest.ResponseRecorder{post, get} {
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+		body := decode[struct {
+			Data struct {
+				Users []struct {
+					ID    string
+					Total int
+				}
+			}
+		}](t, w)
+		if len(body.Data.Users) != 1 || body.Data.Users[0].ID != created.UserID || body.Data.Users[0].Total != 1500 {
+			t.Errorf("unexpected data %s", w.Body.String())
+		}
+	}
+}
+
+func TestGraphQL_InvalidRequest(t *testing.T) {
+	// Arrange
+	s := newTestServer(t)
+
+	// Act
+	missing := s.do(http.MethodPost, "/graphql", GraphQLRequest{})
+	malformed := s.do(http.MethodPost, "/graphql", "{")
+	variables := s.do(http.MethodGet, "/graphql?query=%7B__typename%7D&variables=nope", nil)
+
+	// Assert
+	expectProblem(t, missing, http.StatusBadRequest, problem.TypeValidation)
+	expectProblem(t, malformed, http.StatusBadRequest, problem.TypeValidation)
+	expectProblem(t, variables, http.StatusBadRequest, problem.TypeValidation)
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// mapErrorToHTTP converts an error to the problem returned to clients.
+// It is the single place deciding status codes for handler errors.
+func mapErrorToHTTP(err error) problem.Details {
+	var verr *domain.ValidationError
+
+	switch {
+	case err == nil:
+		return problem.Details{Status: http.StatusOK}
+	case errors.As(err, &verr):
+		p := problem.New(problem.TypeValidation, http.StatusBadRequest, verr.Error())
+		p.Errors = verr.Fields
+		return p
+	case errors.Is(err, domain.ErrInvalidInput):
+		return problem.New(problem.TypeValidation, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrNotFound):
+		return problem.New(problem.TypeNotFound, http.StatusNotFound, "not found")
+	case errors.Is(err, domain.ErrConflict):
+		return problem.New(problem.TypeConflict, http.StatusConflict, "subscription overlaps an existing one")
+	case errors.Is(err, context.DeadlineExceeded):
+		return problem.New(problem.TypeTimeout, http.StatusGatewayTimeout, "timeout")
+	default:
+		return problem.New(problem.TypeInternal, http.StatusInternalServerError, "db error")
+	}
+}
+
+// respondError writes the problem response for err.
+func respondError(c *gin.Context, err error) {
+	problem.Write(c, mapErrorToHTTP(err))
+}
+
+// logFailure logs a failed storage call. Failures mapped to client
+// errors, such as not found, are warnings; the rest are errors.
+func logFailure(c *gin.Context, msg string, err error, attrs ...any) {
+	level := slog.LevelWarn
+	if mapErrorToHTTP(err).Status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	slog.Log(c.Request.Context(), level, msg, append(attrs, "error", err)...)
+}
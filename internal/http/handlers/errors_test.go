@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+)
+
+// ==============================================================
+// ==============================================================
+// mapErrorToHTTP
+// ==============================================================
+// ==============================================================
+func TestMapErrorToHTTP_Nil(t *testing.T) {
+	// Act
+	p := mapErrorToHTTP(nil)
+
+	// Assert
+	if p.Status != http.StatusOK {
+		t.Errorf("expected %d, got %d", http.StatusOK, p.Status)
+	}
+	if p.Type != "" {
+		t.Errorf("expected no problem type, got %q", p.Type)
+	}
+}
+
+func TestMapErrorToHTTP_Statuses(t *testing.T) {
+	cases := []struct {
+		name   string
+		err    error
+		status int
+		typ    string
+	}{
+		{"not found", postgres.ErrNotFound, http.StatusNotFound, problem.TypeNotFound},
+		{"not found wrapped", fmt.Errorf("query failed: %w", postgres.ErrNotFound), http.StatusNotFound, problem.TypeNotFound},
+		{"conflict", fmt.Errorf("update failed: %w", postgres.ErrConflict), http.StatusConflict, problem.TypeConflict},
+		{"deadline", context.DeadlineExceeded, http.StatusGatewayTimeout, problem.TypeTimeout},
+		{"deadline joined", errors.Join(context.DeadlineExceeded), http.StatusGatewayTimeout, problem.TypeTimeout},
+		{"invalid input", fmt.Errorf("%w: bad", domain.ErrInvalidInput), http.StatusBadRequest, problem.TypeValidation},
+		{"internal", errors.New("some db error"), http.StatusInternalServerError, problem.TypeInternal},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Act
+			p := mapErrorToHTTP(tc.err)
+
+			// Assert
+			if p.Status != tc.status || p.Type != tc.typ {
+				t.Errorf("expected %d %s, got %d %s", tc.status, tc.typ, p.Status, p.Type)
+			}
+			if p.Title == "" || p.Detail == "" {
+				t.Errorf("expected title and detail, got %+v", p)
+			}
+		})
+	}
+}
+
+func TestMapErrorToHTTP_InternalDetailHidesCause(t *testing.T) {
+	// Act
+	p := mapErrorToHTTP(errors.New("pq: password authentication failed"))
+
+	// Assert
+	if p.Detail != "db error" {
+		t.Errorf("expected generic detail, got %q", p.Detail)
+	}
+}
+
+func TestMapErrorToHTTP_ValidationFields(t *testing.T) {
+	// Arrange
+	var verr domain.ValidationError
+	verr.Add("price", "must be >= 0")
+	verr.Add("user_id", "must be a UUID")
+
+	// Act
+	p := mapErrorToHTTP(fmt.Errorf("create: %w", verr.Err()))
+
+	// Assert
+	if p.Status != http.StatusBadRequest || len(p.Errors) != 2 {
+		t.Fatalf("unexpected problem %+v", p)
+	}
+	if p.Errors[1].Field != "user_id" {
+		t.Errorf("unexpected field errors %+v", p.Errors)
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// respondError
+// ==============================================================
+// ==============================================================
+func TestRespondError_WritesProblemJSON(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/api/subscriptions/:id", func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logging.NewRequest(c.Request.Context(), "req-1"))
+		respondError(c, domain.Invalid("id", "must be a UUID"))
+	})
+	w := httptest.NewRecorder()
+
+	// Act
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/subscriptions/x", nil))
+
+	// Assert
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+		t.Errorf("unexpected content type %q", ct)
+	}
+
+	var p problem.Details
+	if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+		t.Fatalf("invalid body: %v", err)
+	}
+	if p.Type != problem.TypeValidation || p.Instance != "/api/subscriptions/x" || p.RequestID != "req-1" {
+		t.Errorf("unexpected problem %+v", p)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Field != "id" {
+		t.Errorf("unexpected field errors %+v", p.Errors)
+	}
+}
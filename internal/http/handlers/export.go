@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/xlsx"
+	"github.com/gin-gonic/gin"
+)
+
+// Export limits.
+const (
+	// exportTimeout replaces the regular db timeout, large exports take
+	// longer; it bounds how long an export holds a pool connection
+	exportTimeout = 2 * time.Minute
+	// maxConcurrentExports keeps exports from taking over the pool
+	maxConcurrentExports = 2
+	// exportFlushEvery controls how often buffered rows are sent to the client
+	exportFlushEvery = 500
+)
+
+// spreadsheetSafe defuses values spreadsheets would run as formulas by
+// prefixing them with a quote, which shows them as text.
+func spreadsheetSafe(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// exportColumns defines column order for tabular formats.
+var exportColumns = []string{
+	"id",
+	"service_name",
+	"price",
+	"user_id",
+	"start_date",
+	"end_date",
+	"created_at",
+	"updated_at",
+}
+
+// exportWriter encodes subscriptions in one export format.
+type exportWriter interface {
+	Write(r SubscriptionResponse) error
+	Flush() error
+	Close() error
+}
+
+// exportFormat describes a supported export format.
+type exportFormat struct {
+	contentType string
+	extension   string
+	newWriter   func(w io.Writer) (exportWriter, error)
+}
+
+// exportFormats lists supported export formats by name.
+var exportFormats = map[string]exportFormat{
+	"csv": {
+		contentType: "text/csv; charset=utf-8",
+		extension:   "csv",
+		newWriter:   newCSVExportWriter,
+	},
+	"ndjson": {
+		contentType: "application/x-ndjson",
+		extension:   "ndjson",
+		newWriter:   newNDJSONExportWriter,
+	},
+	"xlsx": {
+		contentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+		extension:   "xlsx",
+		newWriter:   newXLSXExportWriter,
+	},
+}
+
+// csvExportWriter writes subscriptions as CSV rows.
+type csvExportWriter struct {
+	w *csv.Writer
+}
+
+// newCSVExportWriter creates CSV writer and writes the header row.
+func newCSVExportWriter(w io.Writer) (exportWriter, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(exportColumns); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{w: cw}, nil
+}
+
+func (e *csvExportWriter) Write(r SubscriptionResponse) error {
+	return e.w.Write([]string{
+		r.ID,
+		spreadsheetSafe(r.ServiceName),
+		strconv.Itoa(r.Price),
+		r.UserID,
+		r.StartDate,
+		r.EndDate,
+		r.CreatedAt,
+		r.UpdatedAt,
+	})
+}
+
+func (e *csvExportWriter) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvExportWriter) Close() error {
+	return e.Flush()
+}
+
+// ndjsonExportWriter writes one JSON object per line.
+type ndjsonExportWriter struct {
+	enc *json.Encoder
+}
+
+// newNDJSONExportWriter creates NDJSON writer.
+func newNDJSONExportWriter(w io.Writer) (exportWriter, error) {
+	return &ndjsonExportWriter{enc: json.NewEncoder(w)}, nil
+}
+
+func (e *ndjsonExportWriter) Write(r SubscriptionResponse) error {
+	return e.enc.Encode(r)
+}
+
+// Flush is a no-op, the encoder writes every line directly.
+func (e *ndjsonExportWriter) Flush() error {
+	return nil
+}
+
+func (e *ndjsonExportWriter) Close() error {
+	return nil
+}
+
+// xlsxExportWriter writes subscriptions into a single worksheet.
+type xlsxExportWriter struct {
+	w *xlsx.Writer
+}
+
+// newXLSXExportWriter creates XLSX writer and writes the header row.
+func newXLSXExportWriter(w io.Writer) (exportWriter, error) {
+	xw, err := xlsx.NewWriter(w, "Subscriptions")
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]xlsx.Cell, 0, len(exportColumns))
+	for _, col := range exportColumns {
+		header = append(header, xlsx.String(col))
+	}
+	if err := xw.WriteRow(header...); err != nil {
+		return nil, err
+	}
+
+	return &xlsxExportWriter{w: xw}, nil
+}
+
+func (e *xlsxExportWriter) Write(r SubscriptionResponse) error {
+	return e.w.WriteRow(
+		xlsx.String(r.ID),
+		xlsx.String(spreadsheetSafe(r.ServiceName)),
+		xlsx.Int(r.Price),
+		xlsx.String(r.UserID),
+		xlsx.String(r.StartDate),
+		xlsx.String(r.EndDate),
+		xlsx.String(r.CreatedAt),
+		xlsx.String(r.UpdatedAt),
+	)
+}
+
+func (e *xlsxExportWriter) Flush() error {
+	return e.w.Flush()
+}
+
+func (e *xlsxExportWriter) Close() error {
+	return e.w.Close()
+}
+
+// Export streams subscriptions as a downloadable file.
+//
+// @Summary Export subscriptions
+// @Description Streams subscriptions as CSV, NDJSON or XLSX
+// @Tags subscriptions
+// @Produce text/csv,application/x-ndjson,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "Export format: csv (default), ndjson or xlsx"
+// @Param user_id query string false "User UUID"
+// @Param service_name query string false "Service name"
+// @Param as_of query string false "RFC3339 time, answer from the data as it was at that moment"
+// @Success 200 {file} file
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 503 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/export [get]
+func (h *SubscriptionsHandler) Export(c *gin.Context) {
+	name := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", "csv")))
+	format, ok := exportFormats[name]
+	if !ok {
+		respondError(c, domain.Invalid("format", "must be csv, xlsx or ndjson"))
+		return
+	}
+
+	f, err := parseListFilter(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Export: invalid request", "error", err)
+		respondError(c, err)
+		return
+	}
+
+	// Each export holds a pool connection while it streams
+	select {
+	case h.exports <- struct{}{}:
+		defer func() { <-h.exports }()
+	default:
+		slog.WarnContext(c.Request.Context(), "Export: too many concurrent exports")
+		c.Header("Retry-After", "30")
+		problem.Write(c, problem.New(problem.TypeUnavailable, http.StatusServiceUnavailable,
+			"too many exports in progress, retry later"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), exportTimeout)
+	defer cancel()
+
+	// Large exports outlive the server write timeout
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Now().Add(exportTimeout)); err != nil &&
+		!errors.Is(err, http.ErrNotSupported) {
+		slog.WarnContext(c.Request.Context(), "Export: extend write deadline", "error", err)
+	}
+
+	var (
+		w     exportWriter
+		count int
+	)
+
+	// Headers are sent lazily, so query errors can still return JSON
+	start := func() error {
+		filename := fmt.Sprintf("subscriptions-%s.%s",
+			time.Now().UTC().Format("20060102T150405Z"), format.extension)
+
+		c.Header("Content-Type", format.contentType)
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.Status(http.StatusOK)
+
+		var err error
+		w, err = format.newWriter(c.Writer)
+		return err
+	}
+
+	err = h.svc.Stream(ctx, f, func(s domain.Subscription) error {
+		if w == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := w.Write(toResponse(s)); err != nil {
+			return err
+		}
+
+		// Push buffered rows to the client periodically
+		count++
+		if count%exportFlushEvery == 0 {
+			if err := w.Flush(); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		logFailure(c, "Export failed", err, "rows", count)
+
+		if w == nil {
+			respondError(c, err)
+		}
+		// Otherwise the client receives a truncated file
+		return
+	}
+
+	// Empty result still produces a valid file
+	if w == nil {
+		if err := start(); err != nil {
+			slog.ErrorContext(c.Request.Context(), "Export: writer error", "error", err)
+			return
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		slog.ErrorContext(c.Request.Context(), "Export: close error", "error", err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Subscriptions exported", "format", name, "rows", count)
+}
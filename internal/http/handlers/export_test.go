@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/memory"
+	"github.com/gin-gonic/gin"
+)
+
+// sampleExportRow returns a response used by export writer tests.
+func sampleExportRow() SubscriptionResponse {
+	return SubscriptionResponse{
+		ID:          "6f1c2b9e-0d7a-4c55-9b61-0c1d2e3f4a5b",
+		ServiceName: "Yandex Plus, family",
+		Price:       400,
+		UserID:      "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		StartDate:   "07-2025",
+		CreatedAt:   "2025-07-01T00:00:00Z",
+		UpdatedAt:   "2025-07-01T00:00:00Z",
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// export writers
+// ==============================================================
+// ==============================================================
+func TestCSVExportWriter_WritesHeaderAndRows(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	w, err := newCSVExportWriter(&buf)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Act
+	_ = w.Write(sampleExportRow())
+	_ = w.Close()
+
+	// Assert
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if lines[0] != strings.Join(exportColumns, ",") {
+		t.Errorf("unexpected header %q", lines[0])
+	}
+	if !strings.Contains(lines[1], `"Yandex Plus, family",400`) {
+		t.Errorf("expected quoted service name and price, got %q", lines[1])
+	}
+}
+
+func TestCSVExportWriter_DefusesFormulas(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	w, _ := newCSVExportWriter(&buf)
+	row := sampleExportRow()
+	row.ServiceName = "=HYPERLINK(\"http://evil\")"
+
+	// Act
+	_ = w.Write(row)
+	_ = w.Close()
+
+	// Assert
+	if !strings.Contains(buf.String(), `"'=HYPERLINK(""http://evil"")"`) {
+		t.Errorf("expected the formula prefixed with a quote, got %q", buf.String())
+	}
+}
+
+func TestSpreadsheetSafe(t *testing.T) {
+	cases := map[string]string{
+		"Netflix": "Netflix",
+		"=1+1":    "'=1+1",
+		"+7":      "'+7",
+		"-7":      "'-7",
+		"@SUM(1)": "'@SUM(1)",
+		"":        "",
+	}
+	for in, want := range cases {
+		if got := spreadsheetSafe(in); got != want {
+			t.Errorf("spreadsheetSafe(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestNDJSONExportWriter_WritesOneObjectPerLine(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	w, _ := newNDJSONExportWriter(&buf)
+
+	// Act
+	_ = w.Write(sampleExportRow())
+	_ = w.Write(sampleExportRow())
+	_ = w.Close()
+
+	// Assert
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+	var got SubscriptionResponse
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("invalid json line: %v", err)
+	}
+	if got.Price != 400 {
+		t.Errorf("expected price 400, got %d", got.Price)
+	}
+}
+
+func TestExportFormats_Supported(t *testing.T) {
+	for _, name := range []string{"csv", "ndjson", "xlsx"} {
+		if _, ok := exportFormats[name]; !ok {
+			t.Errorf("expected format %q to be supported", name)
+		}
+	}
+}
+
+func TestExport_RejectsWhenExportsAreBusy(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	h := NewSubscriptionsHandler(service.NewSubscriptionService(memory.NewSubscriptionStore()), time.Second)
+	for range maxConcurrentExports {
+		h.exports <- struct{}{}
+	}
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/subscriptions/export", nil)
+
+	// Act
+	h.Export(c)
+
+	// Assert
+	expectProblem(t, w, http.StatusServiceUnavailable, problem.TypeUnavailable)
+}
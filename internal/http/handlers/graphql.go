@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/graphqlapi"
+	"github.com/gin-gonic/gin"
+)
+
+// GraphQLHandler serves the GraphQL endpoint.
+type GraphQLHandler struct {
+	gql       *graphqlapi.Server
+	dbTimeout time.Duration
+}
+
+// NewGraphQLHandler creates GraphQL handler.
+func NewGraphQLHandler(gql *graphqlapi.Server, dbTimeout time.Duration) *GraphQLHandler {
+	return &GraphQLHandler{
+		gql:       gql,
+		dbTimeout: dbTimeout,
+	}
+}
+
+// GraphQLRequest defines a GraphQL query.
+type GraphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Query runs a GraphQL query.
+//
+// POST takes a JSON body, GET the query, operationName and variables
+// parameters. Query errors are reported in the errors field of a 200
+// response; the schema is available through introspection.
+func (h *GraphQLHandler) Query(c *gin.Context) {
+	var req GraphQLRequest
+
+	// GET carries the query in the URL, POST in a JSON body
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if v := c.Query("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				respondError(c, domain.Invalid("variables", "must be a JSON object"))
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		slog.WarnContext(c.Request.Context(), "GraphQL: invalid request body", "error", err)
+		respondError(c, domain.Invalid("body", "invalid JSON"))
+		return
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		respondError(c, domain.Invalid("query", "is required"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	c.JSON(http.StatusOK, h.gql.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
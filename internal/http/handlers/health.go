@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Readiness states.
+const (
+	healthStatusOK           = "ok"
+	healthStatusError        = "error"
+	healthStatusReady        = "ready"
+	healthStatusNotReady     = "not_ready"
+	healthStatusShuttingDown = "shutting_down"
+)
+
+// HealthCheck is one dependency checked by the readiness probe.
+// Check returns optional details that are reported with the result.
+type HealthCheck struct {
+	Name  string
+	Check func(ctx context.Context) (any, error)
+}
+
+// HealthHandler serves liveness and readiness probes.
+type HealthHandler struct {
+	checks       []HealthCheck
+	timeout      time.Duration
+	shuttingDown atomic.Bool
+}
+
+// NewHealthHandler creates health handler. Each readiness check gets timeout.
+func NewHealthHandler(timeout time.Duration, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+	}
+}
+
+// SetShuttingDown makes the readiness probe fail from now on.
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+// CheckResult is the outcome of one readiness check.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+	Details   any     `json:"details,omitempty"`
+}
+
+// ReadinessResponse defines readiness probe response.
+type ReadinessResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Live reports that the process is running.
+// Probes live outside /api and are not part of the Swagger docs.
+func (h *HealthHandler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": healthStatusOK})
+}
+
+// Ready runs all dependency checks concurrently and reports 503 if any
+// fails or the server is shutting down.
+func (h *HealthHandler) Ready(c *gin.Context) {
+	if h.shuttingDown.Load() {
+		c.JSON(http.StatusServiceUnavailable, ReadinessResponse{
+			Status: healthStatusShuttingDown,
+			Checks: map[string]CheckResult{},
+		})
+		return
+	}
+
+	results := h.runChecks(c.Request.Context())
+
+	resp := ReadinessResponse{Status: healthStatusReady, Checks: results}
+	code := http.StatusOK
+	for _, r := range results {
+		if r.Status != healthStatusOK {
+			resp.Status, code = healthStatusNotReady, http.StatusServiceUnavailable
+			break
+		}
+	}
+
+	c.JSON(code, resp)
+}
+
+// runChecks runs every check with its own timeout.
+func (h *HealthHandler) runChecks(ctx context.Context) map[string]CheckResult {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(h.checks))
+	)
+
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			start := time.Now()
+			details, err := check.Check(checkCtx)
+
+			r := CheckResult{
+				Status:    healthStatusOK,
+				LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+				Details:   details,
+			}
+			if err != nil {
+				r.Status, r.Error = healthStatusError, err.Error()
+			}
+
+			mu.Lock()
+			results[check.Name] = r
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// serveReady runs the readiness probe of h.
+func serveReady(h *HealthHandler) (int, ReadinessResponse) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/readyz", nil)
+
+	h.Ready(c)
+
+	var resp ReadinessResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp
+}
+
+// okCheck always succeeds with details.
+var okCheck = HealthCheck{
+	Name: "postgres",
+	Check: func(context.Context) (any, error) {
+		return map[string]int{"total_conns": 2}, nil
+	},
+}
+
+// ==============================================================
+// ==============================================================
+// readiness
+// ==============================================================
+// ==============================================================
+func TestReady_AllChecksPass(t *testing.T) {
+	// Act
+	code, resp := serveReady(NewHealthHandler(time.Second, okCheck))
+
+	// Assert
+	if code != http.StatusOK || resp.Status != healthStatusReady {
+		t.Fatalf("expected ready, got %d %+v", code, resp)
+	}
+	if r := resp.Checks["postgres"]; r.Status != healthStatusOK || r.Details == nil {
+		t.Errorf("unexpected check result: %+v", r)
+	}
+}
+
+func TestReady_FailingCheck(t *testing.T) {
+	// Arrange
+	failing := HealthCheck{
+		Name:  "migrations",
+		Check: func(context.Context) (any, error) { return nil, errors.New("have 6, want 7") },
+	}
+
+	// Act
+	code, resp := serveReady(NewHealthHandler(time.Second, okCheck, failing))
+
+	// Assert
+	if code != http.StatusServiceUnavailable || resp.Status != healthStatusNotReady {
+		t.Fatalf("expected not ready, got %d %+v", code, resp)
+	}
+	if r := resp.Checks["migrations"]; r.Status != healthStatusError || r.Error != "have 6, want 7" {
+		t.Errorf("unexpected check result: %+v", r)
+	}
+}
+
+func TestReady_CheckTimeout(t *testing.T) {
+	// Arrange
+	hanging := HealthCheck{
+		Name: "postgres",
+		Check: func(ctx context.Context) (any, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}
+
+	// Act
+	code, resp := serveReady(NewHealthHandler(20*time.Millisecond, hanging))
+
+	// Assert
+	if code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", code)
+	}
+	if r := resp.Checks["postgres"]; r.Error != context.DeadlineExceeded.Error() {
+		t.Errorf("expected deadline error, got %+v", r)
+	}
+}
+
+func TestReady_ShuttingDown(t *testing.T) {
+	// Arrange
+	h := NewHealthHandler(time.Second, okCheck)
+	h.SetShuttingDown()
+
+	// Act
+	code, resp := serveReady(h)
+
+	// Assert
+	if code != http.StatusServiceUnavailable || resp.Status != healthStatusShuttingDown {
+		t.Fatalf("expected shutting down, got %d %+v", code, resp)
+	}
+}
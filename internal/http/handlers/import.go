@@ -0,0 +1,356 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Import limits.
+const (
+	maxImportBytes = 10 << 20 // 10 MiB
+	maxImportRows  = 10000
+	// importTimeout replaces the regular db timeout, checking and copying
+	// a large file with its audit entries and events takes longer
+	importTimeout = 2 * time.Minute
+)
+
+// importFields lists CSV target fields and whether they are required.
+var importFields = []struct {
+	name     string
+	required bool
+}{
+	{"service_name", true},
+	{"price", true},
+	{"user_id", true},
+	{"start_date", true},
+	{"end_date", false},
+}
+
+// importRow is an accepted CSV row.
+type importRow struct {
+	line int // line the record starts on
+	sub  domain.Subscription
+}
+
+// ImportRowError describes a rejected CSV row.
+type ImportRowError struct {
+	Row   int    `json:"row"` // 1-based line number, header is row 1
+	Error string `json:"error"`
+}
+
+// ImportResponse defines CSV import API response.
+type ImportResponse struct {
+	DryRun    bool             `json:"dry_run"`
+	TotalRows int              `json:"total_rows"`
+	Accepted  int              `json:"accepted"`
+	Inserted  int64            `json:"inserted"`
+	Errors    []ImportRowError `json:"errors"`
+}
+
+// parseColumnMapping parses "field:Header,field:Header" into a field->header map.
+// Fields without an explicit mapping use their own name as the header.
+func parseColumnMapping(s string) (map[string]string, error) {
+	mapping := make(map[string]string, len(importFields))
+	for _, f := range importFields {
+		mapping[f.name] = f.name
+	}
+
+	if strings.TrimSpace(s) == "" {
+		return mapping, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		field, header, ok := strings.Cut(pair, ":")
+		field = strings.TrimSpace(field)
+		header = strings.TrimSpace(header)
+		if !ok || field == "" || header == "" {
+			return nil, domain.Invalid("columns", fmt.Sprintf("invalid column mapping %q", pair))
+		}
+		if _, known := mapping[field]; !known {
+			return nil, domain.Invalid("columns", fmt.Sprintf("unknown field %q", field))
+		}
+		mapping[field] = header
+	}
+
+	return mapping, nil
+}
+
+// parseImportCSV reads CSV rows and validates them like the create endpoint.
+// It returns accepted rows, row errors and the number of data rows.
+func parseImportCSV(
+	r io.Reader,
+	mapping map[string]string,
+	delimiter rune,
+) ([]importRow, []ImportRowError, int, error) {
+
+	reader := csv.NewReader(r)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, 0, domain.Invalid("file", "missing csv header")
+	}
+
+	// Resolve field positions from header names
+	positions := make(map[string]int, len(header))
+	for i, name := range header {
+		positions[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	index := make(map[string]int, len(importFields))
+	for _, f := range importFields {
+		pos, ok := positions[strings.ToLower(mapping[f.name])]
+		if !ok {
+			if f.required {
+				return nil, nil, 0, domain.Invalid("file", fmt.Sprintf("missing column %q", mapping[f.name]))
+			}
+			pos = -1
+		}
+		index[f.name] = pos
+	}
+
+	field := func(record []string, name string) string {
+		pos := index[name]
+		if pos < 0 || pos >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[pos])
+	}
+
+	var (
+		rows    []importRow
+		rowErrs []ImportRowError
+		total   int
+	)
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		total++
+		if total > maxImportRows {
+			return nil, nil, 0, domain.Invalid("file", fmt.Sprintf("at most %d rows allowed", maxImportRows))
+		}
+
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rowErrs = append(rowErrs, ImportRowError{Row: parseErr.StartLine, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, nil, 0, domain.Invalid("file", "read csv: "+err.Error())
+		}
+
+		// Report the line the record starts on
+		row, _ := reader.FieldPos(0)
+
+		// An empty price is reported as missing with the other fields
+		var price *int
+		if v := field(record, "price"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				rowErrs = append(rowErrs, ImportRowError{Row: row, Error: "invalid price"})
+				continue
+			}
+			price = &n
+		}
+
+		// Reuse create endpoint validation
+		sub, err := service.ParseSubscription(service.SubscriptionInput{
+			ServiceName: field(record, "service_name"),
+			Price:       price,
+			UserID:      field(record, "user_id"),
+			StartDate:   field(record, "start_date"),
+			EndDate:     field(record, "end_date"),
+		}, uuid.New())
+		if err != nil {
+			rowErrs = append(rowErrs, ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+
+		rows = append(rows, importRow{line: row, sub: sub})
+	}
+
+	return rows, rowErrs, total, nil
+}
+
+// rejectOverlaps splits accepted rows into the subscriptions to insert
+// and errors for rows overlapping a stored subscription or an earlier row.
+func (h *SubscriptionsHandler) rejectOverlaps(
+	ctx context.Context,
+	rows []importRow,
+) ([]domain.Subscription, []ImportRowError, error) {
+
+	subs := make([]domain.Subscription, len(rows))
+	for i, r := range rows {
+		subs[i] = r.sub
+	}
+
+	overlaps, err := h.svc.Overlaps(ctx, subs)
+	if err != nil || len(overlaps) == 0 {
+		return subs, nil, err
+	}
+
+	rejected := make(map[int]bool, len(overlaps))
+	rowErrs := make([]ImportRowError, 0, len(overlaps))
+	for _, o := range overlaps {
+		msg := "overlaps an existing subscription of the user to the service"
+		if o.Earlier >= 0 {
+			msg = fmt.Sprintf("overlaps row %d", rows[o.Earlier].line)
+		}
+		rejected[o.Index] = true
+		rowErrs = append(rowErrs, ImportRowError{Row: rows[o.Index].line, Error: msg})
+	}
+
+	kept := subs[:0]
+	for i, sub := range subs {
+		if !rejected[i] {
+			kept = append(kept, sub)
+		}
+	}
+
+	return kept, rowErrs, nil
+}
+
+// openImportBody returns the CSV stream from a multipart "file" field or the raw body.
+func openImportBody(c *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, domain.Invalid("file", "is required")
+		}
+		return fh.Open()
+	}
+
+	return c.Request.Body, nil
+}
+
+// Import creates subscriptions from a CSV file.
+//
+// @Summary Import subscriptions from CSV
+// @Description Validates CSV rows and bulk-inserts the accepted ones
+// @Tags subscriptions
+// @Accept text/csv,multipart/form-data
+// @Produce json
+// @Param file formData file false "CSV file (multipart upload)"
+// @Param dry_run query bool false "Validate only, do not insert"
+// @Param columns query string false "Column mapping, e.g. service_name:Service,price:Cost"
+// @Param delimiter query string false "Field delimiter, defaults to comma"
+// @Success 200 {object} ImportResponse
+// @Failure 400 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/import [post]
+func (h *SubscriptionsHandler) Import(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		respondError(c, domain.Invalid("dry_run", "must be a boolean"))
+		return
+	}
+
+	mapping, err := parseColumnMapping(c.Query("columns"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+
+	delimiter := ','
+	if d := c.Query("delimiter"); d != "" {
+		if utf8.RuneCountInString(d) != 1 {
+			respondError(c, domain.Invalid("delimiter", "must be a single character"))
+			return
+		}
+		delimiter, _ = utf8.DecodeRuneInString(d)
+	}
+
+	// Limit upload size
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+
+	body, err := openImportBody(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Import: invalid upload", "error", err)
+		respondError(c, err)
+		return
+	}
+	defer body.Close()
+
+	rows, rowErrs, total, err := parseImportCSV(body, mapping, delimiter)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Import: invalid csv", "error", err)
+		respondError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), importTimeout)
+	defer cancel()
+
+	// Large imports outlive the server write timeout
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Now().Add(importTimeout)); err != nil &&
+		!errors.Is(err, http.ErrNotSupported) {
+		slog.WarnContext(c.Request.Context(), "Import: extend write deadline", "error", err)
+	}
+
+	// Reject rows the overlap constraint would refuse, also on dry runs
+	subs, overlapErrs, err := h.rejectOverlaps(ctx, rows)
+	if err != nil {
+		logFailure(c, "Import failed", err)
+
+		respondError(c, err)
+		return
+	}
+	rowErrs = append(rowErrs, overlapErrs...)
+	sort.SliceStable(rowErrs, func(i, j int) bool { return rowErrs[i].Row < rowErrs[j].Row })
+
+	resp := ImportResponse{
+		DryRun:    dryRun,
+		TotalRows: total,
+		Accepted:  len(subs),
+		Errors:    rowErrs,
+	}
+	if resp.Errors == nil {
+		resp.Errors = []ImportRowError{}
+	}
+
+	if !dryRun && len(subs) > 0 {
+		resp.Inserted, err = h.svc.CreateMany(ctx, subs)
+		if err != nil {
+			logFailure(c, "Import failed", err)
+
+			respondError(c, err)
+			return
+		}
+	}
+
+	slog.InfoContext(c.Request.Context(), "Import processed",
+		"rows", total,
+		"accepted", resp.Accepted,
+		"rejected", len(rowErrs),
+		"inserted", resp.Inserted,
+		"dry_run", dryRun,
+	)
+
+	c.JSON(http.StatusOK, resp)
+}
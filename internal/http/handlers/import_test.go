@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// ==============================================================
+// ==============================================================
+// parseColumnMapping
+// ==============================================================
+// ==============================================================
+func TestParseColumnMapping_Defaults(t *testing.T) {
+	// Act
+	mapping, err := parseColumnMapping("")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mapping["service_name"] != "service_name" || mapping["end_date"] != "end_date" {
+		t.Errorf("expected identity mapping, got %v", mapping)
+	}
+}
+
+func TestParseColumnMapping_Custom(t *testing.T) {
+	// Act
+	mapping, err := parseColumnMapping("service_name: Service , price:Monthly Cost")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mapping["service_name"] != "Service" {
+		t.Errorf("expected Service, got %q", mapping["service_name"])
+	}
+	if mapping["price"] != "Monthly Cost" {
+		t.Errorf("expected Monthly Cost, got %q", mapping["price"])
+	}
+	if mapping["user_id"] != "user_id" {
+		t.Errorf("expected user_id default, got %q", mapping["user_id"])
+	}
+}
+
+func TestParseColumnMapping_Invalid(t *testing.T) {
+	for _, input := range []string{"service_name", "unknown:Foo", "price:"} {
+		if _, err := parseColumnMapping(input); err == nil {
+			t.Errorf("expected error for %q, got nil", input)
+		}
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// parseImportCSV
+// ==============================================================
+// ==============================================================
+func TestParseImportCSV_AcceptsValidAndReportsInvalidRows(t *testing.T) {
+	// Arrange
+	userID := uuid.New().String()
+	input := "Service;Cost;user_id;start_date;end_date\n" +
+		"Netflix;500;" + userID + ";07-2025;\n" +
+		"Spotify;abc;" + userID + ";07-2025;\n" +
+		"Yandex;300;not-a-uuid;07-2025;\n" +
+		"Kinopoisk;200;" + userID + ";07-2025;06-2025\n" +
+		"Okko;100;" + userID + ";01-2025;03-2025\n"
+	mapping, _ := parseColumnMapping("service_name:service,price:cost")
+
+	// Act
+	rows, rowErrs, total, err := parseImportCSV(strings.NewReader(input), mapping, ';')
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if total != 5 {
+		t.Errorf("expected 5 rows, got %d", total)
+	}
+	if len(rows) != 2 || rows[0].line != 2 || rows[1].line != 6 {
+		t.Fatalf("expected accepted rows 2 and 6, got %+v", rows)
+	}
+	if rows[1].sub.EndDate == nil {
+		t.Error("expected end_date to be set for second accepted row")
+	}
+
+	expectedRows := []int{3, 4, 5}
+	if len(rowErrs) != len(expectedRows) {
+		t.Fatalf("expected %d row errors, got %v", len(expectedRows), rowErrs)
+	}
+	for i, row := range expectedRows {
+		if rowErrs[i].Row != row {
+			t.Errorf("expected error on row %d, got row %d", row, rowErrs[i].Row)
+		}
+	}
+}
+
+func TestParseImportCSV_MissingRequiredColumn(t *testing.T) {
+	// Arrange
+	input := "service_name,price,start_date\nNetflix,500,07-2025\n"
+	mapping, _ := parseColumnMapping("")
+
+	// Act
+	_, _, _, err := parseImportCSV(strings.NewReader(input), mapping, ',')
+
+	// Assert
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseImportCSV_EmptyInput(t *testing.T) {
+	// Arrange
+	mapping, _ := parseColumnMapping("")
+
+	// Act
+	_, _, _, err := parseImportCSV(strings.NewReader(""), mapping, ',')
+
+	// Assert
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/stream"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// streamResetEvent tells clients that changes were missed beyond the
+// backlog, so they should reload the subscriptions.
+const streamResetEvent = "reset"
+
+// StreamHandler streams subscription changes as Server-Sent Events.
+type StreamHandler struct {
+	hub       *stream.Hub
+	heartbeat time.Duration
+	dbTimeout time.Duration
+}
+
+// NewStreamHandler creates stream handler sending a heartbeat to idle streams.
+func NewStreamHandler(hub *stream.Hub, heartbeat, dbTimeout time.Duration) *StreamHandler {
+	return &StreamHandler{
+		hub:       hub,
+		heartbeat: heartbeat,
+		dbTimeout: dbTimeout,
+	}
+}
+
+// parseStreamFilter extracts the optional stream filters.
+func parseStreamFilter(c *gin.Context) (stream.Filter, error) {
+	var f stream.Filter
+
+	if userIDStr := strings.TrimSpace(c.Query("user_id")); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
+		if err != nil {
+			return stream.Filter{}, domain.Invalid("user_id", "must be a UUID")
+		}
+		f.UserID = &userID
+	}
+
+	if serviceName := strings.TrimSpace(c.Query("service_name")); serviceName != "" {
+		f.ServiceName = &serviceName
+	}
+
+	return f, nil
+}
+
+// parseLastEventID reads the Last-Event-ID header, nil when absent.
+func parseLastEventID(c *gin.Context) (*int64, error) {
+	v := strings.TrimSpace(c.GetHeader("Last-Event-ID"))
+	if v == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id < 0 {
+		return nil, domain.Invalid("Last-Event-ID", "must be an event ID")
+	}
+
+	return &id, nil
+}
+
+// writeStreamEvent writes one message as a Server-Sent Event.
+func writeStreamEvent(w io.Writer, m outbox.Message) error {
+	_, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", m.ID, m.Type, m.Payload)
+	return err
+}
+
+// Stream pushes subscription changes as Server-Sent Events.
+//
+// @Summary Stream subscription changes
+// @Description Pushes subscription.created, subscription.updated and subscription.deleted
+// @Description events as Server-Sent Events, with the webhook payload as data. Send the
+// @Description last event id as Last-Event-ID to resume; if more changes were missed than
+// @Description the backlog holds, a reset event asks to reload the subscriptions instead.
+// @Tags subscriptions
+// @Produce text/event-stream
+// @Param user_id query string false "User UUID"
+// @Param service_name query string false "Service name"
+// @Param Last-Event-ID header string false "ID of the last event received, to resume"
+// @Success 200 {string} string "event stream"
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Failure 503 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /subscriptions/stream [get]
+func (h *StreamHandler) Stream(c *gin.Context) {
+	ctx := c.Request.Context()
+
+	f, err := parseStreamFilter(c)
+	if err != nil {
+		slog.WarnContext(ctx, "Stream: invalid request", "error", err)
+		respondError(c, err)
+		return
+	}
+	lastID, err := parseLastEventID(c)
+	if err != nil {
+		slog.WarnContext(ctx, "Stream: invalid request", "error", err)
+		respondError(c, err)
+		return
+	}
+	f.UserID = service.ScopeUserFilter(ctx, f.UserID)
+
+	tenantID, err := tenant.FromContext(ctx)
+	if err != nil {
+		logFailure(c, "Stream failed", err)
+		respondError(c, err)
+		return
+	}
+
+	// Subscribe before reading the backlog, so no change falls in between
+	sub, err := h.hub.Subscribe(tenantID, f)
+	if errors.Is(err, stream.ErrClosed) {
+		problem.Write(c, problem.New(problem.TypeUnavailable, http.StatusServiceUnavailable, "shutting down"))
+		return
+	}
+	defer sub.Close()
+
+	var backlog []outbox.Message
+	complete := true
+	if lastID != nil {
+		dbCtx, cancel := context.WithTimeout(ctx, h.dbTimeout)
+		backlog, complete, err = h.hub.Backlog(dbCtx, *lastID, f)
+		cancel()
+		if err != nil {
+			logFailure(c, "Stream failed", err)
+			respondError(c, err)
+			return
+		}
+	}
+
+	// Streams outlive the server write timeout
+	rc := http.NewResponseController(c.Writer)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		slog.WarnContext(ctx, "Stream: clear write deadline", "error", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // no buffering by nginx
+	c.Status(http.StatusOK)
+
+	// Replay missed changes, remembering them to skip their live copies
+	seen := make(map[int64]struct{}, len(backlog))
+	if !complete {
+		_, _ = fmt.Fprintf(c.Writer, "event: %s\ndata: {}\n\n", streamResetEvent)
+	}
+	for _, m := range backlog {
+		if err := writeStreamEvent(c.Writer, m); err != nil {
+			return
+		}
+		seen[m.ID] = struct{}{}
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	// The stream ends with the client or the subscription; clients
+	// reconnect with Last-Event-ID and resume from the backlog
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if _, dup := seen[m.ID]; dup {
+				continue
+			}
+			if err := writeStreamEvent(c.Writer, m); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
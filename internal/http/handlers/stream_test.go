@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/stream"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// streamSource announces the messages sent on msgs.
+type streamSource struct {
+	msgs      chan outbox.Message
+	listening chan struct{}
+	once      sync.Once
+}
+
+func (s *streamSource) Listen(ctx context.Context, ready func(), fn func(outbox.Message)) error {
+	ready()
+	s.once.Do(func() { close(s.listening) })
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case m := <-s.msgs:
+			fn(m)
+		}
+	}
+}
+
+// streamStore returns its messages after the requested ID, ignoring filters.
+type streamStore []outbox.Message
+
+func (s streamStore) Since(_ context.Context, afterID int64, _ stream.Filter, limit int) ([]outbox.Message, error) {
+	var out []outbox.Message
+	for _, m := range s {
+		if m.ID > afterID && len(out) < limit {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// streamServer serves the stream endpoint of a hub on fakes.
+type streamServer struct {
+	t   *testing.T
+	url string
+	src *streamSource
+}
+
+// newStreamServer starts the stream endpoint for tenant acme with a
+// backlog of the given messages.
+func newStreamServer(t *testing.T, backlog int, msgs ...outbox.Message) *streamServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	src := &streamSource{msgs: make(chan outbox.Message), listening: make(chan struct{})}
+	hub := stream.NewHub(streamStore(msgs), src, stream.Options{Backlog: backlog})
+	t.Cleanup(func() { _ = hub.Shutdown(context.Background()) })
+	<-src.listening
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		ctx := tenant.WithID(c.Request.Context(), "acme")
+		if v := c.GetHeader(testUserHeader); v != "" {
+			ctx = auth.WithPrincipal(ctx, auth.Principal{Subject: v, UserID: uuid.MustParse(v)})
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	})
+	r.GET("/api/subscriptions/stream", NewStreamHandler(hub, time.Hour, time.Second).Stream)
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+
+	return &streamServer{t: t, url: srv.URL + "/api/subscriptions/stream", src: src}
+}
+
+// open connects to the stream with headers as alternating names and values.
+func (s *streamServer) open(query string, headers ...string) (*http.Response, *bufio.Reader) {
+	s.t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	s.t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url+query, nil)
+	if err != nil {
+		s.t.Fatalf("new request: %v", err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		s.t.Fatalf("open stream: %v", err)
+	}
+	s.t.Cleanup(func() { _ = resp.Body.Close() })
+
+	return resp, bufio.NewReader(resp.Body)
+}
+
+// sseEvent is one received Server-Sent Event.
+type sseEvent struct {
+	id, event, data string
+}
+
+// readEvent returns the next event of the stream, skipping comments.
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read stream: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && ev != (sseEvent{}):
+			return ev
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+// change returns a message for a changed subscription of user in tenant acme.
+func change(t *testing.T, id int64, user uuid.UUID) outbox.Message {
+	t.Helper()
+
+	ev := domain.NewEvent(domain.EventSubscriptionUpdated, domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserID:      user,
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	payload, err := outbox.Encode(ev)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	return outbox.Message{
+		ID:             id,
+		TenantID:       "acme",
+		EventID:        ev.ID,
+		Type:           ev.Type,
+		SubscriptionID: ev.Subscription.ID,
+		Payload:        payload,
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// Stream
+// ==============================================================
+// ==============================================================
+func TestStream_PushesLiveChanges(t *testing.T) {
+	// Arrange
+	s := newStreamServer(t, 10)
+	resp, r := s.open("")
+	m := change(t, 7, uuid.New())
+
+	// Act
+	s.src.msgs <- m
+	ev := readEvent(t, r)
+
+	// Assert
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("unexpected response %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if ev.id != "7" || ev.event != domain.EventSubscriptionUpdated || ev.data != string(m.Payload) {
+		t.Errorf("unexpected event %+v", ev)
+	}
+}
+
+func TestStream_ResumesFromLastEventID(t *testing.T) {
+	// Arrange
+	user := uuid.New()
+	backlog := []outbox.Message{change(t, 1, user), change(t, 2, user), change(t, 3, user)}
+	s := newStreamServer(t, 10, backlog...)
+
+	// Act
+	_, r := s.open("", "Last-Event-ID", "1")
+	var ids []string
+	for range 2 {
+		ids = append(ids, readEvent(t, r).id)
+	}
+
+	// A change both replayed and announced is sent once
+	s.src.msgs <- backlog[2]
+	s.src.msgs <- change(t, 4, user)
+	ids = append(ids, readEvent(t, r).id)
+
+	// Assert
+	if strings.Join(ids, ",") != "2,3,4" {
+		t.Errorf("expected events 2,3,4, got %v", ids)
+	}
+}
+
+func TestStream_ResetWhenBacklogExceeded(t *testing.T) {
+	// Arrange
+	user := uuid.New()
+	s := newStreamServer(t, 1, change(t, 1, user), change(t, 2, user), change(t, 3, user))
+
+	// Act
+	_, r := s.open("", "Last-Event-ID", "0")
+	ev := readEvent(t, r)
+
+	// Assert
+	if ev.event != streamResetEvent {
+		t.Errorf("expected a reset event, got %+v", ev)
+	}
+}
+
+func TestStream_ScopedCallersOnlySeeTheirChanges(t *testing.T) {
+	// Arrange
+	me := uuid.New()
+	s := newStreamServer(t, 10)
+	_, r := s.open("?user_id="+uuid.NewString(), testUserHeader, me.String())
+
+	// Act
+	s.src.msgs <- change(t, 1, uuid.New())
+	s.src.msgs <- change(t, 2, me)
+	ev := readEvent(t, r)
+
+	// Assert
+	if ev.id != "2" {
+		t.Errorf("expected only the caller's change 2, got %+v", ev)
+	}
+}
+
+func TestStream_InvalidRequest(t *testing.T) {
+	tests := []struct {
+		name, query, lastEventID string
+	}{
+		{"user_id", "?user_id=nope", ""},
+		{"Last-Event-ID", "", "abc"},
+		{"negative Last-Event-ID", "", "-1"},
+	}
+
+	s := newStreamServer(t, 10)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, _ := s.open(tt.query, "Last-Event-ID", tt.lastEventID)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("expected status 400, got %d", resp.StatusCode)
+			}
+		})
+	}
+}
@@ -2,14 +2,14 @@ package handlers
 
 import (
 	"context"
-	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
-	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/service"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -17,27 +17,31 @@ import (
 
 // SubscriptionsHandler handles subscription HTTP requests.
 type SubscriptionsHandler struct {
-	repo      *postgres.SubscriptionRepo
+	svc       *service.SubscriptionService
 	dbTimeout time.Duration
+	exports   chan struct{} // slots of concurrent exports
 }
 
 // NewSubscriptionsHandler creates a new subscriptions handler.
 func NewSubscriptionsHandler(
-	repo *postgres.SubscriptionRepo,
+	svc *service.SubscriptionService,
 	timeout time.Duration,
 ) *SubscriptionsHandler {
 	return &SubscriptionsHandler{
-		repo:      repo,
+		svc:       svc,
 		dbTimeout: timeout,
+		exports:   make(chan struct{}, maxConcurrentExports),
 	}
 }
 
 // SubscriptionRequest defines create payload.
+// Fields are checked by service.ParseSubscription, which reports all
+// invalid ones at once; the validate tags only document them.
 type SubscriptionRequest struct {
-	ServiceName string `json:"service_name" binding:"required"`
-	Price       int    `json:"price" binding:"required"`
-	UserID      string `json:"user_id" binding:"required"`
-	StartDate   string `json:"start_date" binding:"required"` // MM-YYYY
+	ServiceName string `json:"service_name" validate:"required"`
+	Price       *int   `json:"price" validate:"required"`
+	UserID      string `json:"user_id"`
+	StartDate   string `json:"start_date" validate:"required"` // MM-YYYY
 	EndDate     string `json:"end_date"`
 }
 
@@ -75,67 +79,58 @@ func toResponse(s domain.Subscription) SubscriptionResponse {
 
 }
 
-// parseSubscriptionRequest validates input and builds domain entity.
-func parseSubscriptionRequest(
-	req SubscriptionRequest,
-	id uuid.UUID,
-) (domain.Subscription, error) {
-
-	serviceName := strings.TrimSpace(req.ServiceName)
-	if serviceName == "" {
-		return domain.Subscription{}, errors.New("service_name is required")
+// input converts the request to the service input.
+func (r SubscriptionRequest) input() service.SubscriptionInput {
+	return service.SubscriptionInput{
+		ServiceName: r.ServiceName,
+		Price:       r.Price,
+		UserID:      r.UserID,
+		StartDate:   r.StartDate,
+		EndDate:     r.EndDate,
 	}
+}
 
-	if req.Price < 0 {
-		return domain.Subscription{}, errors.New("price must be >= 0")
+// parseAsOf reads the optional as_of point in time.
+func parseAsOf(c *gin.Context) (*time.Time, error) {
+	v := strings.TrimSpace(c.Query("as_of"))
+	if v == "" {
+		return nil, nil
 	}
 
-	userID, err := uuid.Parse(strings.TrimSpace(req.UserID))
+	t, err := time.Parse(time.RFC3339, v)
 	if err != nil {
-		return domain.Subscription{}, errors.New("invalid user_id")
+		return nil, domain.Invalid("as_of", "must be an RFC3339 time")
 	}
 
-	start, err := utils.ParseMonthYear(strings.TrimSpace(req.StartDate))
-	if err != nil {
-		return domain.Subscription{}, err
-	}
+	return &t, nil
+}
 
-	// Parse optional end date
-	var endPtr *time.Time
-	if end := strings.TrimSpace(req.EndDate); end != "" {
-		parsedEnd, err := utils.ParseMonthYear(end)
+// parseListFilter reads optional list filters from query parameters.
+func parseListFilter(c *gin.Context) (domain.ListFilter, error) {
+	// Init list filter
+	var f domain.ListFilter
+
+	if userIDStr := strings.TrimSpace(c.Query("user_id")); userIDStr != "" {
+		userID, err := uuid.Parse(userIDStr)
 		if err != nil {
-			return domain.Subscription{}, err
-		}
-		if parsedEnd.Before(start) {
-			return domain.Subscription{}, errors.New("end_date before start_date")
+			return domain.ListFilter{}, domain.Invalid("user_id", "must be a UUID")
 		}
-		endPtr = &parsedEnd
+		// Set user ID filter
+		f.UserID = &userID
 	}
 
-	// Build domain model
-	return domain.Subscription{
-		ID:          id,
-		ServiceName: serviceName,
-		Price:       req.Price,
-		UserID:      userID,
-		StartDate:   start,
-		EndDate:     endPtr,
-	}, nil
-}
+	if serviceName := strings.TrimSpace(c.Query("service_name")); serviceName != "" {
+		// Set service name filter
+		f.ServiceName = &serviceName
+	}
 
-// mapErrorToHTTP converts internal error to HTTP status + message.
-func mapErrorToHTTP(err error) (int, string) {
-	switch {
-	case err == nil:
-		return http.StatusOK, ""
-	case errors.Is(err, postgres.ErrNotFound):
-		return http.StatusNotFound, "not found"
-	case errors.Is(err, context.DeadlineExceeded):
-		return http.StatusGatewayTimeout, "timeout"
-	default:
-		return http.StatusInternalServerError, "db error"
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		return domain.ListFilter{}, err
 	}
+	f.AsOf = asOf
+
+	return f, nil
 }
 
 // Create handles subscription creation request.
@@ -147,27 +142,19 @@ func mapErrorToHTTP(err error) (int, string) {
 // @Produce json
 // @Param subscription body SubscriptionRequest true "Subscription data"
 // @Success 201 {object} SubscriptionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions [post]
 func (h *SubscriptionsHandler) Create(c *gin.Context) {
 	req := SubscriptionRequest{}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("Create: invalid json: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid json",
-		})
-		return
-	}
-
-	// Parse request
-	sub, err := parseSubscriptionRequest(req, uuid.New())
-	if err != nil {
-		log.Printf("Create: validation error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error(),
-		})
+		slog.WarnContext(c.Request.Context(), "Create: invalid json", "error", err)
+		respondError(c, domain.Invalid("body", "invalid json"))
 		return
 	}
 
@@ -175,17 +162,19 @@ func (h *SubscriptionsHandler) Create(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
 	defer cancel()
 
-	out, err := h.repo.Create(ctx, sub)
+	out, err := h.svc.Create(ctx, req.input())
 	if err != nil {
-		log.Printf("Create: db error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "db error",
-		})
+		logFailure(c, "Create failed", err)
+
+		respondError(c, err)
 		return
 	}
 
-	log.Printf("Subscription created: id=%s user_id=%s service=%s",
-		out.ID, out.UserID, out.ServiceName)
+	logging.Add(c.Request.Context(),
+		slog.String("subscription_id", out.ID.String()),
+		slog.String("user_id", out.UserID.String()),
+	)
+	slog.InfoContext(c.Request.Context(), "Subscription created", "service", out.ServiceName)
 
 	resp := toResponse(out)
 
@@ -199,29 +188,38 @@ func (h *SubscriptionsHandler) Create(c *gin.Context) {
 // @Tags subscriptions
 // @Produce json
 // @Param id path string true "Subscription ID"
+// @Param as_of query string false "RFC3339 time, answer from the data as it was at that moment"
 // @Success 200 {object} SubscriptionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [get]
 func (h *SubscriptionsHandler) Get(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		log.Printf("Get: invalid id: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid id",
-		})
+		slog.WarnContext(c.Request.Context(), "Get: invalid id", "error", err)
+		respondError(c, domain.Invalid("id", "must be a UUID"))
+		return
+	}
+	logging.Add(c.Request.Context(), slog.String("subscription_id", id.String()))
+
+	asOf, err := parseAsOf(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Get: invalid request", "error", err)
+		respondError(c, err)
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout) // limit db time
 	defer cancel()
 
-	s, err := h.repo.GetByID(ctx, id)
+	s, err := h.svc.Get(ctx, id, asOf)
 	if err != nil {
-		log.Printf("Get error: %v", err)
+		logFailure(c, "Get failed", err)
 
-		code, msg := mapErrorToHTTP(err)
-		c.JSON(code, gin.H{"error": msg})
+		respondError(c, err)
 		return
 	}
 
@@ -237,47 +235,42 @@ func (h *SubscriptionsHandler) Get(c *gin.Context) {
 // @Param id path string true "Subscription ID"
 // @Param subscription body SubscriptionRequest true "Updated subscription data"
 // @Success 200 {object} SubscriptionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Failure 409 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [put]
 func (h *SubscriptionsHandler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		log.Printf("Update: invalid id: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "invalid id",
-		})
+		slog.WarnContext(c.Request.Context(), "Update: invalid id", "error", err)
+		respondError(c, domain.Invalid("id", "must be a UUID"))
 		return
 	}
+	logging.Add(c.Request.Context(), slog.String("subscription_id", id.String()))
 
 	var req SubscriptionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Printf("Update: invalid json: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
-		return
-	}
-
-	// Parse request
-	sub, err := parseSubscriptionRequest(req, id)
-	if err != nil {
-		log.Printf("Update: validation error: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		slog.WarnContext(c.Request.Context(), "Update: invalid json", "error", err)
+		respondError(c, domain.Invalid("body", "invalid json"))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
 	defer cancel()
 
-	out, err := h.repo.Update(ctx, sub)
+	out, err := h.svc.Update(ctx, id, req.input())
 	if err != nil {
-		log.Printf("Update error: %v", err)
+		logFailure(c, "Update failed", err)
 
-		code, msg := mapErrorToHTTP(err)
-		c.JSON(code, gin.H{"error": msg})
+		respondError(c, err)
 		return
 	}
 
-	log.Printf("Subscription updated: id=%s", out.ID)
+	slog.InfoContext(c.Request.Context(), "Subscription updated")
 
 	c.JSON(http.StatusOK, toResponse(out))
 }
@@ -288,29 +281,32 @@ func (h *SubscriptionsHandler) Update(c *gin.Context) {
 // @Tags subscriptions
 // @Param id path string true "Subscription ID"
 // @Success 204
-// @Failure 400 {object} map[string]string
-// @Failure 404 {object} map[string]string
+// @Failure 400 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions/{id} [delete]
 func (h *SubscriptionsHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		log.Printf("Delete: invalid id: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		slog.WarnContext(c.Request.Context(), "Delete: invalid id", "error", err)
+		respondError(c, domain.Invalid("id", "must be a UUID"))
 		return
 	}
+	logging.Add(c.Request.Context(), slog.String("subscription_id", id.String()))
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
 	defer cancel()
 
-	if err := h.repo.Delete(ctx, id); err != nil {
-		log.Printf("Delete error: %v", err)
+	if err := h.svc.Delete(ctx, id); err != nil {
+		logFailure(c, "Delete failed", err)
 
-		code, msg := mapErrorToHTTP(err)
-		c.JSON(code, gin.H{"error": msg})
+		respondError(c, err)
 		return
 	}
 
-	log.Printf("Subscription deleted: id=%s", id)
+	slog.InfoContext(c.Request.Context(), "Subscription deleted")
 
 	c.Status(http.StatusNoContent)
 }
@@ -322,37 +318,29 @@ func (h *SubscriptionsHandler) Delete(c *gin.Context) {
 // @Produce json
 // @Param user_id query string false "User UUID"
 // @Param service_name query string false "Service name"
+// @Param as_of query string false "RFC3339 time, answer from the data as it was at that moment"
 // @Success 200 {array} SubscriptionResponse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
 // @Router /subscriptions [get]
 func (h *SubscriptionsHandler) List(c *gin.Context) {
-	// Init list filter
-	var f postgres.ListFilter
-
-	if userIDStr := strings.TrimSpace(c.Query("user_id")); userIDStr != "" {
-		userID, err := uuid.Parse(userIDStr)
-		if err != nil {
-			log.Printf("List: invalid user_id: %v", err)
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
-			return
-		}
-		// Set user ID filter
-		f.UserID = &userID
-	}
-
-	if serviceName := strings.TrimSpace(c.Query("service_name")); serviceName != "" {
-		// Set service name filter
-		f.ServiceName = &serviceName
+	f, err := parseListFilter(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "List: invalid request", "error", err)
+		respondError(c, err)
+		return
 	}
 
 	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
 	defer cancel()
 
-	items, err := h.repo.List(ctx, f)
+	items, err := h.svc.List(ctx, f)
 	if err != nil {
-		log.Printf("List: db error: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "db error"})
+		logFailure(c, "List failed", err)
+		respondError(c, err)
 		return
 	}
 
@@ -0,0 +1,489 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/webhook"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Delivery log page sizes.
+const (
+	defaultDeliveryLimit = 100
+	maxDeliveryLimit     = 1000
+)
+
+// minSecretLength is the shortest signing secret accepted from clients.
+const minSecretLength = 16
+
+// WebhooksHandler handles webhook registration and delivery log endpoints.
+type WebhooksHandler struct {
+	repo         *postgres.WebhookRepo
+	dbTimeout    time.Duration
+	allowPrivate bool
+}
+
+// NewWebhooksHandler creates webhooks handler. Endpoints must resolve to
+// public addresses unless allowPrivate is set.
+func NewWebhooksHandler(repo *postgres.WebhookRepo, dbTimeout time.Duration, allowPrivate bool) *WebhooksHandler {
+	return &WebhooksHandler{
+		repo:         repo,
+		dbTimeout:    dbTimeout,
+		allowPrivate: allowPrivate,
+	}
+}
+
+// checkEndpoint rejects endpoints resolving to internal addresses.
+func (h *WebhooksHandler) checkEndpoint(ctx context.Context, rawURL string) error {
+	if h.allowPrivate {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.dbTimeout)
+	defer cancel()
+
+	err := webhook.CheckURL(ctx, rawURL)
+	switch {
+	case errors.Is(err, webhook.ErrDisallowedAddress):
+		return domain.Invalid("url", "must resolve to a public address")
+	case err != nil:
+		return errors.Join(domain.Invalid("url", "host cannot be resolved"), err)
+	}
+
+	return nil
+}
+
+// WebhookRequest defines webhook registration payload.
+type WebhookRequest struct {
+	URL string `json:"url" binding:"required" example:"https://example.com/hooks/subscriptions"`
+	// Secret signs the payloads; generated when empty
+	Secret string `json:"secret"`
+	// Events to send, all when empty
+	Events []string `json:"events" example:"subscription.created,subscription.deleted"`
+}
+
+// WebhookResponse defines API response for a webhook.
+type WebhookResponse struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret is only returned on registration
+	Secret    string `json:"secret,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+// WebhookDeliveryResponse defines API response for one delivery.
+type WebhookDeliveryResponse struct {
+	ID             int64           `json:"id"`
+	WebhookID      string          `json:"webhook_id"`
+	EventID        string          `json:"event_id"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status" example:"pending"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *string         `json:"next_attempt_at"` // set while pending
+	ResponseStatus *int            `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+	DeliveredAt    *string         `json:"delivered_at"`
+	CreatedAt      string          `json:"created_at"`
+	Payload        json.RawMessage `json:"payload" swaggertype:"object"`
+}
+
+// toWebhookResponse maps domain webhook to API response without secret.
+func toWebhookResponse(w domain.Webhook) WebhookResponse {
+	events := w.Events
+	if events == nil {
+		events = []string{}
+	}
+
+	return WebhookResponse{
+		ID:        w.ID.String(),
+		URL:       w.URL,
+		Events:    events,
+		CreatedAt: w.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// toDeliveryResponse maps domain delivery to API response.
+func toDeliveryResponse(d domain.WebhookDelivery) WebhookDeliveryResponse {
+	resp := WebhookDeliveryResponse{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID.String(),
+		EventID:        d.EventID.String(),
+		Event:          d.Event,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt.Format(time.RFC3339),
+		Payload:        d.Payload,
+	}
+	if d.Status == domain.DeliveryPending {
+		next := d.NextAttemptAt.Format(time.RFC3339)
+		resp.NextAttemptAt = &next
+	}
+	if d.DeliveredAt != nil {
+		at := d.DeliveredAt.Format(time.RFC3339)
+		resp.DeliveredAt = &at
+	}
+
+	return resp
+}
+
+// webhook validates the request and builds the webhook, generating a
+// secret when none is given.
+// All invalid fields are reported in one *domain.ValidationError.
+func (r WebhookRequest) webhook() (domain.Webhook, error) {
+	var verr domain.ValidationError
+
+	rawURL := strings.TrimSpace(r.URL)
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		verr.Add("url", "must be an absolute http or https URL")
+	}
+
+	secret := r.Secret
+	if secret != "" && len(secret) < minSecretLength {
+		verr.Add("secret", "must be at least 16 characters")
+	}
+
+	events := make([]string, 0, len(r.Events))
+	for _, e := range r.Events {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if !slices.Contains(domain.EventTypes, e) {
+			verr.Add("events", "unknown event "+strconv.Quote(e))
+			continue
+		}
+		if !slices.Contains(events, e) {
+			events = append(events, e)
+		}
+	}
+
+	if err := verr.Err(); err != nil {
+		return domain.Webhook{}, err
+	}
+
+	if secret == "" {
+		if secret, err = webhook.NewSecret(); err != nil {
+			return domain.Webhook{}, err
+		}
+	}
+
+	return domain.Webhook{
+		ID:     uuid.New(),
+		URL:    rawURL,
+		Secret: secret,
+		Events: events,
+	}, nil
+}
+
+// parseDeliveryFilter reads delivery log filters from query parameters.
+func parseDeliveryFilter(c *gin.Context, webhookID uuid.UUID) (postgres.DeliveryFilter, error) {
+	f := postgres.DeliveryFilter{WebhookID: webhookID, Limit: defaultDeliveryLimit}
+
+	var verr domain.ValidationError
+
+	if status := strings.ToLower(strings.TrimSpace(c.Query("status"))); status != "" {
+		switch status {
+		case domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryDead:
+			f.Status = &status
+		default:
+			verr.Add("status", "must be pending, delivered or dead")
+		}
+	}
+
+	if v := strings.TrimSpace(c.Query("limit")); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxDeliveryLimit {
+			verr.Add("limit", "must be between 1 and 1000")
+		} else {
+			f.Limit = limit
+		}
+	}
+
+	if err := verr.Err(); err != nil {
+		return postgres.DeliveryFilter{}, err
+	}
+
+	return f, nil
+}
+
+// parseWebhookID reads the webhook ID path parameter.
+func parseWebhookID(c *gin.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		return uuid.Nil, domain.Invalid("id", "must be a UUID")
+	}
+	logging.Add(c.Request.Context(), slog.String("webhook_id", id.String()))
+
+	return id, nil
+}
+
+// Create registers a webhook.
+//
+// @Summary Register webhook
+// @Description Registers an endpoint for subscription events. Payloads are signed with HMAC-SHA256 of "<timestamp>.<body>" in the X-Webhook-Signature header; the secret is only returned here
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param webhook body WebhookRequest true "Webhook data"
+// @Success 201 {object} WebhookResponse
+// @Failure 400 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks [post]
+func (h *WebhooksHandler) Create(c *gin.Context) {
+	req := WebhookRequest{}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		slog.WarnContext(c.Request.Context(), "Create webhook: invalid json", "error", err)
+		respondError(c, domain.Invalid("body", "invalid json"))
+		return
+	}
+
+	w, err := req.webhook()
+	if err == nil {
+		err = h.checkEndpoint(c.Request.Context(), w.URL)
+	}
+	if err != nil {
+		logFailure(c, "Create webhook: invalid request", err)
+		respondError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	out, err := h.repo.Create(ctx, w)
+	if err != nil {
+		logFailure(c, "Create webhook failed", err)
+
+		respondError(c, err)
+		return
+	}
+
+	logging.Add(c.Request.Context(), slog.String("webhook_id", out.ID.String()))
+	slog.InfoContext(c.Request.Context(), "Webhook created", "url", out.URL)
+
+	resp := toWebhookResponse(out)
+	resp.Secret = out.Secret
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// List returns the webhooks of the tenant.
+//
+// @Summary List webhooks
+// @Tags webhooks
+// @Produce json
+// @Success 200 {array} WebhookResponse
+// @Failure 403 {object} problem.Details
+// @Failure 500 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks [get]
+func (h *WebhooksHandler) List(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	hooks, err := h.repo.List(ctx)
+	if err != nil {
+		logFailure(c, "List webhooks failed", err)
+
+		respondError(c, err)
+		return
+	}
+
+	resp := make([]WebhookResponse, 0, len(hooks))
+	for _, w := range hooks {
+		resp = append(resp, toWebhookResponse(w))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Get returns a webhook by ID.
+//
+// @Summary Get webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} WebhookResponse
+// @Failure 400 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [get]
+func (h *WebhooksHandler) Get(c *gin.Context) {
+	id, err := parseWebhookID(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Get webhook: invalid id", "error", err)
+		respondError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	w, err := h.repo.Get(ctx, id)
+	if err != nil {
+		logFailure(c, "Get webhook failed", err)
+
+		respondError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookResponse(w))
+}
+
+// Delete removes a webhook and its delivery log.
+//
+// @Summary Delete webhook
+// @Tags webhooks
+// @Param id path string true "Webhook ID"
+// @Success 204
+// @Failure 400 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks/{id} [delete]
+func (h *WebhooksHandler) Delete(c *gin.Context) {
+	id, err := parseWebhookID(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Delete webhook: invalid id", "error", err)
+		respondError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	if err := h.repo.Delete(ctx, id); err != nil {
+		logFailure(c, "Delete webhook failed", err)
+
+		respondError(c, err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Webhook deleted")
+
+	c.Status(http.StatusNoContent)
+}
+
+// Deliveries returns the delivery log of a webhook, newest first.
+//
+// @Summary Webhook delivery log
+// @Description Lists deliveries with their status, attempts and last response. Dead deliveries gave up after the last retry
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param status query string false "Status: pending, delivered or dead"
+// @Param limit query int false "Maximum number of deliveries (1-1000, default 100)"
+// @Success 200 {array} WebhookDeliveryResponse
+// @Failure 400 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhooksHandler) Deliveries(c *gin.Context) {
+	id, err := parseWebhookID(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Deliveries: invalid id", "error", err)
+		respondError(c, err)
+		return
+	}
+
+	f, err := parseDeliveryFilter(c, id)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Deliveries: invalid request", "error", err)
+		respondError(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	deliveries, err := h.repo.Deliveries(ctx, f)
+	if err != nil {
+		logFailure(c, "Deliveries failed", err)
+
+		respondError(c, err)
+		return
+	}
+
+	resp := make([]WebhookDeliveryResponse, 0, len(deliveries))
+	for _, d := range deliveries {
+		resp = append(resp, toDeliveryResponse(d))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Redeliver schedules a delivery to be sent again.
+//
+// @Summary Redeliver webhook delivery
+// @Description Sends a delivery again with a fresh set of retries, e.g. a dead one after the endpoint was fixed
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path int true "Delivery ID"
+// @Success 202 {object} WebhookDeliveryResponse
+// @Failure 400 {object} problem.Details
+// @Failure 403 {object} problem.Details
+// @Failure 404 {object} problem.Details
+// @Param X-Tenant-ID header string false "Tenant ID, defaults to the configured tenant"
+// @Security BearerAuth
+// @Security ApiKeyAuth
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhooksHandler) Redeliver(c *gin.Context) {
+	id, err := parseWebhookID(c)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Redeliver: invalid id", "error", err)
+		respondError(c, err)
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		slog.WarnContext(c.Request.Context(), "Redeliver: invalid delivery id", "error", err)
+		respondError(c, domain.Invalid("delivery_id", "must be an integer"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.dbTimeout)
+	defer cancel()
+
+	d, err := h.repo.Redeliver(ctx, id, deliveryID)
+	if err != nil {
+		logFailure(c, "Redeliver failed", err)
+
+		respondError(c, err)
+		return
+	}
+
+	slog.InfoContext(c.Request.Context(), "Webhook delivery rescheduled", "delivery_id", d.ID)
+
+	c.JSON(http.StatusAccepted, toDeliveryResponse(d))
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ==============================================================
+// ==============================================================
+// WebhookRequest
+// ==============================================================
+// ==============================================================
+func TestWebhookRequest_Valid(t *testing.T) {
+	// Arrange
+	req := WebhookRequest{
+		URL:    " https://example.com/hooks ",
+		Secret: "0123456789abcdef",
+		Events: []string{"Subscription.Created", "subscription.created", "subscription.ended"},
+	}
+
+	// Act
+	w, err := req.webhook()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.ID == uuid.Nil || w.URL != "https://example.com/hooks" || w.Secret != req.Secret {
+		t.Errorf("unexpected webhook: %+v", w)
+	}
+	want := []string{domain.EventSubscriptionCreated, domain.EventSubscriptionEnded}
+	if !slices.Equal(w.Events, want) {
+		t.Errorf("expected events %v, got %v", want, w.Events)
+	}
+}
+
+func TestWebhookRequest_GeneratesSecret(t *testing.T) {
+	// Act
+	w, err := WebhookRequest{URL: "http://localhost:9000/hook"}.webhook()
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(w.Secret, "whsec_") || len(w.Secret) < minSecretLength {
+		t.Errorf("expected generated secret, got %q", w.Secret)
+	}
+	if len(w.Events) != 0 {
+		t.Errorf("expected all events, got %v", w.Events)
+	}
+}
+
+func TestWebhookRequest_Invalid(t *testing.T) {
+	// Arrange
+	req := WebhookRequest{
+		URL:    "ftp://example.com",
+		Secret: "short",
+		Events: []string{"subscription.renamed"},
+	}
+
+	// Act
+	_, err := req.webhook()
+
+	// Assert
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if len(verr.Fields) != 3 {
+		t.Errorf("expected url, secret and events errors, got %+v", verr.Fields)
+	}
+}
+
+func TestWebhooksHandler_CheckEndpoint(t *testing.T) {
+	// Arrange
+	strict := NewWebhooksHandler(nil, time.Second, false)
+	local := NewWebhooksHandler(nil, time.Second, true)
+
+	// Act
+	strictErr := strict.checkEndpoint(context.Background(), "http://169.254.169.254/latest/meta-data")
+	localErr := local.checkEndpoint(context.Background(), "http://127.0.0.1:9000/hook")
+
+	// Assert
+	var verr *domain.ValidationError
+	if !errors.As(strictErr, &verr) || verr.Fields[0].Field != "url" {
+		t.Errorf("expected a url validation error, got %v", strictErr)
+	}
+	if localErr != nil {
+		t.Errorf("expected private endpoints to be allowed, got %v", localErr)
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// parseDeliveryFilter
+// ==============================================================
+// ==============================================================
+func TestParseDeliveryFilter(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	id := uuid.New()
+
+	cases := []struct {
+		query  string
+		status string
+		limit  int
+		valid  bool
+	}{
+		{"", "", defaultDeliveryLimit, true},
+		{"?status=DEAD&limit=5", domain.DeliveryDead, 5, true},
+		{"?status=failed", "", 0, false},
+		{"?limit=0", "", 0, false},
+	}
+
+	for _, tc := range cases {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/api/webhooks/"+id.String()+"/deliveries"+tc.query, nil)
+
+		// Act
+		f, err := parseDeliveryFilter(c, id)
+
+		// Assert
+		if !tc.valid {
+			if !errors.Is(err, domain.ErrInvalidInput) {
+				t.Errorf("%q: expected invalid input, got %v", tc.query, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.query, err)
+			continue
+		}
+		if f.WebhookID != id || f.Limit != tc.limit {
+			t.Errorf("%q: unexpected filter %+v", tc.query, f)
+		}
+		if (f.Status == nil) != (tc.status == "") || (f.Status != nil && *f.Status != tc.status) {
+			t.Errorf("%q: unexpected status %v", tc.query, f.Status)
+		}
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// toDeliveryResponse
+// ==============================================================
+// ==============================================================
+func TestToDeliveryResponse(t *testing.T) {
+	// Arrange
+	at := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+	pending := domain.WebhookDelivery{
+		ID:            7,
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: at,
+		Payload:       []byte(`{"type":"subscription.created"}`),
+	}
+	delivered := domain.WebhookDelivery{
+		ID:            8,
+		Status:        domain.DeliveryDelivered,
+		NextAttemptAt: at,
+		DeliveredAt:   &at,
+	}
+
+	// Act
+	p := toDeliveryResponse(pending)
+	d := toDeliveryResponse(delivered)
+
+	// Assert
+	if p.NextAttemptAt == nil || *p.NextAttemptAt != "2025-05-01T12:00:00Z" || p.DeliveredAt != nil {
+		t.Errorf("unexpected pending delivery: %+v", p)
+	}
+	if d.NextAttemptAt != nil || d.DeliveredAt == nil || *d.DeliveredAt != "2025-05-01T12:00:00Z" {
+		t.Errorf("unexpected delivered delivery: %+v", d)
+	}
+}
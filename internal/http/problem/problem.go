@@ -0,0 +1,71 @@
+// Package problem writes error responses as RFC 7807 problem details.
+package problem
+
+import (
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the media type of problem responses.
+const ContentType = "application/problem+json"
+
+// Problem types of the API. They are relative URI references,
+// documented in the README.
+const (
+	TypeValidation   = "/problems/validation-error"
+	TypeUnauthorized = "/problems/unauthorized"
+	TypeForbidden    = "/problems/forbidden"
+	TypeNotFound     = "/problems/not-found"
+	TypeConflict     = "/problems/conflict"
+	TypeRateLimited  = "/problems/rate-limited"
+	TypeTimeout      = "/problems/timeout"
+	TypeUnavailable  = "/problems/unavailable"
+	TypeInternal     = "/problems/internal-error"
+)
+
+// titles are the short, fixed summaries of the problem types.
+var titles = map[string]string{
+	TypeValidation:   "Invalid request",
+	TypeUnauthorized: "Unauthorized",
+	TypeForbidden:    "Forbidden",
+	TypeNotFound:     "Not found",
+	TypeConflict:     "Conflict",
+	TypeRateLimited:  "Too many requests",
+	TypeTimeout:      "Timeout",
+	TypeUnavailable:  "Service unavailable",
+	TypeInternal:     "Internal error",
+}
+
+// Details is a problem details object.
+type Details struct {
+	Type      string              `json:"type" example:"/problems/validation-error"`
+	Title     string              `json:"title" example:"Invalid request"`
+	Status    int                 `json:"status" example:"400"`
+	Detail    string              `json:"detail,omitempty" example:"price: must be >= 0"`
+	Instance  string              `json:"instance,omitempty" example:"/api/subscriptions"`
+	RequestID string              `json:"request_id,omitempty" example:"5f0c6a9e-2d1b-4c3a-9f7e-1a2b3c4d5e6f"`
+	Errors    []domain.FieldError `json:"errors,omitempty"`
+}
+
+// New returns a problem of the given type with its standard title.
+func New(typ string, status int, detail string) Details {
+	return Details{
+		Type:   typ,
+		Title:  titles[typ],
+		Status: status,
+		Detail: detail,
+	}
+}
+
+// Write aborts the request with the problem, adding the request path
+// as instance and the request ID.
+func Write(c *gin.Context, d Details) {
+	if d.Instance == "" {
+		d.Instance = c.Request.URL.Path
+	}
+	d.RequestID = logging.RequestID(c.Request.Context())
+
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(d.Status, d)
+}
@@ -0,0 +1,49 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/audit"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// actorHeader names the actor of a change when authentication is disabled.
+const actorHeader = "X-Actor"
+
+// maxAuditHeaderLen limits client supplied actor and request ID values.
+const maxAuditHeaderLen = 128
+
+// auditActor picks the actor recorded for changes. Authenticated callers
+// are identified by their credentials; the header is only trusted when
+// authentication is disabled.
+func auditActor(c *gin.Context) string {
+	if p, ok := auth.PrincipalFrom(c.Request.Context()); ok {
+		return p.Subject
+	}
+	if actor := clip(c.GetHeader(actorHeader)); actor != "" {
+		return actor
+	}
+	return "anonymous"
+}
+
+// auditMeta stores actor and request ID for the audit log.
+// The request ID is the one assigned by requestID.
+func auditMeta() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		m := audit.Meta{Actor: auditActor(c), RequestID: logging.RequestID(ctx)}
+		c.Request = c.Request.WithContext(audit.WithMeta(ctx, m))
+		c.Next()
+	}
+}
+
+// clip trims a header value and caps its length.
+func clip(v string) string {
+	v = strings.TrimSpace(v)
+	if len(v) > maxAuditHeaderLen {
+		v = v[:maxAuditHeaderLen]
+	}
+	return v
+}
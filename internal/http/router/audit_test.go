@@ -0,0 +1,73 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/audit"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// serveAuditMeta runs requestID and auditMeta and returns the stored metadata and response.
+func serveAuditMeta(p *auth.Principal, headers map[string]string) (audit.Meta, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+
+	var got audit.Meta
+	r.Use(requestID(), func(c *gin.Context) {
+		if p != nil {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), *p))
+		}
+	})
+	r.GET("/", auditMeta(), func(c *gin.Context) {
+		got = audit.MetaFrom(c.Request.Context())
+		c.Status(http.StatusOK)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return got, w
+}
+
+// ====================================
+// auditMeta
+// ====================================
+
+func TestAuditMeta_PrincipalWinsOverHeader(t *testing.T) {
+	// Act
+	m, w := serveAuditMeta(&auth.Principal{Subject: "user-1"}, map[string]string{
+		actorHeader:     "mallory",
+		requestIDHeader: "req-42",
+	})
+
+	// Assert
+	if m.Actor != "user-1" || m.RequestID != "req-42" {
+		t.Errorf("unexpected meta: %+v", m)
+	}
+	if got := w.Header().Get(requestIDHeader); got != "req-42" {
+		t.Errorf("expected echoed request id, got %q", got)
+	}
+}
+
+func TestAuditMeta_AnonymousUsesHeaderAndGeneratesRequestID(t *testing.T) {
+	// Act
+	m, w := serveAuditMeta(nil, map[string]string{actorHeader: "ops-script"})
+	anon, _ := serveAuditMeta(nil, nil)
+
+	// Assert
+	if m.Actor != "ops-script" {
+		t.Errorf("expected header actor, got %q", m.Actor)
+	}
+	if m.RequestID == "" || w.Header().Get(requestIDHeader) != m.RequestID {
+		t.Errorf("expected generated request id in response, got %q", m.RequestID)
+	}
+	if anon.Actor != "anonymous" {
+		t.Errorf("expected anonymous actor, got %q", anon.Actor)
+	}
+}
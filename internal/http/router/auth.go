@@ -0,0 +1,103 @@
+package router
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// apiKeyHeader carries API keys of service-to-service clients.
+const apiKeyHeader = "X-API-Key"
+
+// authenticate resolves the caller from an X-API-Key header or a JWT
+// bearer token and stores the principal in the request context.
+// Requests without credentials are rejected unless allowAnonymous is set.
+func authenticate(v *auth.Verifier, keys *postgres.APIKeyRepo, allowAnonymous bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var (
+			p   auth.Principal
+			err error
+		)
+
+		switch key, bearer := c.GetHeader(apiKeyHeader), c.GetHeader("Authorization"); {
+		case key != "" && keys != nil:
+			p, err = auth.AuthenticateAPIKey(c.Request.Context(), keys, key)
+		case v != nil && (bearer != "" || !allowAnonymous):
+			p, err = auth.AuthenticateBearer(v, bearer)
+		case !allowAnonymous:
+			err = auth.ErrMissingCredentials
+		default:
+			// Authentication disabled
+			c.Next()
+			return
+		}
+
+		if err != nil {
+			slog.WarnContext(c.Request.Context(), "authentication rejected", "error", err)
+
+			p := problem.New(problem.TypeUnauthorized, http.StatusUnauthorized, auth.ErrInvalidToken.Error())
+			switch {
+			case errors.Is(err, auth.ErrMissingCredentials):
+				p.Detail = auth.ErrMissingCredentials.Error()
+			case errors.Is(err, auth.ErrInvalidAPIKey):
+				p.Detail = auth.ErrInvalidAPIKey.Error()
+			case errors.Is(err, auth.ErrUnavailable):
+				p = problem.New(problem.TypeUnavailable, http.StatusServiceUnavailable, auth.ErrUnavailable.Error())
+			}
+
+			if p.Status == http.StatusUnauthorized {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			}
+			problem.Write(c, p)
+			return
+		}
+
+		// Make the caller visible to handlers and log records
+		ctx := c.Request.Context()
+		if p.UserID != uuid.Nil {
+			logging.Add(ctx, slog.String("user_id", p.UserID.String()))
+		}
+		c.Request = c.Request.WithContext(auth.WithPrincipal(ctx, p))
+		c.Next()
+	}
+}
+
+// requireScope rejects callers whose credentials do not grant scope.
+// Unauthenticated requests pass only when allowAnonymous is set.
+func requireScope(scope string, allowAnonymous bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, ok := auth.PrincipalFrom(c.Request.Context())
+		if !ok && !allowAnonymous {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			problem.Write(c, problem.New(problem.TypeUnauthorized, http.StatusUnauthorized,
+				auth.ErrMissingCredentials.Error()))
+			return
+		}
+		if ok && !p.HasScope(scope) {
+			problem.Write(c, problem.New(problem.TypeForbidden, http.StatusForbidden,
+				"insufficient scope: "+scope+" required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// requireAdmin rejects callers limited to their own user's data, for
+// endpoints that act on the whole tenant.
+func requireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if auth.ScopedUserID(c.Request.Context()) != nil {
+			problem.Write(c, problem.New(problem.TypeForbidden, http.StatusForbidden,
+				"admin access required"))
+			return
+		}
+		c.Next()
+	}
+}
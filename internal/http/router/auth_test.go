@@ -0,0 +1,149 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/gin-gonic/gin"
+)
+
+// serveWithPrincipal runs requireScope for a request made by p.
+func serveWithPrincipal(p *auth.Principal, scope string, allowAnonymous bool) int {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if p != nil {
+			c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), *p))
+		}
+	})
+	r.GET("/", requireScope(scope, allowAnonymous), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+// ====================================
+// requireScope
+// ====================================
+
+func TestRequireScope(t *testing.T) {
+	cases := []struct {
+		name           string
+		principal      *auth.Principal
+		allowAnonymous bool
+		expected       int
+	}{
+		{"anonymous", nil, false, http.StatusUnauthorized},
+		{"anonymous allowed", nil, true, http.StatusOK},
+		{"jwt user", &auth.Principal{}, false, http.StatusOK},
+		{"api key with scope", &auth.Principal{Scopes: []string{auth.ScopeSubscriptionsRead}}, false, http.StatusOK},
+		{"api key without scope", &auth.Principal{Scopes: []string{}}, true, http.StatusForbidden},
+	}
+
+	for _, tc := range cases {
+		// Act
+		code := serveWithPrincipal(tc.principal, auth.ScopeSubscriptionsRead, tc.allowAnonymous)
+
+		// Assert
+		if code != tc.expected {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.expected, code)
+		}
+	}
+}
+
+// ====================================
+// requireAdmin
+// ====================================
+
+func TestRequireAdmin(t *testing.T) {
+	cases := []struct {
+		name      string
+		principal *auth.Principal
+		expected  int
+	}{
+		{"anonymous", nil, http.StatusOK},
+		{"jwt user", &auth.Principal{}, http.StatusForbidden},
+		{"jwt admin", &auth.Principal{Admin: true}, http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		// Arrange
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			if tc.principal != nil {
+				c.Request = c.Request.WithContext(auth.WithPrincipal(c.Request.Context(), *tc.principal))
+			}
+		})
+		r.GET("/", requireAdmin(), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+		// Act
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		// Assert
+		if w.Code != tc.expected {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.expected, w.Code)
+		}
+	}
+}
+
+// ====================================
+// authenticate
+// ====================================
+
+func TestAuthenticate_MissingBearer(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	v, _ := auth.NewVerifier(auth.VerifierConfig{HMACSecret: []byte("secret")})
+	r := gin.New()
+	r.GET("/", authenticate(v, nil, false), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, header := range []string{"", "Basic abc", "Bearer ", "Bearer not.a.jwt"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Authorization", header)
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%q: expected %d, got %d", header, http.StatusUnauthorized, w.Code)
+		}
+	}
+}
+
+func TestAuthenticate_Anonymous(t *testing.T) {
+	v, _ := auth.NewVerifier(auth.VerifierConfig{HMACSecret: []byte("secret")})
+	cases := []struct {
+		name           string
+		verifier       *auth.Verifier
+		allowAnonymous bool
+		expected       int
+	}{
+		{"no credential source", nil, false, http.StatusUnauthorized},
+		{"jwt configured", v, false, http.StatusUnauthorized},
+		{"auth not required", nil, true, http.StatusOK},
+		{"auth not required with jwt configured", v, true, http.StatusOK},
+	}
+
+	for _, tc := range cases {
+		// Arrange
+		gin.SetMode(gin.TestMode)
+		r := gin.New()
+		r.GET("/", authenticate(tc.verifier, nil, tc.allowAnonymous), func(c *gin.Context) { c.Status(http.StatusOK) })
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		// Assert
+		if w.Code != tc.expected {
+			t.Errorf("%s: expected %d, got %d", tc.name, tc.expected, w.Code)
+		}
+	}
+}
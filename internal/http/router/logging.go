@@ -0,0 +1,89 @@
+package router
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"unicode"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// requestIDHeader carries the request ID from and to clients.
+const requestIDHeader = "X-Request-ID"
+
+// requestID assigns a request ID, or propagates a valid one from the
+// client, echoes it in the response and starts the request log fields.
+func requestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := clip(c.GetHeader(requestIDHeader))
+		if id == "" || !printable(id) {
+			id = uuid.NewString()
+		}
+		c.Header(requestIDHeader, id)
+
+		ctx := logging.NewRequest(c.Request.Context(), id)
+		if route := c.FullPath(); route != "" {
+			logging.Add(ctx, slog.String("route", route))
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// accessLog writes one record per request once it completes.
+func accessLog() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= http.StatusInternalServerError:
+			level = slog.LevelError
+		case status >= http.StatusBadRequest:
+			level = slog.LevelWarn
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", c.Writer.Size()),
+			slog.String("client_ip", c.ClientIP()),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, slog.String("error", c.Errors.String()))
+		}
+
+		slog.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
+
+// recoverPanics turns handler panics into 500 responses and logs them
+// with the stack.
+func recoverPanics() gin.HandlerFunc {
+	return gin.CustomRecoveryWithWriter(io.Discard, func(c *gin.Context, rec any) {
+		slog.ErrorContext(c.Request.Context(), "panic recovered",
+			"panic", rec,
+			"stack", string(debug.Stack()),
+		)
+		problem.Write(c, problem.New(problem.TypeInternal, http.StatusInternalServerError, "internal error"))
+	})
+}
+
+// printable reports whether s contains printable ASCII only,
+// so client IDs cannot inject control characters into logs.
+func printable(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
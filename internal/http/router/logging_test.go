@@ -0,0 +1,121 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/logging"
+	"github.com/gin-gonic/gin"
+)
+
+// captureLogs routes the default logger into a buffer for the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger, err := logging.New(&buf, "json", "debug")
+	if err != nil {
+		t.Fatalf("logging.New: %v", err)
+	}
+
+	prev := slog.Default()
+	slog.SetDefault(logger)
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	return &buf
+}
+
+// ====================================
+// requestID
+// ====================================
+
+func TestRequestID_PropagatesOrGenerates(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		keep   bool
+	}{
+		{"propagates client id", "req-42", true},
+		{"generates missing id", "", false},
+		{"replaces control characters", "bad\nid", false},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// Arrange
+			gin.SetMode(gin.TestMode)
+			r := gin.New()
+
+			var got string
+			r.GET("/", requestID(), func(c *gin.Context) {
+				got = logging.RequestID(c.Request.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.header != "" {
+				req.Header[http.CanonicalHeaderKey(requestIDHeader)] = []string{tc.header}
+			}
+			w := httptest.NewRecorder()
+
+			// Act
+			r.ServeHTTP(w, req)
+
+			// Assert
+			if got == "" || w.Header().Get(requestIDHeader) != got {
+				t.Fatalf("expected echoed request id, got %q and %q", got, w.Header().Get(requestIDHeader))
+			}
+			if (got == tc.header) != tc.keep {
+				t.Errorf("unexpected request id %q", got)
+			}
+		})
+	}
+}
+
+// ====================================
+// accessLog
+// ====================================
+
+func TestAccessLog_StructuredRecord(t *testing.T) {
+	// Arrange
+	buf := captureLogs(t)
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	r.Use(requestID(), accessLog())
+	r.GET("/items/:id", func(c *gin.Context) {
+		logging.Add(c.Request.Context(), slog.String("subscription_id", c.Param("id")))
+		c.Status(http.StatusNotFound)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+	req.Header.Set(requestIDHeader, "req-7")
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("invalid record %q: %v", buf.String(), err)
+	}
+	want := map[string]any{
+		"msg":             "request",
+		"level":           "WARN",
+		"request_id":      "req-7",
+		"route":           "/items/:id",
+		"subscription_id": "7",
+		"status":          float64(http.StatusNotFound),
+	}
+	for k, v := range want {
+		if rec[k] != v {
+			t.Errorf("%s: expected %v, got %v", k, v, rec[k])
+		}
+	}
+	if _, ok := rec["latency_ms"]; !ok {
+		t.Error("expected latency_ms")
+	}
+}
@@ -0,0 +1,47 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// ====================================
+// httpMetrics
+// ====================================
+
+func TestHTTPMetrics_LabelsByRouteTemplate(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	reg := prometheus.NewRegistry()
+
+	r := gin.New()
+	r.Use(httpMetrics(reg))
+	r.GET("/items/:id", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	// Act
+	serveFrom(r, "/items/1", "10.0.0.1:1234")
+	serveFrom(r, "/items/2", "10.0.0.1:1234")
+	serveFrom(r, "/missing", "10.0.0.1:1234")
+
+	out := httptest.NewRecorder()
+	promhttp.HandlerFor(reg, promhttp.HandlerOpts{}).
+		ServeHTTP(out, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	// Assert
+	text := out.Body.String()
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="/items/:id",status="204"} 2`,
+		`http_requests_total{method="GET",route="unmatched",status="404"} 1`,
+		`http_request_duration_seconds_count{method="GET",route="/items/:id",status="204"} 2`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("missing %q in:\n%s", want, text)
+		}
+	}
+}
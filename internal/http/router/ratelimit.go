@@ -0,0 +1,89 @@
+package router
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// rateLimitClient identifies the client a request is counted against:
+// the API key or user of the credentials, otherwise the client IP.
+func rateLimitClient(c *gin.Context) string {
+	if p, ok := auth.PrincipalFrom(c.Request.Context()); ok {
+		if p.UserID != uuid.Nil && !p.Admin {
+			return "user:" + p.UserID.String()
+		}
+		return "sub:" + p.Subject
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// rateLimit enforces per-route token bucket limits. Responses carry
+// RateLimit-* headers; rejected requests get 429 with Retry-After.
+// Store errors let the request through.
+func rateLimit(l *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := ratelimit.RouteKey(c.Request.Method, c.FullPath())
+
+		lim, ok := l.LimitFor(route)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		res, err := l.Take(c.Request.Context(), route, rateLimitClient(c), lim)
+		enforceRateLimit(c, lim, res, err)
+	}
+}
+
+// rateLimitIP enforces the per-IP limit. It runs before authentication,
+// so floods of invalid credentials are rejected before each costs a
+// key lookup.
+func rateLimitIP(l *ratelimit.Limiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		lim, ok := l.IPLimit()
+		if !ok {
+			c.Next()
+			return
+		}
+
+		res, err := l.TakeIP(c.Request.Context(), c.ClientIP(), lim)
+		enforceRateLimit(c, lim, res, err)
+	}
+}
+
+// enforceRateLimit sets the RateLimit-* headers of a taken token and
+// rejects the request when the bucket was empty.
+func enforceRateLimit(c *gin.Context, lim ratelimit.Limit, res ratelimit.Result, err error) {
+	if err != nil {
+		slog.ErrorContext(c.Request.Context(), "rate limit store", "error", err)
+		c.Next()
+		return
+	}
+
+	c.Header("RateLimit-Policy", lim.Policy())
+	c.Header("RateLimit-Limit", strconv.Itoa(lim.Burst))
+	c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	c.Header("RateLimit-Reset", ceilSeconds(res.Reset))
+
+	if !res.Allowed {
+		c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+		problem.Write(c, problem.New(problem.TypeRateLimited, http.StatusTooManyRequests, "rate limit exceeded"))
+		return
+	}
+
+	c.Next()
+}
+
+// ceilSeconds formats a duration as whole seconds, rounded up.
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
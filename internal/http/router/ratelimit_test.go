@@ -0,0 +1,132 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// newRateLimitedRouter serves GET /limited with a limit of one request per minute.
+func newRateLimitedRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	l := ratelimit.New(ratelimit.NewMemoryStore(time.Minute, 0), nil, map[string]ratelimit.Limit{
+		"GET /limited": {Requests: 1, Per: time.Minute, Burst: 1},
+	})
+
+	r := gin.New()
+	r.Use(rateLimit(l))
+	r.GET("/limited", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/free", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+// serveFrom performs a GET request from the given client address.
+func serveFrom(r http.Handler, path, addr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = addr
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// ====================================
+// rateLimit
+// ====================================
+
+func TestRateLimit_RejectsWithRetryAfter(t *testing.T) {
+	// Arrange
+	r := newRateLimitedRouter()
+
+	// Act
+	first := serveFrom(r, "/limited", "10.0.0.1:1234")
+	second := serveFrom(r, "/limited", "10.0.0.1:1234")
+
+	// Assert
+	if first.Code != http.StatusOK || first.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("first: unexpected %d remaining=%q", first.Code, first.Header().Get("RateLimit-Remaining"))
+	}
+	if first.Header().Get("RateLimit-Limit") != "1" || first.Header().Get("RateLimit-Reset") != "60" {
+		t.Errorf("first: unexpected headers %v", first.Header())
+	}
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second: expected 429, got %d", second.Code)
+	}
+	if got := second.Header().Get("Retry-After"); got != "60" {
+		t.Errorf("expected Retry-After 60, got %q", got)
+	}
+}
+
+func TestRateLimit_PerClientAndRoute(t *testing.T) {
+	// Arrange
+	r := newRateLimitedRouter()
+	serveFrom(r, "/limited", "10.0.0.1:1234")
+
+	// Act
+	otherClient := serveFrom(r, "/limited", "10.0.0.2:1234")
+	unlimited := serveFrom(r, "/free", "10.0.0.1:1234")
+
+	// Assert
+	if otherClient.Code != http.StatusOK {
+		t.Errorf("expected separate bucket per client, got %d", otherClient.Code)
+	}
+	if unlimited.Code != http.StatusOK || unlimited.Header().Get("RateLimit-Limit") != "" {
+		t.Errorf("expected unlimited route without headers, got %d %v", unlimited.Code, unlimited.Header())
+	}
+}
+
+func TestNewRouter_LimitsPerIPBeforeAuthentication(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	l := ratelimit.New(ratelimit.NewMemoryStore(time.Minute, 0), nil, nil).
+		WithIPLimit(ratelimit.Limit{Requests: 1, Per: time.Minute, Burst: 1})
+	r := NewRouter(Dependencies{RateLimiter: l})
+
+	// Act
+	first := serveFrom(r, "/api/subscriptions", "10.0.0.1:1234")
+	second := serveFrom(r, "/api/subscriptions", "10.0.0.1:1234")
+	otherIP := serveFrom(r, "/api/subscriptions", "10.0.0.2:1234")
+
+	// Assert
+	if first.Code != http.StatusUnauthorized || otherIP.Code != http.StatusUnauthorized {
+		t.Errorf("expected 401 without credentials, got %d and %d", first.Code, otherIP.Code)
+	}
+	if second.Code != http.StatusTooManyRequests {
+		t.Errorf("expected unauthenticated requests to be limited, got %d", second.Code)
+	}
+}
+
+func TestNewRouter_TrustedProxies(t *testing.T) {
+	cases := []struct {
+		name     string
+		trusted  []string
+		expected string
+	}{
+		{"no trusted proxies", nil, "10.0.0.1"},
+		{"trusted proxy", []string{"10.0.0.0/8"}, "203.0.113.7"},
+	}
+
+	for _, tc := range cases {
+		// Arrange
+		gin.SetMode(gin.TestMode)
+		r := NewRouter(Dependencies{TrustedProxies: tc.trusted})
+		r.GET("/ip", func(c *gin.Context) { c.String(http.StatusOK, c.ClientIP()) })
+
+		req := httptest.NewRequest(http.MethodGet, "/ip", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		w := httptest.NewRecorder()
+
+		// Act
+		r.ServeHTTP(w, req)
+
+		// Assert
+		if w.Body.String() != tc.expected {
+			t.Errorf("%s: expected client IP %s, got %s", tc.name, tc.expected, w.Body.String())
+		}
+	}
+}
@@ -1,8 +1,16 @@
 package router
 
 import (
+	"log/slog"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/http/handlers"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/ratelimit"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/postgres"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel/trace"
 
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
@@ -12,6 +20,43 @@ import (
 type Dependencies struct {
 	Subscriptions *handlers.SubscriptionsHandler
 	Aggregation   *handlers.AggregationHandler
+	Audit         *handlers.AuditHandler
+	Health        *handlers.HealthHandler
+
+	// GraphQL serves /graphql; nil disables the endpoint
+	GraphQL *handlers.GraphQLHandler
+
+	// Webhooks serves webhook registration; nil disables the endpoints
+	Webhooks *handlers.WebhooksHandler
+
+	// Stream serves subscription changes as events; nil disables the endpoint
+	Stream *handlers.StreamHandler
+
+	// Auth verifies bearer tokens; nil disables JWT authentication
+	Auth *auth.Verifier
+
+	// APIKeys authenticates X-API-Key callers; nil disables API keys
+	APIKeys *postgres.APIKeyRepo
+
+	// AllowAnonymous lets requests without credentials through, for
+	// deployments that run with authentication disabled
+	AllowAnonymous bool
+
+	// DefaultTenantID is used for requests without a tenant
+	DefaultTenantID string
+
+	// TrustedProxies may set the client IP with X-Forwarded-For; with
+	// none, the client IP is the address of the connection
+	TrustedProxies []string
+
+	// RateLimiter limits requests per client; nil disables rate limiting
+	RateLimiter *ratelimit.Limiter
+
+	// Metrics collects HTTP metrics served at /metrics; nil disables them
+	Metrics *prometheus.Registry
+
+	// Tracer records a span per request; nil disables tracing
+	Tracer trace.TracerProvider
 }
 
 // NewRouter configures and returns a Gin HTTP router.
@@ -19,23 +64,109 @@ func NewRouter(d Dependencies) *gin.Engine {
 	// Create Gin engine without default middleware
 	r := gin.New()
 
-	// Register logging and panic recovery middleware
-	r.Use(gin.Logger(), gin.Recovery())
+	// Only trusted proxies may name the client IP, which rate limits and
+	// access logs rely on
+	if err := r.SetTrustedProxies(d.TrustedProxies); err != nil {
+		slog.Error("invalid trusted proxies, trusting none", "error", err)
+		_ = r.SetTrustedProxies(nil)
+	}
+
+	// Register request ID, structured access log and panic recovery
+	r.Use(requestID(), accessLog(), recoverPanics())
+
+	// Server span per request, continuing incoming traces
+	if d.Tracer != nil {
+		r.Use(traceRequests(d.Tracer))
+	}
+
+	// Request counts and latencies, exposed for Prometheus
+	if d.Metrics != nil {
+		r.Use(httpMetrics(d.Metrics))
+		r.GET("/metrics", gin.WrapH(promhttp.HandlerFor(d.Metrics, promhttp.HandlerOpts{})))
+	}
 
 	// Swagger UI
 	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
-	api := r.Group("/api")
+	// Liveness and readiness probes, no authentication
+	if d.Health != nil {
+		r.GET("/healthz", d.Health.Live)
+		r.GET("/readyz", d.Health.Ready)
+	}
+
+	// Authenticate callers by API key or bearer token
+	if d.Auth == nil {
+		slog.Warn("JWT authentication disabled: no JWT keys configured")
+	}
+	if d.AllowAnonymous {
+		slog.Warn("authentication not required: requests without credentials are allowed")
+	}
+	var protected []gin.HandlerFunc
+
+	// Limit request rates per IP before credentials are checked, then
+	// per API key, user or IP
+	if d.RateLimiter != nil {
+		protected = append(protected, rateLimitIP(d.RateLimiter))
+	}
+	protected = append(protected, authenticate(d.Auth, d.APIKeys, d.AllowAnonymous))
+	if d.RateLimiter != nil {
+		protected = append(protected, rateLimit(d.RateLimiter))
+	}
+
+	// Scope every query to the request tenant, then attribute changes
+	// to the caller and request
+	protected = append(protected, resolveTenant(d.DefaultTenantID), auditMeta())
+
+	api := r.Group("/api", protected...)
+
+	// Per-route scopes, enforced for API key callers
+	read := requireScope(auth.ScopeSubscriptionsRead, d.AllowAnonymous)
+	write := requireScope(auth.ScopeSubscriptionsWrite, d.AllowAnonymous)
+	aggregate := requireScope(auth.ScopeAggregationRead, d.AllowAnonymous)
+	auditRead := requireScope(auth.ScopeAuditRead, d.AllowAnonymous)
+
 	{
 		// CRUDL operations for subscriptions
-		api.POST("/subscriptions", d.Subscriptions.Create)
-		api.GET("/subscriptions/:id", d.Subscriptions.Get)
-		api.PUT("/subscriptions/:id", d.Subscriptions.Update)
-		api.DELETE("/subscriptions/:id", d.Subscriptions.Delete)
-		api.GET("/subscriptions", d.Subscriptions.List)
+		api.POST("/subscriptions", write, d.Subscriptions.Create)
+		api.POST("/subscriptions/batch", write, d.Subscriptions.Batch)
+		api.POST("/subscriptions/import", write, d.Subscriptions.Import)
+		api.GET("/subscriptions/:id", read, d.Subscriptions.Get)
+		api.PUT("/subscriptions/:id", write, d.Subscriptions.Update)
+		api.DELETE("/subscriptions/:id", write, d.Subscriptions.Delete)
+		api.GET("/subscriptions", read, d.Subscriptions.List)
+		api.GET("/subscriptions/export", read, d.Subscriptions.Export)
 
 		// Aggregation endpoint: calculate total subscription cost for a period
-		api.GET("/subscriptions/total", d.Aggregation.Total)
+		api.GET("/subscriptions/total", aggregate, d.Aggregation.Total)
+
+		// Live subscription changes as Server-Sent Events
+		if d.Stream != nil {
+			api.GET("/subscriptions/stream", read, d.Stream.Stream)
+		}
+
+		// Audit log of subscription changes
+		api.GET("/subscriptions/:id/history", auditRead, d.Audit.History)
+		api.GET("/audit", auditRead, d.Audit.Search)
+	}
+
+	// Webhooks receive events of the whole tenant, so only callers not
+	// limited to their own data may manage them
+	if d.Webhooks != nil {
+		hooks := api.Group("/webhooks", requireAdmin(), requireScope(auth.ScopeWebhooksManage, d.AllowAnonymous))
+		hooks.POST("", d.Webhooks.Create)
+		hooks.GET("", d.Webhooks.List)
+		hooks.GET("/:id", d.Webhooks.Get)
+		hooks.DELETE("/:id", d.Webhooks.Delete)
+		hooks.GET("/:id/deliveries", d.Webhooks.Deliveries)
+		hooks.POST("/:id/deliveries/:delivery_id/redeliver", d.Webhooks.Redeliver)
+	}
+
+	// GraphQL queries over subscriptions and totals; totals also need
+	// the aggregation scope, checked per field
+	if d.GraphQL != nil {
+		gql := r.Group("/graphql", protected...)
+		gql.GET("", read, d.GraphQL.Query)
+		gql.POST("", read, d.GraphQL.Query)
 	}
 
 	return r
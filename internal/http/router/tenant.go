@@ -0,0 +1,35 @@
+package router
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/http/problem"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/gin-gonic/gin"
+)
+
+// tenantHeader selects the tenant of a request.
+const tenantHeader = "X-Tenant-ID"
+
+// resolveTenant stores the request tenant in the context. Storage applies
+// it to every transaction, so row-level security hides other tenants.
+func resolveTenant(defaultTenant string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		p, authenticated := auth.PrincipalFrom(c.Request.Context())
+
+		id, err := tenant.Resolve(p.TenantID, c.GetHeader(tenantHeader), defaultTenant, authenticated)
+		if err != nil {
+			p := problem.New(problem.TypeValidation, http.StatusBadRequest, err.Error())
+			if errors.Is(err, tenant.ErrMismatch) || errors.Is(err, tenant.ErrUnbound) {
+				p = problem.New(problem.TypeForbidden, http.StatusForbidden, err.Error())
+			}
+			problem.Write(c, p)
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), id))
+		c.Next()
+	}
+}
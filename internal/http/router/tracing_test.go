@@ -0,0 +1,56 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ====================================
+// traceRequests
+// ====================================
+
+func TestTraceRequests_ContinuesIncomingTrace(t *testing.T) {
+	// Arrange
+	gin.SetMode(gin.TestMode)
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	defer tp.Shutdown(context.Background())
+
+	var traceID string
+	r := gin.New()
+	r.Use(traceRequests(tp))
+	r.GET("/items/:id", func(c *gin.Context) {
+		traceID = trace.SpanContextFromContext(c.Request.Context()).TraceID().String()
+		c.Status(http.StatusInternalServerError)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	// Act
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Assert
+	if traceID != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("handler saw trace %q", traceID)
+	}
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.Name() != "GET /items/:id" || s.SpanKind() != trace.SpanKindServer || s.Status().Code != codes.Error {
+		t.Errorf("unexpected span %s kind %s status %v", s.Name(), s.SpanKind(), s.Status())
+	}
+	if s.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("unexpected parent %s", s.Parent().SpanID())
+	}
+}
@@ -0,0 +1,131 @@
+// Package logging configures structured logging and carries request
+// scoped log fields in the context.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// New returns a logger writing JSON or text records at the given level
+// (debug, info, warn or error). Records carry the request fields of
+// their context.
+func New(w io.Writer, format, level string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid log level %q", level)
+	}
+
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("invalid log format %q", format)
+	}
+
+	return slog.New(contextHandler{h}), nil
+}
+
+// fields are the log fields of one request. They are shared by pointer,
+// so fields added deeper in the call chain show up in later records of
+// the whole request, including the access log.
+type fields struct {
+	start time.Time
+
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+// fieldsKey stores request fields in a context.
+type fieldsKey struct{}
+
+// requestIDKey stores the request ID in a context.
+type requestIDKey struct{}
+
+// NewRequest starts a field set for a request with the given ID. Records
+// logged with the returned context carry request_id, the added fields
+// and latency_ms since the request started.
+func NewRequest(ctx context.Context, requestID string) context.Context {
+	f := &fields{
+		start: time.Now(),
+		attrs: []slog.Attr{slog.String("request_id", requestID)},
+	}
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+	return context.WithValue(ctx, fieldsKey{}, f)
+}
+
+// RequestID returns the ID of the request, empty outside requests.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Add sets request fields, replacing fields of the same key. It does
+// nothing outside requests.
+func Add(ctx context.Context, attrs ...slog.Attr) {
+	f, ok := ctx.Value(fieldsKey{}).(*fields)
+	if !ok {
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+next:
+	for _, a := range attrs {
+		for i := range f.attrs {
+			if f.attrs[i].Key == a.Key {
+				f.attrs[i] = a
+				continue next
+			}
+		}
+		f.attrs = append(f.attrs, a)
+	}
+}
+
+// contextHandler adds request fields and the trace ID of the record
+// context to every record.
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle adds the context fields and passes the record on.
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.mu.Lock()
+		r.AddAttrs(f.attrs...)
+		f.mu.Unlock()
+		r.AddAttrs(slog.Float64("latency_ms", msSince(f.start)))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs keeps the context handling on derived loggers.
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup keeps the context handling on derived loggers.
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// msSince returns the milliseconds elapsed since t, rounded to microseconds.
+func msSince(t time.Time) float64 {
+	return float64(time.Since(t).Microseconds()) / 1000
+}
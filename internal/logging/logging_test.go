@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// decode parses one JSON log record.
+func decode(t *testing.T, b []byte) map[string]any {
+	t.Helper()
+
+	var rec map[string]any
+	if err := json.Unmarshal(b, &rec); err != nil {
+		t.Fatalf("invalid record %q: %v", b, err)
+	}
+	return rec
+}
+
+// ====================================
+// New
+// ====================================
+
+func TestNew_RejectsUnknownSettings(t *testing.T) {
+	if _, err := New(&bytes.Buffer{}, "xml", "info"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := New(&bytes.Buffer{}, "json", "verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
+
+func TestNew_FiltersByLevel(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger, err := New(&buf, "json", "warn")
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+
+	// Act
+	logger.Info("hidden")
+
+	// Assert
+	if buf.Len() != 0 {
+		t.Errorf("expected no output, got %q", buf.String())
+	}
+}
+
+// ====================================
+// Request fields
+// ====================================
+
+func TestRequestFields_AddedToRecords(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger, _ := New(&buf, "json", "info")
+
+	ctx := NewRequest(context.Background(), "req-1")
+	Add(ctx, slog.String("user_id", "u1"), slog.String("route", "/a"))
+	Add(ctx, slog.String("user_id", "u2"))
+
+	// Act
+	logger.InfoContext(ctx, "done")
+
+	// Assert
+	rec := decode(t, buf.Bytes())
+	if rec["request_id"] != "req-1" || rec["user_id"] != "u2" || rec["route"] != "/a" {
+		t.Errorf("unexpected record %v", rec)
+	}
+	if _, ok := rec["latency_ms"].(float64); !ok {
+		t.Errorf("expected latency_ms, got %v", rec)
+	}
+	if RequestID(ctx) != "req-1" {
+		t.Errorf("unexpected request id %q", RequestID(ctx))
+	}
+}
+
+func TestRequestFields_TraceID(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	logger, _ := New(&buf, "json", "info")
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	// Act
+	logger.InfoContext(ctx, "traced")
+
+	// Assert
+	if rec := decode(t, buf.Bytes()); rec["trace_id"] != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("unexpected record %v", rec)
+	}
+}
+
+func TestAdd_OutsideRequest(t *testing.T) {
+	// Act: must not panic
+	Add(context.Background(), slog.String("user_id", "u1"))
+
+	// Assert
+	if RequestID(context.Background()) != "" {
+		t.Error("expected no request id")
+	}
+}
@@ -0,0 +1,111 @@
+// Package outbox relays subscription change events, stored in the
+// transaction of each change, to pluggable sinks with at-least-once
+// delivery and in order per subscription.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// Message is one event stored in the outbox.
+type Message struct {
+	ID             int64 // increases with every change of a subscription
+	TenantID       string
+	EventID        uuid.UUID
+	Type           string
+	SubscriptionID uuid.UUID
+	Payload        json.RawMessage // event encoded by Encode
+	CreatedAt      time.Time
+}
+
+// Sink publishes messages. The same message may be published again after
+// a failure or crash, so sinks should deduplicate by event ID if needed.
+type Sink interface {
+	Publish(ctx context.Context, m Message) error
+}
+
+// Store holds the outbox of the tenant in ctx.
+type Store interface {
+	// Tenants returns the tenants with unsent messages, ignoring ctx's tenant.
+	Tenants(ctx context.Context) ([]string, error)
+
+	// Process locks up to limit due unsent messages, only the oldest one
+	// of each subscription, and passes them to fn in one transaction.
+	// Messages fn accepts are marked sent, the others are retried after
+	// backoff(attempts). It returns the number of messages sent.
+	Process(
+		ctx context.Context,
+		limit int,
+		backoff func(attempt int) time.Duration,
+		fn func(Message) error,
+	) (int, error)
+
+	// Purge removes messages of all tenants sent before the given time.
+	Purge(ctx context.Context, sentBefore time.Time) (int64, error)
+}
+
+// payload is the JSON encoding of an event.
+type payload struct {
+	ID         uuid.UUID        `json:"id"`
+	Type       string           `json:"type"`
+	OccurredAt time.Time        `json:"occurred_at"`
+	Data       subscriptionData `json:"data"`
+}
+
+// subscriptionData is a subscription as rendered by the REST API.
+type subscriptionData struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Price       int       `json:"price"`
+	UserID      uuid.UUID `json:"user_id"`
+	StartDate   string    `json:"start_date"`
+	EndDate     *string   `json:"end_date"`
+}
+
+// Encode returns the JSON payload of an event.
+func Encode(ev domain.Event) ([]byte, error) {
+	s := ev.Subscription
+	data := subscriptionData{
+		ID:          s.ID,
+		ServiceName: s.ServiceName,
+		Price:       s.Price,
+		UserID:      s.UserID,
+		StartDate:   utils.FormatMonthYear(s.StartDate),
+	}
+	if s.EndDate != nil {
+		end := utils.FormatMonthYear(*s.EndDate)
+		data.EndDate = &end
+	}
+
+	return json.Marshal(payload{
+		ID:         ev.ID,
+		Type:       ev.Type,
+		OccurredAt: ev.OccurredAt,
+		Data:       data,
+	})
+}
+
+// Backoff returns the delay before retrying a message after the given
+// failed attempt: one second doubled for every further attempt, at most
+// one minute. Later changes of the subscription wait meanwhile.
+func Backoff(attempt int) time.Duration {
+	const (
+		base     = time.Second
+		maxDelay = time.Minute
+	)
+
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxDelay {
+			return maxDelay
+		}
+	}
+	return d
+}
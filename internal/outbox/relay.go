@@ -0,0 +1,160 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+)
+
+// purgeInterval is how often sent messages past retention are removed.
+const purgeInterval = 10 * time.Minute
+
+// Options configures a Relay; zero fields use the defaults.
+type Options struct {
+	Interval  time.Duration // polling for unsent messages, default 1s
+	BatchSize int           // messages processed per transaction, default 100
+	Retention time.Duration // how long sent messages are kept, default 24h
+
+	// Backoff returns the delay after a failed attempt, default Backoff
+	Backoff func(attempt int) time.Duration
+}
+
+// withDefaults fills unset options.
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = time.Second
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 100
+	}
+	if o.Retention <= 0 {
+		o.Retention = 24 * time.Hour
+	}
+	if o.Backoff == nil {
+		o.Backoff = Backoff
+	}
+	return o
+}
+
+// Relay publishes unsent outbox messages to a sink in the background
+// until Shutdown. Several relays, also in other instances, may share
+// one outbox: each message is locked by the relay publishing it.
+type Relay struct {
+	store Store
+	sink  Sink
+	opts  Options
+
+	stop      chan struct{}
+	stopped   chan struct{}
+	stopOnce  sync.Once
+	lastPurge time.Time
+}
+
+// NewRelay starts a relay from store to sink.
+func NewRelay(store Store, sink Sink, opts Options) *Relay {
+	r := &Relay{
+		store:     store,
+		sink:      sink,
+		opts:      opts.withDefaults(),
+		stop:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+		lastPurge: time.Now(),
+	}
+	go r.run()
+	return r
+}
+
+// Shutdown stops the relay after the running batch is committed.
+// Unsent messages are published after the next start.
+func (r *Relay) Shutdown(ctx context.Context) error {
+	r.stopOnce.Do(func() { close(r.stop) })
+
+	select {
+	case <-r.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run relays messages on every poll until Shutdown.
+func (r *Relay) run() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+		r.tick(context.Background())
+	}
+}
+
+// tick relays the unsent messages of every tenant and purges old ones.
+func (r *Relay) tick(ctx context.Context) {
+	tenants, err := r.store.Tenants(ctx)
+	if err != nil {
+		slog.Error("Outbox: list tenants failed", "error", err)
+		return
+	}
+
+	for _, id := range tenants {
+		if r.stopping() {
+			return
+		}
+		r.relay(tenant.WithID(ctx, id))
+	}
+
+	if time.Since(r.lastPurge) >= purgeInterval {
+		r.lastPurge = time.Now()
+		n, err := r.store.Purge(ctx, time.Now().Add(-r.opts.Retention))
+		if err != nil {
+			slog.Error("Outbox: purge failed", "error", err)
+		} else if n > 0 {
+			slog.Debug("Outbox: purged sent messages", "messages", n)
+		}
+	}
+}
+
+// relay publishes the due messages of the tenant in ctx batch by batch.
+// Each batch holds at most one message per subscription, so the next
+// change of a subscription is only published after the previous one.
+func (r *Relay) relay(ctx context.Context) {
+	for !r.stopping() {
+		processed := 0
+		_, err := r.store.Process(ctx, r.opts.BatchSize, r.opts.Backoff, func(m Message) error {
+			processed++
+			if err := r.sink.Publish(ctx, m); err != nil {
+				slog.Warn("Outbox: publish failed",
+					"message_id", m.ID, "event", m.Type, "subscription_id", m.SubscriptionID, "error", err)
+				return err
+			}
+			return nil
+		})
+		if err != nil {
+			slog.Error("Outbox: process messages failed", "error", err)
+			return
+		}
+
+		if processed < r.opts.BatchSize {
+			return
+		}
+	}
+}
+
+// stopping reports whether Shutdown was called.
+func (r *Relay) stopping() bool {
+	select {
+	case <-r.stop:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,265 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memStore keeps the outbox of one tenant in memory with the claiming
+// rules of the Postgres store.
+type memStore struct {
+	mu       sync.Mutex
+	messages []Message
+	attempts map[int64]int
+	sent     map[int64]bool
+	due      map[int64]time.Time
+}
+
+func newMemStore() *memStore {
+	return &memStore{
+		attempts: map[int64]int{},
+		sent:     map[int64]bool{},
+		due:      map[int64]time.Time{},
+	}
+}
+
+// add appends a message of the subscription and returns it.
+func (s *memStore) add(sub uuid.UUID, typ string) Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m := Message{
+		ID:             int64(len(s.messages) + 1),
+		TenantID:       "acme",
+		EventID:        uuid.New(),
+		Type:           typ,
+		SubscriptionID: sub,
+	}
+	s.messages = append(s.messages, m)
+	return m
+}
+
+func (s *memStore) Tenants(context.Context) ([]string, error) {
+	return []string{"acme"}, nil
+}
+
+func (s *memStore) Process(
+	_ context.Context,
+	limit int,
+	backoff func(int) time.Duration,
+	fn func(Message) error,
+) (int, error) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Only the oldest unsent message of each subscription is due
+	blocked := map[uuid.UUID]bool{}
+	var claimed []Message
+	for _, m := range s.messages {
+		if s.sent[m.ID] {
+			continue
+		}
+		if !blocked[m.SubscriptionID] && !s.due[m.ID].After(time.Now()) && len(claimed) < limit {
+			claimed = append(claimed, m)
+		}
+		blocked[m.SubscriptionID] = true
+	}
+
+	sent := 0
+	for _, m := range claimed {
+		s.attempts[m.ID]++
+		if err := fn(m); err != nil {
+			s.due[m.ID] = time.Now().Add(backoff(s.attempts[m.ID]))
+			continue
+		}
+		s.sent[m.ID] = true
+		sent++
+	}
+	return sent, nil
+}
+
+func (s *memStore) Purge(context.Context, time.Time) (int64, error) {
+	return 0, nil
+}
+
+// unsent returns the number of messages not yet sent.
+func (s *memStore) unsent() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.messages) - len(s.sent)
+}
+
+// recordingSink records published messages and fails while fail
+// returns true for one.
+type recordingSink struct {
+	mu   sync.Mutex
+	got  []Message
+	fail func(Message) bool
+}
+
+func (s *recordingSink) Publish(_ context.Context, m Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.got = append(s.got, m)
+	if s.fail != nil && s.fail(m) {
+		return errors.New("sink unavailable")
+	}
+	return nil
+}
+
+// published returns a copy of the messages published so far.
+func (s *recordingSink) published() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.got...)
+}
+
+// newRelay starts a fast-polling relay without backoff.
+func newRelay(t *testing.T, store Store, sink Sink) *Relay {
+	t.Helper()
+
+	r := NewRelay(store, sink, Options{
+		Interval:  5 * time.Millisecond,
+		BatchSize: 2,
+		Backoff:   func(int) time.Duration { return 0 },
+	})
+	t.Cleanup(func() { _ = r.Shutdown(context.Background()) })
+
+	return r
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// Relay
+// ==============================================================
+// ==============================================================
+func TestRelay_PublishesAllMessagesInOrder(t *testing.T) {
+	// Arrange
+	store := newMemStore()
+	sink := &recordingSink{}
+	a, b := uuid.New(), uuid.New()
+	for range 3 {
+		store.add(a, "subscription.updated")
+		store.add(b, "subscription.updated")
+	}
+
+	// Act
+	newRelay(t, store, sink)
+
+	// Assert
+	waitFor(t, func() bool { return store.unsent() == 0 })
+
+	got := sink.published()
+	if len(got) != 6 {
+		t.Fatalf("expected 6 messages, got %d", len(got))
+	}
+	last := map[uuid.UUID]int64{}
+	for _, m := range got {
+		if m.ID <= last[m.SubscriptionID] {
+			t.Errorf("message %d of %s published after %d", m.ID, m.SubscriptionID, last[m.SubscriptionID])
+		}
+		last[m.SubscriptionID] = m.ID
+	}
+}
+
+func TestRelay_RetriesFailedMessagesBeforeLaterChanges(t *testing.T) {
+	// Arrange
+	store := newMemStore()
+	sub, other := uuid.New(), uuid.New()
+	first := store.add(sub, "subscription.created")
+	store.add(sub, "subscription.updated")
+	store.add(other, "subscription.created")
+
+	var failures int
+	sink := &recordingSink{fail: func(m Message) bool {
+		if m.ID == first.ID && failures < 3 {
+			failures++
+			return true
+		}
+		return false
+	}}
+
+	// Act
+	newRelay(t, store, sink)
+
+	// Assert
+	waitFor(t, func() bool { return store.unsent() == 0 })
+
+	// The failed message is published again until accepted, the later
+	// change of its subscription only afterwards
+	var order []int64
+	for _, m := range sink.published() {
+		if m.SubscriptionID == sub {
+			order = append(order, m.ID)
+		}
+	}
+	want := []int64{1, 1, 1, 1, 2}
+	if len(order) != len(want) {
+		t.Fatalf("expected publications %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected publications %v, got %v", want, order)
+		}
+	}
+}
+
+func TestRelay_OtherSubscriptionsAreNotBlocked(t *testing.T) {
+	// Arrange
+	store := newMemStore()
+	stuck, other := uuid.New(), uuid.New()
+	store.add(stuck, "subscription.created")
+	store.add(other, "subscription.created")
+	store.add(other, "subscription.deleted")
+
+	sink := &recordingSink{fail: func(m Message) bool { return m.SubscriptionID == stuck }}
+
+	// Act
+	newRelay(t, store, sink)
+
+	// Assert
+	waitFor(t, func() bool { return store.unsent() == 1 })
+}
+
+// ==============================================================
+// ==============================================================
+// Backoff
+// ==============================================================
+// ==============================================================
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{5, 16 * time.Second},
+		{7, time.Minute},
+		{100, time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
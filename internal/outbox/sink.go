@@ -0,0 +1,138 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/config"
+	"github.com/google/uuid"
+)
+
+// envelope is the JSON form of a message written by the built-in sinks.
+type envelope struct {
+	Sequence       int64           `json:"sequence"`
+	TenantID       string          `json:"tenant_id"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	Event          json.RawMessage `json:"event"`
+}
+
+// encodeEnvelope returns the JSON envelope of m.
+func encodeEnvelope(m Message) ([]byte, error) {
+	return json.Marshal(envelope{
+		Sequence:       m.ID,
+		TenantID:       m.TenantID,
+		SubscriptionID: m.SubscriptionID,
+		Event:          m.Payload,
+	})
+}
+
+// WriterSink writes each message as one line of JSON,
+// e.g. to stdout or a file.
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterSink returns a sink writing to w.
+func NewWriterSink(w io.Writer) *WriterSink {
+	return &WriterSink{w: w}
+}
+
+// Publish writes one message.
+func (s *WriterSink) Publish(_ context.Context, m Message) error {
+	line, err := encodeEnvelope(m)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(line, '\n'))
+	return err
+}
+
+// HTTPSink posts each message as JSON to a URL.
+type HTTPSink struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPSink returns a sink posting to url.
+func NewHTTPSink(url string, timeout time.Duration) *HTTPSink {
+	return &HTTPSink{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// Publish posts one message; responses other than 2xx are errors.
+// The event ID is sent as Idempotency-Key for deduplication.
+func (s *HTTPSink) Publish(ctx context.Context, m Message) error {
+	body, err := encodeEnvelope(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", m.EventID.String())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("outbox endpoint responded %s", resp.Status)
+	}
+
+	return nil
+}
+
+// Fanout publishes every message to all sinks. A failure of one sink
+// makes the relay retry the message for all of them.
+type Fanout []Sink
+
+// Publish passes m to every sink and joins their errors.
+func (f Fanout) Publish(ctx context.Context, m Message) error {
+	var errs []error
+	for _, s := range f {
+		if err := s.Publish(ctx, m); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NewSinkFromConfig builds the configured sink.
+// It returns nil when no sink is configured.
+func NewSinkFromConfig(cfg *config.Config) (Sink, error) {
+	switch cfg.OutboxSink {
+	case "stdout":
+		return NewWriterSink(os.Stdout), nil
+	case "file":
+		f, err := os.OpenFile(cfg.OutboxFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open outbox file: %w", err)
+		}
+		return NewWriterSink(f), nil
+	case "http":
+		return NewHTTPSink(cfg.OutboxHTTPURL, 10*time.Second), nil
+	default:
+		return nil, nil
+	}
+}
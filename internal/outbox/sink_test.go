@@ -0,0 +1,170 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// message returns an encoded created event of a new subscription.
+func message(t *testing.T) Message {
+	t.Helper()
+
+	ev := domain.NewEvent(domain.EventSubscriptionCreated, domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	payload, err := Encode(ev)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	return Message{
+		ID:             42,
+		TenantID:       "acme",
+		EventID:        ev.ID,
+		Type:           ev.Type,
+		SubscriptionID: ev.Subscription.ID,
+		Payload:        payload,
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// WriterSink
+// ==============================================================
+// ==============================================================
+func TestWriterSink_WritesOneLinePerMessage(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	sink := NewWriterSink(&buf)
+	m := message(t)
+
+	// Act
+	for range 2 {
+		if err := sink.Publish(context.Background(), m); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+
+	// Assert
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+
+	var got struct {
+		Sequence       int64     `json:"sequence"`
+		TenantID       string    `json:"tenant_id"`
+		SubscriptionID uuid.UUID `json:"subscription_id"`
+		Event          struct {
+			ID   uuid.UUID `json:"id"`
+			Type string    `json:"type"`
+			Data struct {
+				StartDate string  `json:"start_date"`
+				EndDate   *string `json:"end_date"`
+			} `json:"data"`
+		} `json:"event"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("invalid line: %v", err)
+	}
+	if got.Sequence != 42 || got.TenantID != "acme" || got.SubscriptionID != m.SubscriptionID {
+		t.Errorf("unexpected envelope: %s", lines[0])
+	}
+	if got.Event.ID != m.EventID || got.Event.Type != domain.EventSubscriptionCreated {
+		t.Errorf("unexpected event: %s", lines[0])
+	}
+	if got.Event.Data.StartDate != "01-2025" || got.Event.Data.EndDate != nil {
+		t.Errorf("unexpected subscription data: %s", lines[0])
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// HTTPSink
+// ==============================================================
+// ==============================================================
+func TestHTTPSink_PostsEnvelope(t *testing.T) {
+	// Arrange
+	var (
+		header http.Header
+		body   []byte
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Clone()
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer receiver.Close()
+
+	m := message(t)
+
+	// Act
+	err := NewHTTPSink(receiver.URL, time.Second).Publish(context.Background(), m)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if header.Get("Idempotency-Key") != m.EventID.String() {
+		t.Errorf("unexpected idempotency key %q", header.Get("Idempotency-Key"))
+	}
+	if header.Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected content type %q", header.Get("Content-Type"))
+	}
+	if !json.Valid(body) || !bytes.Contains(body, []byte(`"sequence":42`)) {
+		t.Errorf("unexpected body: %s", body)
+	}
+}
+
+func TestHTTPSink_FailsOnErrorStatus(t *testing.T) {
+	// Arrange
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	// Act
+	err := NewHTTPSink(receiver.URL, time.Second).Publish(context.Background(), message(t))
+
+	// Assert
+	if err == nil {
+		t.Fatal("expected an error for status 503")
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// Fanout
+// ==============================================================
+// ==============================================================
+func TestFanout_PublishesToAllSinks(t *testing.T) {
+	// Arrange
+	ok := &recordingSink{}
+	failing := &recordingSink{fail: func(Message) bool { return true }}
+	f := Fanout{failing, ok}
+
+	// Act
+	err := f.Publish(context.Background(), message(t))
+
+	// Assert
+	if err == nil || !strings.Contains(err.Error(), "sink unavailable") {
+		t.Fatalf("expected the failing sink's error, got %v", err)
+	}
+	if len(ok.published()) != 1 || len(failing.published()) != 1 {
+		t.Error("expected every sink to receive the message")
+	}
+}
@@ -0,0 +1,105 @@
+// Package ratelimit implements per-client token bucket rate limiting.
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidLimit indicates a malformed limit definition.
+var ErrInvalidLimit = errors.New("invalid rate limit")
+
+// Limit is a token bucket refilled with Requests tokens every Per,
+// holding at most Burst tokens.
+type Limit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// rate returns the refill rate in tokens per second.
+func (l Limit) rate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// Policy formats the limit for the RateLimit-Policy header.
+func (l Limit) Policy() string {
+	return fmt.Sprintf("%d;w=%d;burst=%d", l.Requests, int(math.Ceil(l.Per.Seconds())), l.Burst)
+}
+
+// limitUnits maps limit period suffixes to durations.
+var limitUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// ParseLimit parses "N/unit" or "N/unit:burst", e.g. "10/m" or "100/h:20".
+// Units are s, m and h; the burst defaults to N.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+
+	spec, burstStr, hasBurst := strings.Cut(s, ":")
+	nStr, unit, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(nStr))
+	if err != nil || n <= 0 {
+		return Limit{}, fmt.Errorf("%w: %q", ErrInvalidLimit, s)
+	}
+
+	per, ok := limitUnits[strings.TrimSpace(unit)]
+	if !ok {
+		return Limit{}, fmt.Errorf("%w: unknown unit in %q", ErrInvalidLimit, s)
+	}
+
+	l := Limit{Requests: n, Per: per, Burst: n}
+	if hasBurst {
+		burst, err := strconv.Atoi(strings.TrimSpace(burstStr))
+		if err != nil || burst <= 0 {
+			return Limit{}, fmt.Errorf("%w: invalid burst in %q", ErrInvalidLimit, s)
+		}
+		l.Burst = burst
+	}
+
+	return l, nil
+}
+
+// ParseRouteLimits parses comma-separated "METHOD /path=limit" entries,
+// e.g. "GET /api/subscriptions/total=10/m, POST /api/subscriptions/import=5/m".
+// Paths use the router syntax, e.g. /api/subscriptions/:id.
+func ParseRouteLimits(s string) (map[string]Limit, error) {
+	out := map[string]Limit{}
+
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+
+		route, limitStr, ok := strings.Cut(entry, "=")
+		method, path, hasPath := strings.Cut(strings.TrimSpace(route), " ")
+		if !ok || !hasPath || strings.TrimSpace(path) == "" {
+			return nil, fmt.Errorf("%w: route entry %q", ErrInvalidLimit, entry)
+		}
+
+		l, err := ParseLimit(limitStr)
+		if err != nil {
+			return nil, err
+		}
+
+		out[RouteKey(method, path)] = l
+	}
+
+	return out, nil
+}
+
+// RouteKey identifies a route by method and path pattern.
+func RouteKey(method, path string) string {
+	return strings.ToUpper(strings.TrimSpace(method)) + " " + strings.TrimSpace(path)
+}
@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	cases := []struct {
+		in       string
+		expected Limit
+	}{
+		{"10/s", Limit{Requests: 10, Per: time.Second, Burst: 10}},
+		{" 60/m ", Limit{Requests: 60, Per: time.Minute, Burst: 60}},
+		{"100/h:20", Limit{Requests: 100, Per: time.Hour, Burst: 20}},
+	}
+
+	for _, tc := range cases {
+		// Act
+		got, err := ParseLimit(tc.in)
+
+		// Assert
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", tc.in, err)
+			continue
+		}
+		if got != tc.expected {
+			t.Errorf("%q: expected %+v, got %+v", tc.in, tc.expected, got)
+		}
+	}
+}
+
+func TestParseLimit_Invalid(t *testing.T) {
+	for _, in := range []string{"", "10", "0/m", "-1/m", "10/d", "x/m", "10/m:0", "10/m:x"} {
+		// Act
+		_, err := ParseLimit(in)
+
+		// Assert
+		if !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("%q: expected ErrInvalidLimit, got %v", in, err)
+		}
+	}
+}
+
+func TestParseRouteLimits(t *testing.T) {
+	// Act
+	routes, err := ParseRouteLimits("get /api/subscriptions/total=10/m, POST /api/subscriptions/import=5/m:1,")
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(routes) != 2 {
+		t.Fatalf("expected 2 routes, got %d", len(routes))
+	}
+	if l := routes["GET /api/subscriptions/total"]; l.Requests != 10 || l.Per != time.Minute {
+		t.Errorf("unexpected total limit: %+v", l)
+	}
+	if l := routes["POST /api/subscriptions/import"]; l.Burst != 1 {
+		t.Errorf("unexpected import limit: %+v", l)
+	}
+}
+
+func TestParseRouteLimits_Invalid(t *testing.T) {
+	for _, in := range []string{"/api/subscriptions=10/m", "GET /api/subscriptions", "GET =10/m", "GET /api=ten/m"} {
+		// Act
+		_, err := ParseRouteLimits(in)
+
+		// Assert
+		if !errors.Is(err, ErrInvalidLimit) {
+			t.Errorf("%q: expected ErrInvalidLimit, got %v", in, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/config"
+)
+
+// In-memory bucket limits: how long idle buckets are kept, and how many
+// are kept at most.
+const (
+	bucketIdleTTL = 10 * time.Minute
+	maxBuckets    = 100_000
+)
+
+// ipRoute is the route key of the buckets of the per-IP limit.
+const ipRoute = "*"
+
+// Limiter applies per-route limits to clients.
+type Limiter struct {
+	store  Store
+	def    *Limit // nil leaves routes without own limit unlimited
+	routes map[string]Limit
+	ip     *Limit // nil leaves requests before authentication unlimited
+	now    func() time.Time
+}
+
+// New creates a limiter. def applies to routes missing from routes.
+func New(store Store, def *Limit, routes map[string]Limit) *Limiter {
+	return &Limiter{
+		store:  store,
+		def:    def,
+		routes: routes,
+		now:    time.Now,
+	}
+}
+
+// WithIPLimit sets a limit per client IP over all routes, applied
+// before the caller is authenticated.
+func (l *Limiter) WithIPLimit(lim Limit) *Limiter {
+	l.ip = &lim
+	return l
+}
+
+// IPLimit returns the per-IP limit and whether there is one.
+func (l *Limiter) IPLimit() (Limit, bool) {
+	if l.ip == nil {
+		return Limit{}, false
+	}
+	return *l.ip, true
+}
+
+// TakeIP takes a token for a client IP from its bucket of the per-IP limit.
+func (l *Limiter) TakeIP(ctx context.Context, ip string, lim Limit) (Result, error) {
+	return l.Take(ctx, ipRoute, "ip:"+ip, lim)
+}
+
+// LimitFor returns the limit of a route and whether it is limited.
+func (l *Limiter) LimitFor(route string) (Limit, bool) {
+	if lim, ok := l.routes[route]; ok {
+		return lim, true
+	}
+	if l.def != nil {
+		return *l.def, true
+	}
+	return Limit{}, false
+}
+
+// Take takes a token for client on route. Each client has a separate
+// bucket per route.
+func (l *Limiter) Take(ctx context.Context, route, client string, lim Limit) (Result, error) {
+	return l.store.Take(ctx, route+"|"+client, lim, l.now())
+}
+
+// NewFromConfig builds an in-process limiter from rate limit settings.
+// It returns nil without error when no limit is configured, which
+// leaves rate limiting disabled.
+func NewFromConfig(cfg *config.Config) (*Limiter, error) {
+	var def *Limit
+	if cfg.RateLimitDefault != "" {
+		l, err := ParseLimit(cfg.RateLimitDefault)
+		if err != nil {
+			return nil, err
+		}
+		def = &l
+	}
+
+	routes, err := ParseRouteLimits(cfg.RateLimitRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	var ip *Limit
+	if cfg.RateLimitIP != "" {
+		l, err := ParseLimit(cfg.RateLimitIP)
+		if err != nil {
+			return nil, err
+		}
+		ip = &l
+	}
+
+	if def == nil && len(routes) == 0 && ip == nil {
+		return nil, nil
+	}
+
+	l := New(NewMemoryStore(bucketIdleTTL, maxBuckets), def, routes)
+	if ip != nil {
+		l.WithIPLimit(*ip)
+	}
+	return l, nil
+}
@@ -0,0 +1,120 @@
+package ratelimit
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Result is the outcome of taking a token from a bucket.
+type Result struct {
+	Allowed    bool
+	Remaining  int           // whole tokens left after this request
+	Reset      time.Duration // time until the bucket is full again
+	RetryAfter time.Duration // time until the next token, zero when allowed
+}
+
+// Store keeps token buckets. The in-process MemoryStore is used by
+// default; a shared store (e.g. Redis) can implement the same interface
+// to limit across instances.
+type Store interface {
+	Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error)
+}
+
+// bucket is the state of one token bucket.
+type bucket struct {
+	key    string
+	tokens float64
+	last   time.Time
+}
+
+// MemoryStore keeps buckets in process memory, least recently used first
+// in line for eviction.
+type MemoryStore struct {
+	mu         sync.Mutex
+	buckets    map[string]*list.Element
+	lru        *list.List // of *bucket, most recently used at the front
+	idleTTL    time.Duration
+	maxBuckets int
+}
+
+// NewMemoryStore creates an in-process store. Buckets untouched for
+// idleTTL are evicted; by then they are full again anyway. At most
+// maxBuckets are kept, evicting the least recently used one beyond that,
+// so clients cycling through keys cannot exhaust memory; zero means no
+// cap.
+func NewMemoryStore(idleTTL time.Duration, maxBuckets int) *MemoryStore {
+	return &MemoryStore{
+		buckets:    map[string]*list.Element{},
+		lru:        list.New(),
+		idleTTL:    idleTTL,
+		maxBuckets: maxBuckets,
+	}
+}
+
+// Take refills the bucket for the elapsed time and takes one token.
+func (s *MemoryStore) Take(_ context.Context, key string, l Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	burst := float64(l.Burst)
+	rate := l.rate()
+
+	var b *bucket
+	if e, ok := s.buckets[key]; ok {
+		s.lru.MoveToFront(e)
+		b = e.Value.(*bucket)
+	} else {
+		// Make room by evicting the least recently used bucket
+		if s.maxBuckets > 0 && len(s.buckets) >= s.maxBuckets {
+			s.remove(s.lru.Back())
+		}
+		b = &bucket{key: key, tokens: burst, last: now}
+		s.buckets[key] = s.lru.PushFront(b)
+	}
+
+	// Refill for the time since the last request
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(burst, b.tokens+elapsed*rate)
+		b.last = now
+	}
+
+	res := Result{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = seconds((burst - b.tokens) / rate)
+
+	return res, nil
+}
+
+// sweep evicts idle buckets, starting with the least recently used.
+func (s *MemoryStore) sweep(now time.Time) {
+	if s.idleTTL <= 0 {
+		return
+	}
+
+	for e := s.lru.Back(); e != nil && now.Sub(e.Value.(*bucket).last) >= s.idleTTL; e = s.lru.Back() {
+		s.remove(e)
+	}
+}
+
+// remove drops the bucket of e.
+func (s *MemoryStore) remove(e *list.Element) {
+	delete(s.buckets, e.Value.(*bucket).key)
+	s.lru.Remove(e)
+}
+
+// seconds converts fractional seconds to a duration.
+func seconds(f float64) time.Duration {
+	return time.Duration(f * float64(time.Second))
+}
@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore_TokenBucket(t *testing.T) {
+	// Arrange
+	s := NewMemoryStore(time.Minute, 0)
+	l := Limit{Requests: 2, Per: time.Second, Burst: 2}
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	// Act
+	first, _ := s.Take(ctx, "k", l, now)
+	second, _ := s.Take(ctx, "k", l, now)
+	third, _ := s.Take(ctx, "k", l, now)
+	refilled, _ := s.Take(ctx, "k", l, now.Add(500*time.Millisecond))
+
+	// Assert
+	if !first.Allowed || first.Remaining != 1 {
+		t.Errorf("first: unexpected %+v", first)
+	}
+	if !second.Allowed || second.Remaining != 0 || second.Reset != time.Second {
+		t.Errorf("second: unexpected %+v", second)
+	}
+	if third.Allowed || third.RetryAfter != 500*time.Millisecond {
+		t.Errorf("third: expected rejection with 500ms retry, got %+v", third)
+	}
+	if !refilled.Allowed {
+		t.Errorf("refilled: expected a new token after 500ms, got %+v", refilled)
+	}
+}
+
+func TestMemoryStore_KeysAreIndependent(t *testing.T) {
+	// Arrange
+	s := NewMemoryStore(time.Minute, 0)
+	l := Limit{Requests: 1, Per: time.Minute, Burst: 1}
+	now := time.Now()
+
+	// Act
+	_, _ = s.Take(context.Background(), "a", l, now)
+	res, _ := s.Take(context.Background(), "b", l, now)
+
+	// Assert
+	if !res.Allowed {
+		t.Error("expected separate bucket per key")
+	}
+}
+
+func TestMemoryStore_EvictsIdleBuckets(t *testing.T) {
+	// Arrange
+	s := NewMemoryStore(time.Minute, 0)
+	l := Limit{Requests: 1, Per: time.Hour, Burst: 1}
+	now := time.Now()
+	_, _ = s.Take(context.Background(), "idle", l, now)
+
+	// Act
+	_, _ = s.Take(context.Background(), "other", l, now.Add(2*time.Minute))
+
+	// Assert
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("expected idle bucket to be evicted")
+	}
+}
+
+func TestMemoryStore_CapsBuckets(t *testing.T) {
+	// Arrange
+	s := NewMemoryStore(time.Hour, 2)
+	l := Limit{Requests: 1, Per: time.Hour, Burst: 1}
+	now := time.Now()
+	_, _ = s.Take(context.Background(), "a", l, now)
+	_, _ = s.Take(context.Background(), "b", l, now)
+	_, _ = s.Take(context.Background(), "a", l, now) // a is used more recently
+
+	// Act
+	_, _ = s.Take(context.Background(), "c", l, now)
+
+	// Assert
+	if len(s.buckets) != 2 {
+		t.Errorf("expected 2 buckets, got %d", len(s.buckets))
+	}
+	if _, ok := s.buckets["b"]; ok {
+		t.Error("expected the least recently used bucket to be evicted")
+	}
+	if _, ok := s.buckets["a"]; !ok {
+		t.Error("expected the recently used bucket to be kept")
+	}
+}
@@ -0,0 +1,145 @@
+// Package server runs the HTTP server and shuts it down gracefully.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Config holds HTTP server timeouts.
+type Config struct {
+	Addr              string
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+
+	// DrainDelay keeps serving after a shutdown signal while load
+	// balancers notice the failing readiness probe
+	DrainDelay time.Duration
+
+	// ShutdownTimeout bounds draining in-flight requests and shutdown hooks
+	ShutdownTimeout time.Duration
+}
+
+// Server is an HTTP server with ordered shutdown hooks.
+type Server struct {
+	http            *http.Server
+	drainDelay      time.Duration
+	shutdownTimeout time.Duration
+	draining        []func()
+	hooks           []hook
+}
+
+// hook is a named shutdown step.
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// New creates a server for handler.
+func New(cfg Config, handler http.Handler) *Server {
+	return &Server{
+		http: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+		drainDelay:      cfg.DrainDelay,
+		shutdownTimeout: cfg.ShutdownTimeout,
+	}
+}
+
+// OnDrain registers a function called as soon as shutdown starts, before
+// the drain delay, e.g. to fail readiness checks.
+func (s *Server) OnDrain(fn func()) {
+	s.draining = append(s.draining, fn)
+}
+
+// OnShutdown registers a step that runs after in-flight requests are
+// drained. Steps run in registration order, so background workers are
+// registered before the resources they use, e.g. the database pool.
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.hooks = append(s.hooks, hook{name: name, fn: fn})
+}
+
+// Run listens on the configured address and serves until ctx is done.
+func (s *Server) Run(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	return s.Serve(ctx, ln)
+}
+
+// Serve serves on ln until ctx is done, then runs drain callbacks, waits
+// the drain delay, stops accepting connections, waits for in-flight
+// requests and runs the shutdown hooks, the last two within the shutdown
+// timeout.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.http.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		// Server failed before shutdown was requested
+		return errors.Join(err, s.runHooks(context.Background()))
+	case <-ctx.Done():
+	}
+
+	for _, fn := range s.draining {
+		fn()
+	}
+
+	// Keep serving until load balancers stop routing to this instance
+	if s.drainDelay > 0 {
+		slog.Info("Shutdown: waiting before closing listeners", "drain_delay", s.drainDelay.String())
+		time.Sleep(s.drainDelay)
+	}
+
+	slog.Info("Shutdown: draining in-flight requests")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	shutdownErr := s.http.Shutdown(shutdownCtx)
+	if shutdownErr != nil {
+		// Deadline hit, cut remaining connections
+		slog.Warn("Shutdown: closing open connections", "error", shutdownErr)
+		_ = s.http.Close()
+	}
+
+	// Serve returns ErrServerClosed once Shutdown was called
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		shutdownErr = errors.Join(shutdownErr, err)
+	}
+
+	if err := s.runHooks(shutdownCtx); err != nil {
+		shutdownErr = errors.Join(shutdownErr, err)
+	}
+
+	slog.Info("Shutdown: complete")
+	return shutdownErr
+}
+
+// runHooks runs shutdown hooks in order and collects their errors.
+func (s *Server) runHooks(ctx context.Context) error {
+	var errs []error
+	for _, h := range s.hooks {
+		if err := h.fn(ctx); err != nil {
+			slog.Error("Shutdown: hook failed", "hook", h.name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
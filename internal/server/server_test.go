@@ -0,0 +1,189 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// startServer serves handler on a random port and returns its URL,
+// the shutdown trigger and the channel receiving Serve's result.
+func startServer(t *testing.T, s *Server) (string, context.CancelFunc, chan error) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Serve(ctx, ln) }()
+
+	return "http://" + ln.Addr().String(), cancel, done
+}
+
+// blockingHandler signals when a request arrives and answers once released.
+func blockingHandler(started chan<- struct{}, release <-chan struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		_, _ = io.WriteString(w, "done")
+	})
+}
+
+// ====================================
+// Graceful shutdown
+// ====================================
+
+func TestServe_DrainsInFlightRequests(t *testing.T) {
+	// Arrange
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	cfg := Config{ShutdownTimeout: 5 * time.Second}
+	s := New(cfg, blockingHandler(started, release))
+
+	var order []string
+	var mu sync.Mutex
+	s.OnShutdown("worker", func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, "worker")
+		return nil
+	})
+	s.OnShutdown("pool", func(context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, "pool")
+		return nil
+	})
+
+	url, shutdown, done := startServer(t, s)
+
+	type result struct {
+		body string
+		err  error
+	}
+	resCh := make(chan result, 1)
+	go func() {
+		resp, err := http.Get(url)
+		if err != nil {
+			resCh <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		resCh <- result{body: string(body), err: err}
+	}()
+	<-started
+
+	// Act
+	shutdown()
+
+	// Assert: shutdown waits for the in-flight request
+	select {
+	case err := <-done:
+		t.Fatalf("server stopped with request in flight: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// New connections are refused while draining
+	if _, err := http.Get(url); err == nil {
+		t.Error("expected new requests to be refused during shutdown")
+	}
+
+	close(release)
+
+	res := <-resCh
+	if res.err != nil || res.body != "done" {
+		t.Errorf("expected in-flight request to complete, got %q (%v)", res.body, res.err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(order) != 2 || order[0] != "worker" || order[1] != "pool" {
+		t.Errorf("expected hooks in registration order, got %v", order)
+	}
+}
+
+func TestServe_ShutdownDeadline(t *testing.T) {
+	// Arrange
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	defer close(release)
+
+	cfg := Config{ShutdownTimeout: 100 * time.Millisecond}
+	s := New(cfg, blockingHandler(started, release))
+
+	hookRan := false
+	s.OnShutdown("pool", func(context.Context) error {
+		hookRan = true
+		return nil
+	})
+
+	url, shutdown, done := startServer(t, s)
+	go func() {
+		if resp, err := http.Get(url); err == nil {
+			resp.Body.Close()
+		}
+	}()
+	<-started
+
+	// Act
+	shutdown()
+
+	// Assert
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected deadline exceeded, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown did not respect its deadline")
+	}
+	if !hookRan {
+		t.Error("expected shutdown hooks to run after the deadline")
+	}
+}
+
+func TestServe_DrainDelayKeepsServing(t *testing.T) {
+	// Arrange
+	drained := make(chan struct{})
+	cfg := Config{DrainDelay: 300 * time.Millisecond, ShutdownTimeout: time.Second}
+	s := New(cfg, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-drained:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	s.OnDrain(func() { close(drained) })
+
+	url, shutdown, done := startServer(t, s)
+
+	// Act
+	shutdown()
+	<-drained
+	resp, err := http.Get(url)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("expected requests to be served during drain delay: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("expected drain callback to run first, got %d", resp.StatusCode)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("unexpected shutdown error: %v", err)
+	}
+}
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// TotalQuery selects the subscriptions and period to aggregate.
+type TotalQuery struct {
+	StartDate   string     // MM-YYYY, required
+	EndDate     string     // MM-YYYY, required
+	UserID      string     // optional user UUID
+	ServiceName string     // optional
+	AsOf        *time.Time // read the data as it was at this moment
+}
+
+// MonthlyTotal is the subscription cost of a single month.
+type MonthlyTotal struct {
+	Month time.Time // first day of the month
+	Total int
+}
+
+// TotalResult is the cost of a period broken down by month.
+type TotalResult struct {
+	PeriodStart   time.Time
+	PeriodEnd     time.Time
+	UserID        *uuid.UUID
+	ServiceName   *string
+	Subscriptions int // subscriptions active in the period
+	Total         int
+	Months        []MonthlyTotal
+}
+
+// AggregationService calculates subscription costs.
+type AggregationService struct {
+	store domain.SubscriptionStore
+}
+
+// NewAggregationService creates an aggregation service on store.
+func NewAggregationService(store domain.SubscriptionStore) *AggregationService {
+	return &AggregationService{store: store}
+}
+
+// Period is an inclusive range of months.
+type Period struct {
+	Start time.Time // first month
+	End   time.Time // last month
+}
+
+// ParsePeriod validates a period given as two MM-YYYY values.
+func ParsePeriod(start, end string) (Period, error) {
+	var verr domain.ValidationError
+	p := parsePeriod(start, end, &verr)
+	return p, verr.Err()
+}
+
+// parsePeriod parses a period, adding invalid values to verr.
+func parsePeriod(start, end string, verr *domain.ValidationError) Period {
+	var (
+		p   Period
+		err error
+	)
+
+	p.Start, err = parseRequiredMonthYear(strings.TrimSpace(start))
+	if err != nil {
+		verr.Add("start_date", err.Error())
+	}
+
+	p.End, err = parseRequiredMonthYear(strings.TrimSpace(end))
+	if err != nil {
+		verr.Add("end_date", err.Error())
+	}
+
+	return p
+}
+
+// Total calculates the subscription cost of a period.
+// The sum includes only months when subscriptions were active.
+func (s *AggregationService) Total(ctx context.Context, q TotalQuery) (TotalResult, error) {
+	// Collect all invalid parameters
+	var verr domain.ValidationError
+
+	p := parsePeriod(q.StartDate, q.EndDate, &verr)
+
+	// Optional user filter
+	var userID *uuid.UUID
+	if v := strings.TrimSpace(q.UserID); v != "" {
+		parsedID, err := uuid.Parse(v)
+		if err != nil {
+			verr.Add("user_id", "must be a UUID")
+		} else {
+			userID = &parsedID
+		}
+	}
+
+	if err := verr.Err(); err != nil {
+		return TotalResult{}, err
+	}
+
+	// Scoped callers only aggregate their own subscriptions
+	userID = ScopeUserFilter(ctx, userID)
+
+	// Optional service filter
+	var serviceName *string
+	if v := strings.TrimSpace(q.ServiceName); v != "" {
+		serviceName = &v
+	}
+
+	// Fetch overlapping subscriptions
+	items, err := s.store.ListOverlapping(ctx, userID, serviceName, p.Start, p.End, q.AsOf)
+	if err != nil {
+		return TotalResult{}, err
+	}
+
+	// Break the period down into monthly totals
+	total, months := Breakdown(items, p)
+
+	return TotalResult{
+		PeriodStart:   p.Start,
+		PeriodEnd:     p.End,
+		UserID:        userID,
+		ServiceName:   serviceName,
+		Subscriptions: len(items),
+		Total:         total,
+		Months:        months,
+	}, nil
+}
+
+// Active returns every subscription the caller may see that is active
+// in the period, for callers that group and sum them on their own.
+func (s *AggregationService) Active(ctx context.Context, p Period, asOf *time.Time) ([]domain.Subscription, error) {
+	return s.store.ListOverlapping(ctx, ScopeUserFilter(ctx, nil), nil, p.Start, p.End, asOf)
+}
+
+// Breakdown returns the cost of items in every month of the period and
+// the sum of all months.
+func Breakdown(items []domain.Subscription, p Period) (int, []MonthlyTotal) {
+	months := monthlyTotals(items, p.Start, p.End)
+
+	total := 0
+	for _, m := range months {
+		total += m.Total
+	}
+
+	return total, months
+}
+
+// parseRequiredMonthYear parses a mandatory MM-YYYY value.
+func parseRequiredMonthYear(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, errors.New("is required")
+	}
+	return utils.ParseMonthYear(v)
+}
+
+// monthlyTotals returns the cost of active subscriptions for every month of the period.
+func monthlyTotals(
+	items []domain.Subscription,
+	periodStart,
+	periodEnd time.Time,
+) []MonthlyTotal {
+
+	n := monthsInclusive(periodStart, periodEnd)
+	if n <= 0 {
+		return nil
+	}
+
+	sums := make([]int, n)
+	for _, s := range items {
+		// Clamp subscription activity to the requested period
+		activeStart := maxTime(s.StartDate, periodStart)
+		activeEnd := periodEnd
+		if s.EndDate != nil {
+			activeEnd = minTime(*s.EndDate, periodEnd)
+		}
+
+		// Skip subscriptions not active during the requested period
+		if activeEnd.Before(activeStart) {
+			continue
+		}
+
+		first := monthsInclusive(periodStart, activeStart) - 1
+		last := monthsInclusive(periodStart, activeEnd) - 1
+		for i := first; i <= last; i++ {
+			sums[i] += s.Price
+		}
+	}
+
+	out := make([]MonthlyTotal, n)
+	for i := range sums {
+		out[i] = MonthlyTotal{
+			Month: periodStart.AddDate(0, i, 0),
+			Total: sums[i],
+		}
+	}
+
+	return out
+}
+
+// monthsInclusive returns number of full months between two dates, inclusive.
+func monthsInclusive(start, end time.Time) int {
+	return (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+}
+
+// maxTime returns the later of two time values.
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// minTime returns the earlier of two time values.
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -1,8 +1,10 @@
-package handlers
+package service
 
 import (
 	"testing"
 	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
 )
 
 // ====================================
@@ -151,3 +153,53 @@ func TestMinTime_Equal(t *testing.T) {
 		t.Errorf("expected %v, got %v", b, result)
 	}
 }
+
+// ====================================
+// monthlyTotals
+// ====================================
+
+// TestMonthlyTotals_ClampsToPeriod verifies per-month sums with partial overlap.
+func TestMonthlyTotals_ClampsToPeriod(t *testing.T) {
+	// Arrange
+	periodStart := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	items := []domain.Subscription{
+		{Price: 100, StartDate: time.Date(2024, 11, 1, 0, 0, 0, 0, time.UTC), EndDate: &end},
+		{Price: 50, StartDate: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// Act
+	result := monthlyTotals(items, periodStart, periodEnd)
+
+	// Assert
+	expected := []MonthlyTotal{
+		{Month: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Total: 100},
+		{Month: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Total: 150},
+		{Month: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC), Total: 50},
+		{Month: time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC), Total: 50},
+	}
+	if len(result) != len(expected) {
+		t.Fatalf("expected %d months, got %d", len(expected), len(result))
+	}
+	for i := range expected {
+		if !result[i].Month.Equal(expected[i].Month) || result[i].Total != expected[i].Total {
+			t.Errorf("month %d: expected %+v, got %+v", i, expected[i], result[i])
+		}
+	}
+}
+
+// TestMonthlyTotals_InvertedPeriod verifies that an inverted period yields no months.
+func TestMonthlyTotals_InvertedPeriod(t *testing.T) {
+	// Arrange
+	periodStart := time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	result := monthlyTotals(nil, periodStart, periodEnd)
+
+	// Assert
+	if len(result) != 0 {
+		t.Errorf("expected no months, got %v", result)
+	}
+}
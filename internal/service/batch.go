@@ -0,0 +1,263 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// MaxBatchOperations limits the number of operations in one batch.
+const MaxBatchOperations = 100
+
+// Batch operation kinds.
+const (
+	BatchOpCreate = "create"
+	BatchOpUpdate = "update"
+	BatchOpDelete = "delete"
+)
+
+// Batch execution modes.
+const (
+	BatchModeAllOrNothing = "all_or_nothing"
+	BatchModeBestEffort   = "best_effort"
+)
+
+// Outcomes of operations that did not run to completion because of
+// another operation of the batch.
+var (
+	ErrNotExecuted = errors.New("not executed")
+	ErrRolledBack  = errors.New("rolled back")
+)
+
+// errBatchAborted stops an all-or-nothing batch after the first failure.
+var errBatchAborted = errors.New("batch aborted")
+
+// BatchOperation is a single create, update or delete operation.
+type BatchOperation struct {
+	Op           string
+	ID           string // required for update and delete
+	Subscription *SubscriptionInput
+}
+
+// BatchItemResult is the outcome of one batch operation.
+// Err is nil for operations that were applied and committed.
+type BatchItemResult struct {
+	Op           string
+	ID           uuid.UUID // zero for operations rejected by validation
+	Subscription *domain.Subscription
+	Err          error
+}
+
+// BatchResult is the outcome of a batch.
+type BatchResult struct {
+	Mode      string
+	Committed bool
+	// Rejected is set when an all-or-nothing batch was not run
+	// because some operations are invalid
+	Rejected bool
+	Items    []BatchItemResult
+}
+
+// batchItem is a validated batch operation ready for execution.
+type batchItem struct {
+	op  string
+	id  uuid.UUID
+	sub domain.Subscription
+}
+
+// Batch applies several operations in one transaction.
+//
+// In all_or_nothing mode (the default) the first failure rolls back
+// every operation. In best_effort mode each operation runs in its own
+// savepoint, so failed operations are skipped and the rest are committed.
+func (s *SubscriptionService) Batch(
+	ctx context.Context,
+	mode string,
+	ops []BatchOperation,
+) (BatchResult, error) {
+
+	mode = strings.TrimSpace(mode)
+	if mode == "" {
+		mode = BatchModeAllOrNothing
+	}
+	if mode != BatchModeAllOrNothing && mode != BatchModeBestEffort {
+		return BatchResult{}, domain.Invalid("mode", "must be all_or_nothing or best_effort")
+	}
+
+	if len(ops) == 0 {
+		return BatchResult{}, domain.Invalid("operations", "is required")
+	}
+	if len(ops) > MaxBatchOperations {
+		return BatchResult{}, domain.Invalid("operations",
+			fmt.Sprintf("at most %d operations allowed", MaxBatchOperations))
+	}
+
+	items, results := prepareBatch(ctx, ops)
+	res := BatchResult{Mode: mode, Items: results}
+
+	// All-or-nothing batches with invalid items are not executed
+	if mode == BatchModeAllOrNothing {
+		for _, r := range results {
+			if r.Err != nil {
+				res.Rejected = true
+				markPending(results, fmt.Errorf("%w: batch has invalid operations", ErrNotExecuted))
+				return res, nil
+			}
+		}
+	}
+
+	done := make([]bool, len(items))
+	err := s.store.InTx(ctx, func(tx domain.SubscriptionStore) error {
+		for i, item := range items {
+			// Skip operations rejected by validation
+			if results[i].Err != nil {
+				continue
+			}
+
+			if mode == BatchModeAllOrNothing {
+				if err := applyBatchItem(ctx, tx, item, &results[i]); err != nil {
+					results[i].Err = err
+					return errBatchAborted
+				}
+				done[i] = true
+				continue
+			}
+
+			// Isolate each best-effort operation in a savepoint
+			if err := tx.InTx(ctx, func(sp domain.SubscriptionStore) error {
+				return applyBatchItem(ctx, sp, item, &results[i])
+			}); err != nil {
+				results[i].Err = err
+				results[i].Subscription = nil
+				continue
+			}
+			done[i] = true
+		}
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		res.Committed = true
+	case errors.Is(err, errBatchAborted):
+		// Operations applied before the failure were rolled back
+		for i := range results {
+			if done[i] {
+				results[i].Err, results[i].Subscription = ErrRolledBack, nil
+			}
+		}
+		markPending(results, fmt.Errorf("%w: batch aborted", ErrNotExecuted))
+	default:
+		return BatchResult{}, err
+	}
+
+	return res, nil
+}
+
+// prepareBatch validates all operations up front.
+func prepareBatch(ctx context.Context, ops []BatchOperation) ([]batchItem, []BatchItemResult) {
+	items := make([]batchItem, len(ops))
+	results := make([]BatchItemResult, len(ops))
+
+	for i, op := range ops {
+		kind := strings.ToLower(strings.TrimSpace(op.Op))
+		results[i] = BatchItemResult{Op: kind}
+
+		item, err := parseBatchOperation(ctx, kind, op)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		items[i] = item
+		results[i].ID = item.id
+	}
+
+	return items, results
+}
+
+// parseBatchOperation validates one operation and builds its batch item.
+func parseBatchOperation(ctx context.Context, kind string, op BatchOperation) (batchItem, error) {
+	switch kind {
+	case BatchOpCreate:
+		if op.Subscription == nil {
+			return batchItem{}, domain.Invalid("subscription", "is required")
+		}
+		sub, err := ParseSubscription(scopeInput(ctx, *op.Subscription), uuid.New())
+		if err != nil {
+			return batchItem{}, err
+		}
+		return batchItem{op: kind, id: sub.ID, sub: sub}, nil
+
+	case BatchOpUpdate, BatchOpDelete:
+		id, err := uuid.Parse(strings.TrimSpace(op.ID))
+		if err != nil {
+			return batchItem{}, domain.Invalid("id", "must be a UUID")
+		}
+		if kind == BatchOpDelete {
+			return batchItem{op: kind, id: id}, nil
+		}
+		if op.Subscription == nil {
+			return batchItem{}, domain.Invalid("subscription", "is required")
+		}
+		sub, err := ParseSubscription(scopeInput(ctx, *op.Subscription), id)
+		if err != nil {
+			return batchItem{}, err
+		}
+		return batchItem{op: kind, id: id, sub: sub}, nil
+
+	default:
+		return batchItem{}, domain.Invalid("op", fmt.Sprintf("unknown op %q", op.Op))
+	}
+}
+
+// applyBatchItem executes one operation and fills its result on success.
+func applyBatchItem(
+	ctx context.Context,
+	store domain.SubscriptionStore,
+	item batchItem,
+	res *BatchItemResult,
+) error {
+
+	switch item.op {
+	case BatchOpCreate:
+		out, err := store.Create(ctx, item.sub)
+		if err != nil {
+			return err
+		}
+		res.Subscription = &out
+
+	case BatchOpUpdate:
+		if err := ensureOwner(ctx, store, item.id); err != nil {
+			return err
+		}
+		out, err := store.Update(ctx, item.sub)
+		if err != nil {
+			return err
+		}
+		res.Subscription = &out
+
+	case BatchOpDelete:
+		if err := ensureOwner(ctx, store, item.id); err != nil {
+			return err
+		}
+		if err := store.Delete(ctx, item.id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// markPending records err for operations that never ran.
+func markPending(results []BatchItemResult, err error) {
+	for i := range results {
+		if results[i].Err == nil {
+			results[i].Err = err
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// ==============================================================
+// ==============================================================
+// prepareBatch
+// ==============================================================
+// ==============================================================
+func TestPrepareBatch_ValidOperations(t *testing.T) {
+	// Arrange
+	id := uuid.New()
+	sub := &SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       intPtr(500),
+		UserID:      uuid.New().String(),
+		StartDate:   "07-2025",
+	}
+	ops := []BatchOperation{
+		{Op: "create", Subscription: sub},
+		{Op: "UPDATE", ID: id.String(), Subscription: sub},
+		{Op: "delete", ID: id.String()},
+	}
+
+	// Act
+	items, results := prepareBatch(context.Background(), ops)
+
+	// Assert
+	for i, res := range results {
+		if res.Err != nil {
+			t.Errorf("op %d: expected no error, got %v", i, res.Err)
+		}
+	}
+	if items[1].op != BatchOpUpdate || items[1].sub.ID != id {
+		t.Errorf("expected update of %v, got %+v", id, items[1])
+	}
+	if items[2].id != id || results[2].ID != id {
+		t.Errorf("expected delete of %v, got %v", id, items[2].id)
+	}
+}
+
+func TestPrepareBatch_InvalidOperations(t *testing.T) {
+	// Arrange
+	ops := []BatchOperation{
+		{Op: "create"},
+		{Op: "update", ID: "not-a-uuid"},
+		{Op: "delete"},
+		{Op: "upsert"},
+		{Op: "create", Subscription: &SubscriptionInput{ServiceName: "Netflix", Price: intPtr(-1)}},
+	}
+
+	// Act
+	_, results := prepareBatch(context.Background(), ops)
+
+	// Assert
+	for i, res := range results {
+		if res.Err == nil {
+			t.Errorf("op %d: expected validation error", i)
+		}
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// markPending
+// ==============================================================
+// ==============================================================
+func TestMarkPending_KeepsExistingErrors(t *testing.T) {
+	// Arrange
+	results := []BatchItemResult{
+		{Err: ErrRolledBack},
+		{},
+	}
+
+	// Act
+	markPending(results, ErrNotExecuted)
+
+	// Assert
+	if results[0].Err != ErrRolledBack {
+		t.Errorf("expected first op to keep %v, got %v", ErrRolledBack, results[0].Err)
+	}
+	if results[1].Err != ErrNotExecuted {
+		t.Errorf("expected pending op to be %v, got %v", ErrNotExecuted, results[1].Err)
+	}
+}
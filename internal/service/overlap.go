@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// endOfTime bounds the lookup period of open-ended subscriptions.
+var endOfTime = time.Date(9999, time.December, 1, 0, 0, 0, 0, time.UTC)
+
+// Overlap is a subscription of a batch the overlap constraint rejects.
+type Overlap struct {
+	Index int // position in the batch
+	// Earlier is the position of the earlier subscription of the batch
+	// it overlaps, -1 when it overlaps a stored one
+	Earlier int
+}
+
+// Overlaps reports the subscriptions of a batch that CreateMany would
+// reject for overlapping a stored subscription or an earlier one of
+// the batch that is not rejected itself, in batch order. Stored
+// subscriptions are read once per user. It reports none when the
+// store allows overlaps.
+//
+// A concurrent write can still make CreateMany fail with
+// domain.ErrConflict.
+func (s *SubscriptionService) Overlaps(ctx context.Context, subs []domain.Subscription) ([]Overlap, error) {
+	if s.allowOverlaps {
+		return nil, nil
+	}
+
+	// Group the batch by user, scoped callers create for themselves
+	byUser := make(map[uuid.UUID][]int)
+	for i, sub := range subs {
+		user := sub.UserID
+		if uid := auth.ScopedUserID(ctx); uid != nil {
+			user = *uid
+		}
+		byUser[user] = append(byUser[user], i)
+	}
+
+	var out []Overlap
+	for user, idx := range byUser {
+		from, to := subs[idx[0]].StartDate, time.Time{}
+		for _, i := range idx {
+			from = minTime(from, subs[i].StartDate)
+			to = maxTime(to, periodEnd(subs[i]))
+		}
+
+		stored, err := s.store.ListOverlapping(ctx, &user, nil, from, to, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var accepted []int
+	next:
+		for _, i := range idx {
+			for _, o := range stored {
+				if overlaps(o, subs[i]) {
+					out = append(out, Overlap{Index: i, Earlier: -1})
+					continue next
+				}
+			}
+			for _, j := range accepted {
+				if overlaps(subs[j], subs[i]) {
+					out = append(out, Overlap{Index: i, Earlier: j})
+					continue next
+				}
+			}
+			accepted = append(accepted, i)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Index < out[j].Index })
+	return out, nil
+}
+
+// overlaps reports whether two subscriptions of the same user to the
+// same service share a month, the rule of the overlap constraint.
+// The user is not compared, callers group by it.
+func overlaps(a, b domain.Subscription) bool {
+	return a.ServiceName == b.ServiceName &&
+		!a.StartDate.After(periodEnd(b)) &&
+		!b.StartDate.After(periodEnd(a))
+}
+
+// periodEnd returns the last month of sub, endOfTime when open-ended.
+func periodEnd(sub domain.Subscription) time.Time {
+	if sub.EndDate == nil {
+		return endOfTime
+	}
+	return *sub.EndDate
+}
@@ -0,0 +1,86 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ==============================================================
+// ==============================================================
+// caller scoping
+// ==============================================================
+// ==============================================================
+func TestScopeInput_UserToken(t *testing.T) {
+	// Arrange
+	userID := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: userID})
+	in := SubscriptionInput{UserID: uuid.NewString()}
+
+	// Act
+	in = scopeInput(ctx, in)
+
+	// Assert
+	if in.UserID != userID.String() {
+		t.Errorf("expected user_id %s, got %s", userID, in.UserID)
+	}
+}
+
+func TestScopeInput_AdminAndAnonymousKeepRequest(t *testing.T) {
+	// Arrange
+	requested := uuid.NewString()
+	contexts := []context.Context{
+		context.Background(),
+		auth.WithPrincipal(context.Background(), auth.Principal{Admin: true}),
+	}
+
+	for _, ctx := range contexts {
+		in := SubscriptionInput{UserID: requested}
+
+		// Act
+		in = scopeInput(ctx, in)
+
+		// Assert
+		if in.UserID != requested {
+			t.Errorf("expected user_id %s, got %s", requested, in.UserID)
+		}
+	}
+}
+
+func TestScopeUserFilter_OverridesFilter(t *testing.T) {
+	// Arrange
+	userID := uuid.New()
+	other := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: userID})
+
+	// Act
+	result := ScopeUserFilter(ctx, &other)
+
+	// Assert
+	if result == nil || *result != userID {
+		t.Errorf("expected filter %v, got %v", userID, result)
+	}
+}
+
+func TestOwnedByCaller(t *testing.T) {
+	// Arrange
+	userID := uuid.New()
+	ctx := auth.WithPrincipal(context.Background(), auth.Principal{UserID: userID})
+	own := domain.Subscription{UserID: userID, StartDate: time.Now()}
+	foreign := domain.Subscription{UserID: uuid.New(), StartDate: time.Now()}
+
+	// Act & Assert
+	if !ownedByCaller(ctx, own) {
+		t.Error("expected own subscription to be visible")
+	}
+	if ownedByCaller(ctx, foreign) {
+		t.Error("expected foreign subscription to be hidden")
+	}
+	if !ownedByCaller(context.Background(), foreign) {
+		t.Error("expected anonymous caller to see every subscription")
+	}
+}
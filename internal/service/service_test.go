@@ -0,0 +1,432 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/memory"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// newServices creates both services on a fresh in-memory store.
+func newServices(t *testing.T) (*SubscriptionService, *AggregationService, context.Context) {
+	t.Helper()
+
+	store := memory.NewSubscriptionStore()
+	ctx := tenant.WithID(context.Background(), "acme")
+
+	return NewSubscriptionService(store), NewAggregationService(store), ctx
+}
+
+// intPtr returns a pointer to n.
+func intPtr(n int) *int { return &n }
+
+// netflix returns a valid subscription input for userID.
+func netflix(userID uuid.UUID) SubscriptionInput {
+	return SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       intPtr(500),
+		UserID:      userID.String(),
+		StartDate:   "01-2025",
+		EndDate:     "03-2025",
+	}
+}
+
+// asUser returns ctx of a caller limited to userID's data.
+func asUser(ctx context.Context, userID uuid.UUID) context.Context {
+	return auth.WithPrincipal(ctx, auth.Principal{Subject: userID.String(), UserID: userID})
+}
+
+// ==============================================================
+// ==============================================================
+// SubscriptionService
+// ==============================================================
+// ==============================================================
+func TestSubscriptionService_CreateValidates(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+
+	// Act
+	_, err := svc.Create(ctx, SubscriptionInput{Price: intPtr(-1)})
+
+	// Assert
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if len(verr.Fields) != 4 {
+		t.Errorf("expected 4 invalid fields, got %+v", verr.Fields)
+	}
+}
+
+func TestSubscriptionService_ScopedCallerCreatesForThemselves(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	owner := uuid.New()
+
+	// Act
+	sub, err := svc.Create(asUser(ctx, owner), netflix(uuid.New()))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if sub.UserID != owner {
+		t.Errorf("expected user %v, got %v", owner, sub.UserID)
+	}
+}
+
+func TestSubscriptionService_ForeignSubscriptionsLookMissing(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	sub, err := svc.Create(ctx, netflix(uuid.New()))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	stranger := asUser(ctx, uuid.New())
+
+	// Act
+	_, getErr := svc.Get(stranger, sub.ID, nil)
+	_, updErr := svc.Update(stranger, sub.ID, netflix(uuid.New()))
+	delErr := svc.Delete(stranger, sub.ID)
+	list, listErr := svc.List(stranger, domain.ListFilter{})
+
+	// Assert
+	for name, err := range map[string]error{"get": getErr, "update": updErr, "delete": delErr} {
+		if !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("%s: expected not found, got %v", name, err)
+		}
+	}
+	if listErr != nil || len(list) != 0 {
+		t.Errorf("expected empty list, got %d items (%v)", len(list), listErr)
+	}
+	if _, err := svc.Get(ctx, sub.ID, nil); err != nil {
+		t.Errorf("expected subscription to survive, got %v", err)
+	}
+}
+
+func TestSubscriptionService_UpdateAndDelete(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	owner := uuid.New()
+	sub, err := svc.Create(ctx, netflix(owner))
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	in := netflix(owner)
+	in.Price = intPtr(700)
+
+	// Act
+	updated, err := svc.Update(asUser(ctx, owner), sub.ID, in)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	delErr := svc.Delete(asUser(ctx, owner), sub.ID)
+
+	// Assert
+	if updated.Price != 700 {
+		t.Errorf("expected price 700, got %d", updated.Price)
+	}
+	if delErr != nil {
+		t.Fatalf("delete: %v", delErr)
+	}
+	if _, err := svc.Get(ctx, sub.ID, nil); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("expected not found after delete, got %v", err)
+	}
+}
+
+func TestSubscriptionService_ListPage(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	for range 5 {
+		if _, err := svc.Create(ctx, netflix(uuid.New())); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	// Act
+	first, err := svc.ListPage(ctx, domain.ListFilter{}, 2)
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+
+	// A subscription created meanwhile does not shift the following pages
+	if _, err := svc.Create(ctx, netflix(uuid.New())); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	second, err := svc.ListPage(ctx, domain.ListFilter{After: first.Next}, 2)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	last, err := svc.ListPage(ctx, domain.ListFilter{After: second.Next}, 2)
+	if err != nil {
+		t.Fatalf("last page: %v", err)
+	}
+	_, invalidErr := svc.ListPage(ctx, domain.ListFilter{}, 0)
+
+	// Assert
+	if len(first.Items) != 2 || first.Next == nil || first.Next.ID != first.Items[1].ID {
+		t.Errorf("expected 2 items and a cursor at the second, got %d items and %+v", len(first.Items), first.Next)
+	}
+	if len(second.Items) != 2 || second.Items[0].ID == first.Items[1].ID {
+		t.Errorf("expected the page after the first, got %+v", second.Items)
+	}
+	if len(last.Items) != 1 || last.Next != nil {
+		t.Errorf("expected 1 item on the last page, got %d items and next %+v", len(last.Items), last.Next)
+	}
+	if !errors.Is(invalidErr, domain.ErrInvalidInput) {
+		t.Errorf("expected invalid input, got %v", invalidErr)
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// SubscriptionService.Batch
+// ==============================================================
+// ==============================================================
+func TestSubscriptionService_Overlaps(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	userID := uuid.New()
+	if _, err := svc.Create(ctx, netflix(userID)); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	month := func(m time.Month) time.Time { return time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC) }
+	end := month(6)
+	batch := []domain.Subscription{
+		{ID: uuid.New(), ServiceName: "Netflix", UserID: userID, StartDate: month(3)},
+		{ID: uuid.New(), ServiceName: "Spotify", UserID: userID, StartDate: month(1), EndDate: &end},
+		{ID: uuid.New(), ServiceName: "Spotify", UserID: userID, StartDate: month(6)},
+		{ID: uuid.New(), ServiceName: "Spotify", UserID: uuid.New(), StartDate: month(1)},
+	}
+
+	// Act
+	got, err := svc.Overlaps(ctx, batch)
+	allowed, allowedErr := NewSubscriptionService(svc.store).WithOverlapConstraint(false).Overlaps(ctx, batch)
+
+	// Assert
+	if err != nil || allowedErr != nil {
+		t.Fatalf("unexpected error: %v %v", err, allowedErr)
+	}
+	want := []Overlap{{Index: 0, Earlier: -1}, {Index: 2, Earlier: 1}}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+	if len(allowed) != 0 {
+		t.Errorf("expected no overlaps without the constraint, got %+v", allowed)
+	}
+}
+
+func TestBatch_AllOrNothingRollsBack(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	in := netflix(uuid.New())
+	ops := []BatchOperation{
+		{Op: BatchOpCreate, Subscription: &in},
+		{Op: BatchOpDelete, ID: uuid.NewString()},
+		{Op: BatchOpCreate, Subscription: &in},
+	}
+
+	// Act
+	res, err := svc.Batch(ctx, "", ops)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if res.Committed || res.Mode != BatchModeAllOrNothing {
+		t.Fatalf("expected uncommitted all_or_nothing batch, got %+v", res)
+	}
+	if !errors.Is(res.Items[0].Err, ErrRolledBack) {
+		t.Errorf("op 0: expected rolled back, got %v", res.Items[0].Err)
+	}
+	if !errors.Is(res.Items[1].Err, domain.ErrNotFound) {
+		t.Errorf("op 1: expected not found, got %v", res.Items[1].Err)
+	}
+	if !errors.Is(res.Items[2].Err, ErrNotExecuted) {
+		t.Errorf("op 2: expected not executed, got %v", res.Items[2].Err)
+	}
+	if list, _ := svc.List(ctx, domain.ListFilter{}); len(list) != 0 {
+		t.Errorf("expected no subscriptions, got %d", len(list))
+	}
+}
+
+func TestBatch_AllOrNothingRejectsInvalid(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	in := netflix(uuid.New())
+	ops := []BatchOperation{
+		{Op: BatchOpCreate, Subscription: &in},
+		{Op: "upsert"},
+	}
+
+	// Act
+	res, err := svc.Batch(ctx, BatchModeAllOrNothing, ops)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if !res.Rejected || res.Committed {
+		t.Errorf("expected rejected batch, got %+v", res)
+	}
+	if !errors.Is(res.Items[0].Err, ErrNotExecuted) {
+		t.Errorf("op 0: expected not executed, got %v", res.Items[0].Err)
+	}
+}
+
+func TestBatch_BestEffortSkipsFailures(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	in := netflix(uuid.New())
+	ops := []BatchOperation{
+		{Op: BatchOpCreate, Subscription: &in},
+		{Op: BatchOpCreate, Subscription: &in}, // overlaps the first one
+		{Op: BatchOpDelete, ID: uuid.NewString()},
+	}
+
+	// Act
+	res, err := svc.Batch(ctx, BatchModeBestEffort, ops)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if !res.Committed {
+		t.Fatalf("expected committed batch, got %+v", res)
+	}
+	if res.Items[0].Err != nil || res.Items[0].Subscription == nil {
+		t.Errorf("op 0: expected success, got %+v", res.Items[0])
+	}
+	if !errors.Is(res.Items[1].Err, domain.ErrConflict) {
+		t.Errorf("op 1: expected conflict, got %v", res.Items[1].Err)
+	}
+	if !errors.Is(res.Items[2].Err, domain.ErrNotFound) {
+		t.Errorf("op 2: expected not found, got %v", res.Items[2].Err)
+	}
+	if list, _ := svc.List(ctx, domain.ListFilter{}); len(list) != 1 {
+		t.Errorf("expected 1 subscription, got %d", len(list))
+	}
+}
+
+func TestBatch_InvalidRequest(t *testing.T) {
+	// Arrange
+	svc, _, ctx := newServices(t)
+	tooMany := make([]BatchOperation, MaxBatchOperations+1)
+
+	cases := []struct {
+		mode string
+		ops  []BatchOperation
+	}{
+		{"sometimes", []BatchOperation{{Op: BatchOpDelete}}},
+		{BatchModeBestEffort, nil},
+		{BatchModeBestEffort, tooMany},
+	}
+
+	for i, tc := range cases {
+		// Act
+		_, err := svc.Batch(ctx, tc.mode, tc.ops)
+
+		// Assert
+		if !errors.Is(err, domain.ErrInvalidInput) {
+			t.Errorf("case %d: expected invalid input, got %v", i, err)
+		}
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// AggregationService
+// ==============================================================
+// ==============================================================
+func TestAggregationService_TotalValidates(t *testing.T) {
+	// Arrange
+	_, agg, ctx := newServices(t)
+
+	// Act
+	_, err := agg.Total(ctx, TotalQuery{EndDate: "13-2025", UserID: "nope"})
+
+	// Assert
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	if len(verr.Fields) != 3 {
+		t.Errorf("expected 3 invalid fields, got %+v", verr.Fields)
+	}
+}
+
+func TestAggregationService_Total(t *testing.T) {
+	// Arrange
+	svc, agg, ctx := newServices(t)
+	owner := uuid.New()
+	if _, err := svc.Create(ctx, netflix(owner)); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	other := netflix(uuid.New())
+	other.ServiceName = "Spotify"
+	if _, err := svc.Create(ctx, other); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Act
+	all, err := agg.Total(ctx, TotalQuery{StartDate: "02-2025", EndDate: "12-2025"})
+	if err != nil {
+		t.Fatalf("total: %v", err)
+	}
+	own, err := agg.Total(asUser(ctx, owner), TotalQuery{StartDate: "02-2025", EndDate: "12-2025"})
+	if err != nil {
+		t.Fatalf("scoped total: %v", err)
+	}
+
+	// Assert
+	if all.Total != 2000 || all.Subscriptions != 2 || len(all.Months) != 11 {
+		t.Errorf("expected 2000 from 2 subscriptions over 11 months, got %+v", all)
+	}
+	if own.Total != 1000 || own.UserID == nil || *own.UserID != owner {
+		t.Errorf("expected scoped total 1000 for %v, got %+v", owner, own)
+	}
+}
+
+func TestAggregationService_ActiveAndBreakdown(t *testing.T) {
+	// Arrange
+	svc, agg, ctx := newServices(t)
+	owner := uuid.New()
+	for _, userID := range []uuid.UUID{owner, uuid.New()} {
+		if _, err := svc.Create(ctx, netflix(userID)); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+	p, err := ParsePeriod("03-2025", "04-2025")
+	if err != nil {
+		t.Fatalf("period: %v", err)
+	}
+
+	// Act
+	all, err := agg.Active(ctx, p, nil)
+	if err != nil {
+		t.Fatalf("active: %v", err)
+	}
+	own, err := agg.Active(asUser(ctx, owner), p, nil)
+	if err != nil {
+		t.Fatalf("scoped active: %v", err)
+	}
+	total, months := Breakdown(all, p)
+
+	// Assert
+	if len(all) != 2 || len(own) != 1 || own[0].UserID != owner {
+		t.Errorf("expected 2 active and 1 own subscription, got %d and %d", len(all), len(own))
+	}
+	if total != 1000 || len(months) != 2 || months[1].Total != 0 {
+		t.Errorf("expected 1000 in March only, got %d %+v", total, months)
+	}
+	if _, err := ParsePeriod("", "13-2025"); !errors.Is(err, domain.ErrInvalidInput) {
+		t.Errorf("expected invalid period, got %v", err)
+	}
+}
@@ -0,0 +1,285 @@
+// Package service holds the business rules of subscriptions, independent
+// of the transport that calls them.
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/auth"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/utils"
+	"github.com/google/uuid"
+)
+
+// SubscriptionInput is the unvalidated data of a subscription to write.
+type SubscriptionInput struct {
+	ServiceName string
+	Price       *int // nil when missing
+	UserID      string
+	StartDate   string // MM-YYYY
+	EndDate     string // MM-YYYY, empty for open-ended subscriptions
+}
+
+// ParseSubscription validates input and builds the subscription with id.
+// All invalid fields are reported in one *domain.ValidationError.
+func ParseSubscription(in SubscriptionInput, id uuid.UUID) (domain.Subscription, error) {
+	var verr domain.ValidationError
+
+	serviceName := strings.TrimSpace(in.ServiceName)
+	if serviceName == "" {
+		verr.Add("service_name", "is required")
+	}
+
+	price := 0
+	switch {
+	case in.Price == nil:
+		verr.Add("price", "is required")
+	case *in.Price < 0:
+		verr.Add("price", "must be >= 0")
+	default:
+		price = *in.Price
+	}
+
+	userID, err := uuid.Parse(strings.TrimSpace(in.UserID))
+	if err != nil {
+		verr.Add("user_id", "must be a UUID")
+	}
+
+	start, err := utils.ParseMonthYear(strings.TrimSpace(in.StartDate))
+	if err != nil {
+		verr.Add("start_date", err.Error())
+	}
+
+	// Parse optional end date
+	var endPtr *time.Time
+	if end := strings.TrimSpace(in.EndDate); end != "" {
+		parsedEnd, err := utils.ParseMonthYear(end)
+		switch {
+		case err != nil:
+			verr.Add("end_date", err.Error())
+		case !start.IsZero() && parsedEnd.Before(start):
+			verr.Add("end_date", "must not be before start_date")
+		default:
+			endPtr = &parsedEnd
+		}
+	}
+
+	if err := verr.Err(); err != nil {
+		return domain.Subscription{}, err
+	}
+
+	return domain.Subscription{
+		ID:          id,
+		ServiceName: serviceName,
+		Price:       price,
+		UserID:      userID,
+		StartDate:   start,
+		EndDate:     endPtr,
+	}, nil
+}
+
+// SubscriptionService creates, reads and changes subscriptions.
+// Callers limited to their own data (see auth.ScopedUserID) only see
+// and write their own subscriptions; others look like missing ones.
+type SubscriptionService struct {
+	store domain.SubscriptionStore
+	// allowOverlaps is set when the store keeps no overlap constraint
+	allowOverlaps bool
+}
+
+// NewSubscriptionService creates a subscription service on store.
+func NewSubscriptionService(store domain.SubscriptionStore) *SubscriptionService {
+	return &SubscriptionService{store: store}
+}
+
+// WithOverlapConstraint tells the service whether the store rejects
+// overlapping subscriptions, as DB_OVERLAP_CONSTRAINT configures it.
+// It does by default.
+func (s *SubscriptionService) WithOverlapConstraint(enabled bool) *SubscriptionService {
+	s.allowOverlaps = !enabled
+	return s
+}
+
+// Create validates and stores a new subscription.
+func (s *SubscriptionService) Create(ctx context.Context, in SubscriptionInput) (domain.Subscription, error) {
+	sub, err := ParseSubscription(scopeInput(ctx, in), uuid.New())
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	return s.store.Create(ctx, sub)
+}
+
+// Get returns a subscription, as it was at asOf when set.
+func (s *SubscriptionService) Get(
+	ctx context.Context,
+	id uuid.UUID,
+	asOf *time.Time,
+) (domain.Subscription, error) {
+
+	var (
+		sub domain.Subscription
+		err error
+	)
+	if asOf != nil {
+		sub, err = s.store.GetByIDAsOf(ctx, id, *asOf)
+	} else {
+		sub, err = s.store.GetByID(ctx, id)
+	}
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+	if !ownedByCaller(ctx, sub) {
+		return domain.Subscription{}, domain.ErrNotFound
+	}
+
+	return sub, nil
+}
+
+// Update validates and replaces an existing subscription.
+func (s *SubscriptionService) Update(
+	ctx context.Context,
+	id uuid.UUID,
+	in SubscriptionInput,
+) (domain.Subscription, error) {
+
+	sub, err := ParseSubscription(scopeInput(ctx, in), id)
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	// Check ownership and update in one transaction
+	var out domain.Subscription
+	err = s.store.InTx(ctx, func(tx domain.SubscriptionStore) error {
+		if err := ensureOwner(ctx, tx, id); err != nil {
+			return err
+		}
+		out, err = tx.Update(ctx, sub)
+		return err
+	})
+
+	return out, err
+}
+
+// Delete removes a subscription.
+func (s *SubscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.store.InTx(ctx, func(tx domain.SubscriptionStore) error {
+		if err := ensureOwner(ctx, tx, id); err != nil {
+			return err
+		}
+		return tx.Delete(ctx, id)
+	})
+}
+
+// List returns subscriptions matching the filter, newest first.
+func (s *SubscriptionService) List(ctx context.Context, f domain.ListFilter) ([]domain.Subscription, error) {
+	f.UserID = ScopeUserFilter(ctx, f.UserID)
+	return s.store.List(ctx, f)
+}
+
+// Page is one page of a subscription list.
+type Page struct {
+	Items []domain.Subscription
+	// Next is the position after the last item, nil on the last page
+	Next *domain.Cursor
+}
+
+// errPageFull stops streaming once a page is complete.
+var errPageFull = errors.New("page full")
+
+// ListPage returns up to limit subscriptions List would return, starting
+// after f.After.
+func (s *SubscriptionService) ListPage(
+	ctx context.Context,
+	f domain.ListFilter,
+	limit int,
+) (Page, error) {
+
+	if limit <= 0 {
+		return Page{}, domain.Invalid("limit", "must be > 0")
+	}
+
+	// Read one extra item to learn whether another page follows
+	var page Page
+	err := s.Stream(ctx, f, func(sub domain.Subscription) error {
+		if len(page.Items) == limit {
+			last := page.Items[limit-1]
+			page.Next = &domain.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+			return errPageFull
+		}
+		page.Items = append(page.Items, sub)
+		return nil
+	})
+	if err != nil && !errors.Is(err, errPageFull) {
+		return Page{}, err
+	}
+
+	return page, nil
+}
+
+// Stream passes the subscriptions List would return to fn one by one.
+func (s *SubscriptionService) Stream(
+	ctx context.Context,
+	f domain.ListFilter,
+	fn func(domain.Subscription) error,
+) error {
+
+	f.UserID = ScopeUserFilter(ctx, f.UserID)
+	return s.store.Stream(ctx, f, fn)
+}
+
+// CreateMany stores already validated subscriptions, all or none.
+// Scoped callers always create them for themselves.
+func (s *SubscriptionService) CreateMany(ctx context.Context, subs []domain.Subscription) (int64, error) {
+	if uid := auth.ScopedUserID(ctx); uid != nil {
+		for i := range subs {
+			subs[i].UserID = *uid
+		}
+	}
+
+	return s.store.CreateMany(ctx, subs)
+}
+
+// scopeInput replaces the requested user with the caller's own user
+// when the caller is limited to their own data.
+func scopeInput(ctx context.Context, in SubscriptionInput) SubscriptionInput {
+	if uid := auth.ScopedUserID(ctx); uid != nil {
+		in.UserID = uid.String()
+	}
+	return in
+}
+
+// ScopeUserFilter limits an optional user filter to the caller's own user.
+func ScopeUserFilter(ctx context.Context, userID *uuid.UUID) *uuid.UUID {
+	if uid := auth.ScopedUserID(ctx); uid != nil {
+		return uid
+	}
+	return userID
+}
+
+// ensureOwner returns domain.ErrNotFound when a scoped caller accesses
+// another user's subscription, so foreign IDs look like missing ones.
+func ensureOwner(ctx context.Context, store domain.SubscriptionStore, id uuid.UUID) error {
+	if auth.ScopedUserID(ctx) == nil {
+		return nil
+	}
+
+	sub, err := store.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ownedByCaller(ctx, sub) {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// ownedByCaller reports whether a scoped caller may see the subscription.
+func ownedByCaller(ctx context.Context, sub domain.Subscription) bool {
+	uid := auth.ScopedUserID(ctx)
+	return uid == nil || sub.UserID == *uid
+}
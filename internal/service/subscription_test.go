@@ -0,0 +1,215 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ==============================================================
+// ==============================================================
+// ParseSubscription
+// ==============================================================
+// ==============================================================
+func TestParseSubscription_ValidWithoutEndDate(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       intPtr(500),
+		UserID:      uuid.New().String(),
+		StartDate:   "07-2025",
+		EndDate:     "",
+	}
+	id := uuid.New()
+
+	// Act
+	sub, err := ParseSubscription(req, id)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sub.ID != id {
+		t.Errorf("expected id %v, got %v", id, sub.ID)
+	}
+	if sub.ServiceName != "Netflix" {
+		t.Errorf("expected service_name Netflix, got %s", sub.ServiceName)
+	}
+	if sub.Price != 500 {
+		t.Errorf("expected price 500, got %d", sub.Price)
+	}
+	if sub.EndDate != nil {
+		t.Errorf("expected end_date to be nil")
+	}
+}
+
+func TestParseSubscription_ValidWithEndDate(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: "Spotify",
+		Price:       intPtr(300),
+		UserID:      uuid.New().String(),
+		StartDate:   "01-2025",
+		EndDate:     "03-2025",
+	}
+	id := uuid.New()
+
+	// Act
+	sub, err := ParseSubscription(req, id)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if sub.EndDate == nil {
+		t.Fatalf("expected end_date to be set")
+	}
+
+	expectedEnd := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	if !sub.EndDate.Equal(expectedEnd) {
+		t.Errorf("expected end_date %v, got %v", expectedEnd, sub.EndDate)
+	}
+}
+
+func TestParseSubscription_EmptyServiceName(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: "   ",
+		Price:       intPtr(100),
+		UserID:      uuid.New().String(),
+		StartDate:   "07-2025",
+	}
+
+	// Act
+	_, err := ParseSubscription(req, uuid.New())
+
+	// Assert
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseSubscription_NegativePrice(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       intPtr(-10),
+		UserID:      uuid.New().String(),
+		StartDate:   "07-2025",
+	}
+
+	// Act
+	_, err := ParseSubscription(req, uuid.New())
+
+	// Assert
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseSubscription_MissingPrice(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: "Netflix",
+		UserID:      uuid.New().String(),
+		StartDate:   "07-2025",
+	}
+
+	// Act
+	_, err := ParseSubscription(req, uuid.New())
+
+	// Assert
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "price" {
+		t.Errorf("expected a price error, got %v", err)
+	}
+}
+
+func TestParseSubscription_InvalidUserID(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       intPtr(100),
+		UserID:      "not-a-uuid",
+		StartDate:   "07-2025",
+	}
+
+	// Act
+	_, err := ParseSubscription(req, uuid.New())
+
+	// Assert
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseSubscription_InvalidStartDate(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       intPtr(100),
+		UserID:      uuid.New().String(),
+		StartDate:   "2025-07",
+	}
+
+	// Act
+	_, err := ParseSubscription(req, uuid.New())
+
+	// Assert
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseSubscription_EndDateBeforeStartDate(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: "Netflix",
+		Price:       intPtr(100),
+		UserID:      uuid.New().String(),
+		StartDate:   "07-2025",
+		EndDate:     "06-2025",
+	}
+
+	// Act
+	_, err := ParseSubscription(req, uuid.New())
+
+	// Assert
+	if err == nil {
+		t.Fatal("expected error, got nil")
+	}
+}
+
+func TestParseSubscription_ReportsAllInvalidFields(t *testing.T) {
+	// Arrange
+	req := SubscriptionInput{
+		ServiceName: " ",
+		Price:       intPtr(-1),
+		UserID:      "not-a-uuid",
+		StartDate:   "13-2025",
+		EndDate:     "bad",
+	}
+
+	// Act
+	_, err := ParseSubscription(req, uuid.New())
+
+	// Assert
+	var verr *domain.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+	want := []string{"service_name", "price", "user_id", "start_date", "end_date"}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), verr.Fields)
+	}
+	for i, f := range verr.Fields {
+		if f.Field != want[i] || f.Message == "" {
+			t.Errorf("field %d: unexpected %+v", i, f)
+		}
+	}
+}
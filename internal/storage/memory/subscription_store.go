@@ -0,0 +1,475 @@
+// Package memory provides an in-memory subscription store for tests and
+// local development. It follows the semantics of the PostgreSQL store,
+// without the audit log.
+package memory
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// row is a current subscription with the time its version became valid.
+type row struct {
+	sub       domain.Subscription
+	validFrom time.Time
+}
+
+// version is a replaced or deleted subscription version.
+type version struct {
+	sub       domain.Subscription
+	validFrom time.Time
+	validTo   time.Time
+}
+
+// tenantData holds the subscriptions of one tenant.
+type tenantData struct {
+	rows    map[uuid.UUID]row
+	history []version
+}
+
+// state holds the data of all tenants.
+type state map[string]*tenantData
+
+// clone returns a deep copy for a transaction to work on.
+func (s state) clone() state {
+	out := make(state, len(s))
+	for id, td := range s {
+		c := &tenantData{
+			rows:    make(map[uuid.UUID]row, len(td.rows)),
+			history: append([]version(nil), td.history...),
+		}
+		for k, v := range td.rows {
+			c.rows[k] = v
+		}
+		out[id] = c
+	}
+	return out
+}
+
+// SubscriptionStore keeps subscriptions in memory, separated by tenant.
+// It is safe for concurrent use; transactions are serialized.
+type SubscriptionStore struct {
+	mu   *sync.Mutex
+	data *state
+	// inTx is set for the store passed to InTx, which holds mu
+	inTx bool
+	now  func() time.Time
+	// allowOverlaps drops the overlap constraint, see WithOverlapConstraint
+	allowOverlaps bool
+}
+
+// SubscriptionStore implements the store interface of the handlers.
+var _ domain.SubscriptionStore = (*SubscriptionStore)(nil)
+
+// NewSubscriptionStore creates an empty store.
+func NewSubscriptionStore() *SubscriptionStore {
+	data := make(state)
+	return &SubscriptionStore{mu: &sync.Mutex{}, data: &data, now: time.Now}
+}
+
+// WithOverlapConstraint sets whether overlapping subscriptions of a user
+// to a service are rejected with domain.ErrConflict, like the database
+// with DB_OVERLAP_CONSTRAINT. They are by default.
+func (s *SubscriptionStore) WithOverlapConstraint(enabled bool) *SubscriptionStore {
+	s.allowOverlaps = !enabled
+	return s
+}
+
+// InTx runs fn on a copy of the data and keeps the copy if fn returns nil.
+// Other callers wait until the transaction ends, so fn must only use
+// the store passed to it.
+func (s *SubscriptionStore) InTx(
+	ctx context.Context,
+	fn func(tx domain.SubscriptionStore) error,
+) error {
+
+	if _, err := tenant.FromContext(ctx); err != nil {
+		return err
+	}
+
+	// Savepoints run under the lock of the outer transaction
+	if !s.inTx {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	data := s.data.clone()
+	tx := &SubscriptionStore{
+		mu:            s.mu,
+		data:          &data,
+		inTx:          true,
+		now:           s.now,
+		allowOverlaps: s.allowOverlaps,
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	*s.data = data
+	return nil
+}
+
+// withTenant runs fn on the data of the tenant from ctx.
+func (s *SubscriptionStore) withTenant(ctx context.Context, fn func(td *tenantData) error) error {
+	id, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !s.inTx {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+	}
+
+	td := (*s.data)[id]
+	if td == nil {
+		td = &tenantData{rows: make(map[uuid.UUID]row)}
+		(*s.data)[id] = td
+	}
+
+	return fn(td)
+}
+
+// Create inserts a new subscription.
+func (s *SubscriptionStore) Create(
+	ctx context.Context,
+	sub domain.Subscription,
+) (domain.Subscription, error) {
+
+	err := s.withTenant(ctx, func(td *tenantData) error {
+		if _, ok := td.rows[sub.ID]; ok {
+			return fmt.Errorf("create subscription: duplicate id %s", sub.ID)
+		}
+
+		sub = normalize(sub)
+		if s.overlaps(td.rows, sub) {
+			return domain.ErrConflict
+		}
+
+		now := s.now()
+		sub.CreatedAt, sub.UpdatedAt = now, now
+		td.rows[sub.ID] = row{sub: sub, validFrom: now}
+		return nil
+	})
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	return sub, nil
+}
+
+// CreateMany inserts all subscriptions or none of them.
+func (s *SubscriptionStore) CreateMany(
+	ctx context.Context,
+	subs []domain.Subscription,
+) (int64, error) {
+
+	err := s.withTenant(ctx, func(td *tenantData) error {
+		// Check against stored rows and each other before inserting
+		added := make(map[uuid.UUID]row, len(subs))
+		for _, sub := range subs {
+			sub = normalize(sub)
+			if _, ok := td.rows[sub.ID]; ok {
+				return fmt.Errorf("copy subscriptions: duplicate id %s", sub.ID)
+			}
+			if _, ok := added[sub.ID]; ok {
+				return fmt.Errorf("copy subscriptions: duplicate id %s", sub.ID)
+			}
+			if s.overlaps(td.rows, sub) || s.overlaps(added, sub) {
+				return domain.ErrConflict
+			}
+			added[sub.ID] = row{sub: sub}
+		}
+
+		now := s.now()
+		for id, r := range added {
+			r.sub.CreatedAt, r.sub.UpdatedAt, r.validFrom = now, now, now
+			td.rows[id] = r
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(subs)), nil
+}
+
+// GetByID returns subscription by ID.
+func (s *SubscriptionStore) GetByID(
+	ctx context.Context,
+	id uuid.UUID,
+) (domain.Subscription, error) {
+
+	var out domain.Subscription
+	err := s.withTenant(ctx, func(td *tenantData) error {
+		r, ok := td.rows[id]
+		if !ok {
+			return domain.ErrNotFound
+		}
+		out = r.sub
+		return nil
+	})
+
+	return out, err
+}
+
+// GetByIDAsOf returns subscription by ID as it was at asOf.
+func (s *SubscriptionStore) GetByIDAsOf(
+	ctx context.Context,
+	id uuid.UUID,
+	asOf time.Time,
+) (domain.Subscription, error) {
+
+	var (
+		out   domain.Subscription
+		found bool
+	)
+	err := s.withTenant(ctx, func(td *tenantData) error {
+		for _, sub := range versionsAsOf(td, asOf) {
+			if sub.ID == id {
+				out, found = sub, true
+			}
+		}
+		if !found {
+			return domain.ErrNotFound
+		}
+		return nil
+	})
+
+	return out, err
+}
+
+// Update replaces an existing subscription, keeping the previous version.
+func (s *SubscriptionStore) Update(
+	ctx context.Context,
+	sub domain.Subscription,
+) (domain.Subscription, error) {
+
+	err := s.withTenant(ctx, func(td *tenantData) error {
+		before, ok := td.rows[sub.ID]
+		if !ok {
+			return domain.ErrNotFound
+		}
+
+		sub = normalize(sub)
+		if s.overlaps(td.rows, sub) {
+			return domain.ErrConflict
+		}
+
+		now := s.now()
+		sub.CreatedAt, sub.UpdatedAt = before.sub.CreatedAt, now
+		td.history = append(td.history, version{sub: before.sub, validFrom: before.validFrom, validTo: now})
+		td.rows[sub.ID] = row{sub: sub, validFrom: now}
+		return nil
+	})
+	if err != nil {
+		return domain.Subscription{}, err
+	}
+
+	return sub, nil
+}
+
+// Delete removes subscription by ID, keeping it as a past version.
+func (s *SubscriptionStore) Delete(
+	ctx context.Context,
+	id uuid.UUID,
+) error {
+
+	return s.withTenant(ctx, func(td *tenantData) error {
+		before, ok := td.rows[id]
+		if !ok {
+			return domain.ErrNotFound
+		}
+
+		td.history = append(td.history, version{sub: before.sub, validFrom: before.validFrom, validTo: s.now()})
+		delete(td.rows, id)
+		return nil
+	})
+}
+
+// List returns subscriptions with optional filters, newest first.
+func (s *SubscriptionStore) List(
+	ctx context.Context,
+	f domain.ListFilter,
+) ([]domain.Subscription, error) {
+
+	var out []domain.Subscription
+	err := s.withTenant(ctx, func(td *tenantData) error {
+		for _, sub := range source(td, f.AsOf) {
+			if !matches(sub, f.UserID, f.ServiceName) || !matchesAny(sub, f.UserIDs, f.ServiceNames) {
+				continue
+			}
+			if f.After != nil && !newer(*f.After, sub) {
+				continue
+			}
+			out = append(out, sub)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(out, func(i, j int) bool { return newer(position(out[i]), out[j]) })
+	return out, nil
+}
+
+// ListOverlapping returns subscriptions overlapping period, by start date.
+// With asOf set, rows are read as they were at that moment.
+func (s *SubscriptionStore) ListOverlapping(
+	ctx context.Context,
+	userID *uuid.UUID,
+	serviceName *string,
+	periodStart,
+	periodEnd time.Time,
+	asOf *time.Time,
+) ([]domain.Subscription, error) {
+
+	var out []domain.Subscription
+	err := s.withTenant(ctx, func(td *tenantData) error {
+		for _, sub := range source(td, asOf) {
+			if !matches(sub, userID, serviceName) {
+				continue
+			}
+			if sub.StartDate.After(periodEnd) || (sub.EndDate != nil && sub.EndDate.Before(periodStart)) {
+				continue
+			}
+			out = append(out, sub)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].StartDate.Before(out[j].StartDate) })
+	return out, nil
+}
+
+// Stream passes subscriptions matching the filter to fn one by one.
+// The rows are read up front, so fn may call the store.
+func (s *SubscriptionStore) Stream(
+	ctx context.Context,
+	f domain.ListFilter,
+	fn func(domain.Subscription) error,
+) error {
+
+	items, err := s.List(ctx, f)
+	if err != nil {
+		return err
+	}
+
+	for _, sub := range items {
+		if err := fn(sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// source returns the current rows, or the versions valid at asOf.
+func source(td *tenantData, asOf *time.Time) []domain.Subscription {
+	if asOf != nil {
+		return versionsAsOf(td, *asOf)
+	}
+
+	out := make([]domain.Subscription, 0, len(td.rows))
+	for _, r := range td.rows {
+		out = append(out, r.sub)
+	}
+	return out
+}
+
+// versionsAsOf returns the subscription versions that were current at asOf.
+func versionsAsOf(td *tenantData, asOf time.Time) []domain.Subscription {
+	var out []domain.Subscription
+	for _, r := range td.rows {
+		if !r.validFrom.After(asOf) {
+			out = append(out, r.sub)
+		}
+	}
+	for _, v := range td.history {
+		if !v.validFrom.After(asOf) && v.validTo.After(asOf) {
+			out = append(out, v.sub)
+		}
+	}
+	return out
+}
+
+// matches applies the optional user and service filters.
+func matches(sub domain.Subscription, userID *uuid.UUID, serviceName *string) bool {
+	return (userID == nil || sub.UserID == *userID) &&
+		(serviceName == nil || sub.ServiceName == *serviceName)
+}
+
+// position returns the list cursor of sub.
+func position(sub domain.Subscription) domain.Cursor {
+	return domain.Cursor{CreatedAt: sub.CreatedAt, ID: sub.ID}
+}
+
+// newer reports whether c comes before sub in the newest-first order,
+// by creation time and then by ID like the database.
+func newer(c domain.Cursor, sub domain.Subscription) bool {
+	if !c.CreatedAt.Equal(sub.CreatedAt) {
+		return c.CreatedAt.After(sub.CreatedAt)
+	}
+	return bytes.Compare(c.ID[:], sub.ID[:]) > 0
+}
+
+// matchesAny reports whether sub is of one of the users and one of the
+// services; nil lists match all.
+func matchesAny(sub domain.Subscription, userIDs []uuid.UUID, serviceNames []string) bool {
+	return (userIDs == nil || slices.Contains(userIDs, sub.UserID)) &&
+		(serviceNames == nil || slices.Contains(serviceNames, sub.ServiceName))
+}
+
+// overlaps reports whether sub violates the overlap constraint against
+// rows, never when the store allows overlaps.
+func (s *SubscriptionStore) overlaps(rows map[uuid.UUID]row, sub domain.Subscription) bool {
+	return !s.allowOverlaps && overlapsAny(rows, sub)
+}
+
+// overlapsAny reports whether sub overlaps another subscription of the same
+// user and service, the exclusion constraint of the database.
+func overlapsAny(rows map[uuid.UUID]row, sub domain.Subscription) bool {
+	for id, r := range rows {
+		o := r.sub
+		if id == sub.ID || o.UserID != sub.UserID || o.ServiceName != sub.ServiceName {
+			continue
+		}
+
+		// Inclusive date ranges, open ended without end date
+		if (sub.EndDate == nil || !o.StartDate.After(*sub.EndDate)) &&
+			(o.EndDate == nil || !sub.StartDate.After(*o.EndDate)) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalize truncates the dates to whole days in UTC, as date columns do.
+func normalize(sub domain.Subscription) domain.Subscription {
+	sub.StartDate = dateOf(sub.StartDate)
+	if sub.EndDate != nil {
+		end := dateOf(*sub.EndDate)
+		sub.EndDate = &end
+	}
+	return sub
+}
+
+// dateOf returns midnight UTC of the calendar day of t.
+func dateOf(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
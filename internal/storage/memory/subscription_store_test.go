@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/storetest"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+func TestSubscriptionStore_Conformance(t *testing.T) {
+	storetest.Run(t, storetest.Options{}, func(*testing.T) domain.SubscriptionStore {
+		return NewSubscriptionStore()
+	})
+}
+
+func TestSubscriptionStore_ConformanceWithoutOverlapConstraint(t *testing.T) {
+	storetest.Run(t, storetest.Options{AllowOverlaps: true}, func(*testing.T) domain.SubscriptionStore {
+		return NewSubscriptionStore().WithOverlapConstraint(false)
+	})
+}
+
+func TestSubscriptionStore_ConcurrentWrites(t *testing.T) {
+	// Arrange
+	st := NewSubscriptionStore()
+	ctx := tenant.WithID(context.Background(), "acme")
+	user := uuid.New()
+
+	// Act
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = st.InTx(ctx, func(tx domain.SubscriptionStore) error {
+				_, err := tx.Create(ctx, domain.Subscription{
+					ID:          uuid.New(),
+					ServiceName: "Service",
+					Price:       i,
+					UserID:      user,
+					StartDate:   time.Date(2025, time.Month(i%12+1), 1, 0, 0, 0, 0, time.UTC),
+				})
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+
+	listed, err := st.List(ctx, domain.ListFilter{UserID: &user})
+
+	// Assert
+	// Open-ended rows of one service overlap, so exactly one wins
+	if err != nil || len(listed) != 1 {
+		t.Errorf("expected exactly one row, got %d (%v)", len(listed), err)
+	}
+}
@@ -0,0 +1,204 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// APIKeyRepo provides API key persistence.
+type APIKeyRepo struct {
+	pool *pgxpool.Pool
+}
+
+// NewAPIKeyRepo creates a new repository instance.
+func NewAPIKeyRepo(pool *pgxpool.Pool) *APIKeyRepo {
+	return &APIKeyRepo{pool: pool}
+}
+
+// Create stores a new API key.
+func (r *APIKeyRepo) Create(
+	ctx context.Context,
+	k domain.APIKey,
+) (domain.APIKey, error) {
+
+	const q = `
+		INSERT INTO api_keys (
+			id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			admin,
+			user_id,
+			tenant_id,
+			expires_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING created_at;
+	`
+
+	if err := r.pool.QueryRow(
+		ctx,
+		q,
+		k.ID,
+		k.Name,
+		k.Prefix,
+		k.Hash,
+		k.Scopes,
+		k.Admin,
+		k.UserID,
+		k.TenantID,
+		k.ExpiresAt,
+	).Scan(&k.CreatedAt); err != nil {
+		return domain.APIKey{}, fmt.Errorf("create api key: %w", err)
+	}
+
+	return k, nil
+}
+
+// GetByHash returns the API key with the given hash.
+func (r *APIKeyRepo) GetByHash(
+	ctx context.Context,
+	hash string,
+) (domain.APIKey, error) {
+
+	const q = `
+		SELECT
+			id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			admin,
+			user_id,
+			tenant_id,
+			expires_at,
+			last_used_at,
+			revoked_at,
+			created_at
+		FROM api_keys
+		WHERE key_hash = $1;
+	`
+
+	var k domain.APIKey
+	if err := r.pool.QueryRow(ctx, q, hash).Scan(
+		&k.ID,
+		&k.Name,
+		&k.Prefix,
+		&k.Hash,
+		&k.Scopes,
+		&k.Admin,
+		&k.UserID,
+		&k.TenantID,
+		&k.ExpiresAt,
+		&k.LastUsedAt,
+		&k.RevokedAt,
+		&k.CreatedAt,
+	); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.APIKey{}, ErrNotFound
+		}
+		return domain.APIKey{}, fmt.Errorf("get api key: %w", err)
+	}
+
+	return k, nil
+}
+
+// List returns all API keys, newest first.
+func (r *APIKeyRepo) List(ctx context.Context) ([]domain.APIKey, error) {
+	const q = `
+		SELECT
+			id,
+			name,
+			prefix,
+			key_hash,
+			scopes,
+			admin,
+			user_id,
+			tenant_id,
+			expires_at,
+			last_used_at,
+			revoked_at,
+			created_at
+		FROM api_keys
+		ORDER BY created_at DESC;
+	`
+
+	rows, err := r.pool.Query(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	var out []domain.APIKey
+	for rows.Next() {
+		var k domain.APIKey
+		if err := rows.Scan(
+			&k.ID,
+			&k.Name,
+			&k.Prefix,
+			&k.Hash,
+			&k.Scopes,
+			&k.Admin,
+			&k.UserID,
+			&k.TenantID,
+			&k.ExpiresAt,
+			&k.LastUsedAt,
+			&k.RevokedAt,
+			&k.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("list api keys scan: %w", err)
+		}
+		out = append(out, k)
+	}
+
+	// Check iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list api keys rows: %w", err)
+	}
+
+	return out, nil
+}
+
+// Revoke marks an API key as revoked.
+func (r *APIKeyRepo) Revoke(ctx context.Context, id uuid.UUID) error {
+	const q = `
+		UPDATE api_keys
+		SET revoked_at = now()
+		WHERE id = $1 AND revoked_at IS NULL;
+	`
+
+	tag, err := r.pool.Exec(ctx, q, id)
+	if err != nil {
+		return fmt.Errorf("revoke api key: %w", err)
+	}
+
+	// Unknown or already revoked key
+	if tag.RowsAffected() == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// TouchLastUsed records key usage, at most once per minute per key.
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id uuid.UUID) error {
+	const q = `
+		UPDATE api_keys
+		SET last_used_at = now()
+		WHERE id = $1
+		  AND (last_used_at IS NULL OR last_used_at < now() - interval '1 minute');
+	`
+
+	if _, err := r.pool.Exec(ctx, q, id); err != nil {
+		return fmt.Errorf("touch api key: %w", err)
+	}
+
+	return nil
+}
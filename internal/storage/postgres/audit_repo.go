@@ -0,0 +1,237 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/audit"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// auditSnapshot is the JSON form of a subscription stored in audit entries.
+type auditSnapshot struct {
+	ID          uuid.UUID `json:"id"`
+	ServiceName string    `json:"service_name"`
+	Price       int       `json:"price"`
+	UserID      uuid.UUID `json:"user_id"`
+	StartDate   string    `json:"start_date"` // YYYY-MM-DD
+	EndDate     *string   `json:"end_date"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// snapshot encodes a subscription for the audit log, nil stays nil.
+func snapshot(s *domain.Subscription) ([]byte, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	snap := auditSnapshot{
+		ID:          s.ID,
+		ServiceName: s.ServiceName,
+		Price:       s.Price,
+		UserID:      s.UserID,
+		StartDate:   s.StartDate.Format(time.DateOnly),
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+	}
+	if s.EndDate != nil {
+		end := s.EndDate.Format(time.DateOnly)
+		snap.EndDate = &end
+	}
+
+	return json.Marshal(snap)
+}
+
+// recordAudit writes an audit entry for a change. db must be the
+// transaction of the change, so both are committed together.
+func recordAudit(
+	ctx context.Context,
+	db dbtx,
+	action string,
+	before,
+	after *domain.Subscription,
+) error {
+
+	const q = `
+		INSERT INTO subscription_audit (
+			subscription_id,
+			user_id,
+			action,
+			actor,
+			request_id,
+			before,
+			after
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7);
+	`
+
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+
+	beforeJSON, err := snapshot(before)
+	if err != nil {
+		return fmt.Errorf("audit snapshot: %w", err)
+	}
+	afterJSON, err := snapshot(after)
+	if err != nil {
+		return fmt.Errorf("audit snapshot: %w", err)
+	}
+
+	m := audit.MetaFrom(ctx)
+	if _, err := db.Exec(
+		ctx,
+		q,
+		subject.ID,
+		subject.UserID,
+		action,
+		m.Actor,
+		m.RequestID,
+		beforeJSON,
+		afterJSON,
+	); err != nil {
+		return fmt.Errorf("record audit: %w", err)
+	}
+
+	return nil
+}
+
+// recordAuditCreates writes create entries for bulk-inserted subscriptions.
+func recordAuditCreates(
+	ctx context.Context,
+	db dbtx,
+	subs []domain.Subscription,
+) error {
+
+	m := audit.MetaFrom(ctx)
+
+	columns := []string{
+		"subscription_id",
+		"user_id",
+		"action",
+		"actor",
+		"request_id",
+		"after",
+	}
+
+	// Stream entries into the audit table
+	_, err := db.CopyFrom(
+		ctx,
+		pgx.Identifier{"subscription_audit"},
+		columns,
+		pgx.CopyFromSlice(len(subs), func(i int) ([]any, error) {
+			s := subs[i]
+			after, err := snapshot(&s)
+			if err != nil {
+				return nil, err
+			}
+			return []any{s.ID, s.UserID, audit.ActionCreate, m.Actor, m.RequestID, after}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("copy audit entries: %w", err)
+	}
+
+	return nil
+}
+
+// AuditRepo reads the subscription audit log.
+type AuditRepo struct {
+	db dbtx
+}
+
+// NewAuditRepo creates a new repository instance.
+func NewAuditRepo(pool *pgxpool.Pool) *AuditRepo {
+	return &AuditRepo{db: pool}
+}
+
+// AuditFilter defines optional audit search filters.
+type AuditFilter struct {
+	SubscriptionID *uuid.UUID
+	UserID         *uuid.UUID
+	Actor          *string
+	Action         *string
+	From           *time.Time // inclusive
+	To             *time.Time // exclusive
+	Limit          int
+}
+
+// Search returns audit entries matching the filter, newest first.
+func (r *AuditRepo) Search(
+	ctx context.Context,
+	f AuditFilter,
+) ([]domain.AuditEntry, error) {
+
+	const q = `
+		SELECT
+			id,
+			subscription_id,
+			user_id,
+			action,
+			actor,
+			request_id,
+			before,
+			after,
+			created_at
+		FROM subscription_audit
+		WHERE ($1::uuid IS NULL OR subscription_id = $1)
+		  AND ($2::uuid IS NULL OR user_id = $2)
+		  AND ($3::text IS NULL OR actor = $3)
+		  AND ($4::text IS NULL OR action = $4)
+		  AND ($5::timestamptz IS NULL OR created_at >= $5)
+		  AND ($6::timestamptz IS NULL OR created_at < $6)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $7;
+	`
+
+	var out []domain.AuditEntry
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		// Query matching entries
+		rows, err := db.Query(ctx, q,
+			f.SubscriptionID, f.UserID, f.Actor, f.Action, f.From, f.To, f.Limit)
+		if err != nil {
+			return fmt.Errorf("search audit: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				e             domain.AuditEntry
+				before, after []byte
+			)
+			if err := rows.Scan(
+				&e.ID,
+				&e.SubscriptionID,
+				&e.UserID,
+				&e.Action,
+				&e.Actor,
+				&e.RequestID,
+				&before,
+				&after,
+				&e.CreatedAt,
+			); err != nil {
+				return fmt.Errorf("audit scan: %w", err)
+			}
+			e.Before, e.After = before, after
+			out = append(out, e)
+		}
+
+		// Check iteration errors
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("audit rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
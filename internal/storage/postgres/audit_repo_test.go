@@ -0,0 +1,138 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/audit"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+func TestSnapshot(t *testing.T) {
+	// Arrange
+	end := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	s := domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       499,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &end,
+	}
+
+	// Act
+	raw, err := snapshot(&s)
+	empty, emptyErr := snapshot(nil)
+
+	// Assert
+	if err != nil || emptyErr != nil {
+		t.Fatalf("unexpected error: %v %v", err, emptyErr)
+	}
+	if empty != nil {
+		t.Errorf("expected nil snapshot, got %s", empty)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	if got["service_name"] != "Netflix" || got["price"] != float64(499) {
+		t.Errorf("unexpected snapshot: %s", raw)
+	}
+	if got["start_date"] != "2025-07-01" || got["end_date"] != "2025-12-01" {
+		t.Errorf("unexpected dates: %s", raw)
+	}
+}
+
+func TestAuditRepo_RecordsEveryChange(t *testing.T) {
+	// Arrange
+	pool := testPool(t)
+	repo := NewSubscriptionRepo(pool)
+	audits := NewAuditRepo(pool)
+
+	ctx := tenant.WithID(context.Background(), "test-tenant-a")
+	ctx = audit.WithMeta(ctx, audit.Meta{Actor: "alice", RequestID: "req-1"})
+
+	s, err := repo.Create(ctx, domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Audit " + uuid.NewString()[:8],
+		Price:       100,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Act
+	s.Price = 200
+	if _, err := repo.Update(ctx, s); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := repo.Delete(ctx, s.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	entries, err := audits.Search(ctx, AuditFilter{SubscriptionID: &s.ID, Limit: 10})
+
+	// Assert
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+
+	// Newest first
+	for i, action := range []string{audit.ActionDelete, audit.ActionUpdate, audit.ActionCreate} {
+		e := entries[i]
+		if e.Action != action || e.Actor != "alice" || e.RequestID != "req-1" {
+			t.Errorf("entry %d: unexpected %+v", i, e)
+		}
+	}
+	if entries[2].Before != nil || entries[0].After != nil {
+		t.Error("expected no before for create and no after for delete")
+	}
+}
+
+func TestAuditRepo_BulkCreateRecordsStoredTimestamps(t *testing.T) {
+	// Arrange
+	pool := testPool(t)
+	repo := NewSubscriptionRepo(pool)
+	audits := NewAuditRepo(pool)
+
+	ctx := tenant.WithID(context.Background(), "test-tenant-a")
+	s := domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Audit " + uuid.NewString()[:8],
+		Price:       100,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	if _, err := repo.CreateMany(ctx, []domain.Subscription{s}); err != nil {
+		t.Fatalf("create many: %v", err)
+	}
+	stored, getErr := repo.GetByID(ctx, s.ID)
+	entries, err := audits.Search(ctx, AuditFilter{SubscriptionID: &s.ID, Limit: 10})
+
+	// Assert
+	if getErr != nil || err != nil {
+		t.Fatalf("unexpected error: %v %v", getErr, err)
+	}
+	if len(entries) != 1 || entries[0].Action != audit.ActionCreate {
+		t.Fatalf("expected one create entry, got %+v", entries)
+	}
+
+	var after auditSnapshot
+	if err := json.Unmarshal(entries[0].After, &after); err != nil {
+		t.Fatalf("invalid snapshot: %v", err)
+	}
+	if after.CreatedAt.IsZero() || !after.CreatedAt.Equal(stored.CreatedAt) || !after.UpdatedAt.Equal(stored.UpdatedAt) {
+		t.Errorf("expected stored timestamps %v, got %+v", stored.CreatedAt, after)
+	}
+}
@@ -0,0 +1,24 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/storage/storetest"
+)
+
+// TestSubscriptionRepo_Conformance runs the suite in the overlap mode
+// the test database is migrated with, see DB_OVERLAP_CONSTRAINT.
+func TestSubscriptionRepo_Conformance(t *testing.T) {
+	pool := testPool(t)
+
+	enabled, err := OverlapConstraintEnabled(context.Background(), pool)
+	if err != nil {
+		t.Fatalf("look up overlap constraint: %v", err)
+	}
+
+	storetest.Run(t, storetest.Options{AllowOverlaps: !enabled}, func(t *testing.T) domain.SubscriptionStore {
+		return NewSubscriptionRepo(pool)
+	})
+}
@@ -1,6 +1,23 @@
 package postgres
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/jackc/pgx/v5/pgconn"
+)
 
 // ErrNotFound indicates that a requested entity was not found.
-var ErrNotFound = errors.New("not found")
+var ErrNotFound = domain.ErrNotFound
+
+// ErrConflict indicates that a write violates a uniqueness or overlap rule.
+var ErrConflict = domain.ErrConflict
+
+// exclusionViolation is the Postgres SQLSTATE for EXCLUDE constraint failures.
+const exclusionViolation = "23P01"
+
+// isExclusionViolation reports whether err was raised by an exclusion constraint.
+func isExclusionViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == exclusionViolation
+}
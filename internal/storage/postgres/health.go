@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrSchemaMismatch indicates a database not migrated to SchemaVersion.
+var ErrSchemaMismatch = errors.New("schema version mismatch")
+
+// MigrationStatus describes the applied migration version.
+type MigrationStatus struct {
+	Version  int64 `json:"version"`
+	Expected int64 `json:"expected"`
+	Dirty    bool  `json:"dirty"`
+}
+
+// Check fails when the schema is dirty or older than expected. Newer
+// schemas pass: during a rolling deploy the old instances keep serving
+// while the new release has already migrated, and migrations are written
+// to stay compatible with the previous release.
+func (st MigrationStatus) Check() error {
+	if st.Dirty {
+		return fmt.Errorf("%w: version %d is dirty", ErrSchemaMismatch, st.Version)
+	}
+	if st.Version < st.Expected {
+		return fmt.Errorf("%w: have %d, want %d or newer", ErrSchemaMismatch, st.Version, st.Expected)
+	}
+	return nil
+}
+
+// CheckSchemaVersion reads the version recorded by golang-migrate and
+// fails unless it is at least SchemaVersion and not dirty.
+func CheckSchemaVersion(ctx context.Context, pool *pgxpool.Pool) (MigrationStatus, error) {
+	const q = `
+		SELECT version, dirty
+		FROM schema_migrations
+		LIMIT 1;
+	`
+
+	st := MigrationStatus{Expected: SchemaVersion}
+	if err := pool.QueryRow(ctx, q).Scan(&st.Version, &st.Dirty); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return st, fmt.Errorf("%w: no migrations applied", ErrSchemaMismatch)
+		}
+		return st, fmt.Errorf("read schema version: %w", err)
+	}
+
+	return st, st.Check()
+}
+
+// PoolStats is a snapshot of connection pool usage.
+type PoolStats struct {
+	MaxConns          int32 `json:"max_conns"`
+	TotalConns        int32 `json:"total_conns"`
+	AcquiredConns     int32 `json:"acquired_conns"`
+	IdleConns         int32 `json:"idle_conns"`
+	AcquireCount      int64 `json:"acquire_count"`
+	EmptyAcquireCount int64 `json:"empty_acquire_count"`
+	AcquireDurationMS int64 `json:"acquire_duration_ms"`
+}
+
+// Stats returns current pool statistics.
+func Stats(pool *pgxpool.Pool) PoolStats {
+	s := pool.Stat()
+	return PoolStats{
+		MaxConns:          s.MaxConns(),
+		TotalConns:        s.TotalConns(),
+		AcquiredConns:     s.AcquiredConns(),
+		IdleConns:         s.IdleConns(),
+		AcquireCount:      s.AcquireCount(),
+		EmptyAcquireCount: s.EmptyAcquireCount(),
+		AcquireDurationMS: s.AcquireDuration().Milliseconds(),
+	}
+}
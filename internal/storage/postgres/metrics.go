@@ -0,0 +1,148 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// businessMetricsTTL limits how often scrapes hit the database.
+const businessMetricsTTL = 30 * time.Second
+
+// QueryMetrics registers the repository duration histogram and returns
+// an observer feeding it.
+func QueryMetrics(reg prometheus.Registerer) QueryObserver {
+	h := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of SubscriptionRepo calls, including transaction setup.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "outcome"})
+	reg.MustRegister(h)
+
+	return func(method string, d time.Duration, err error) {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+		}
+		h.WithLabelValues(method, outcome).Observe(d.Seconds())
+	}
+}
+
+// RegisterPoolMetrics exposes connection pool gauges and wait counters.
+func RegisterPoolMetrics(reg prometheus.Registerer, pool *pgxpool.Pool) {
+	reg.MustRegister(newPoolCollector(pool))
+}
+
+// poolStat is one pool metric read from a pgxpool.Stat snapshot.
+type poolStat struct {
+	desc  *prometheus.Desc
+	kind  prometheus.ValueType
+	value func(s *pgxpool.Stat) float64
+}
+
+// poolCollector reads all pool metrics from one snapshot per scrape.
+type poolCollector struct {
+	pool  *pgxpool.Pool
+	stats []poolStat
+}
+
+// newPoolCollector describes the pool metrics.
+func newPoolCollector(pool *pgxpool.Pool) *poolCollector {
+	stat := func(name, help string, kind prometheus.ValueType, value func(s *pgxpool.Stat) float64) poolStat {
+		return poolStat{desc: prometheus.NewDesc(name, help, nil, nil), kind: kind, value: value}
+	}
+
+	return &poolCollector{
+		pool: pool,
+		stats: []poolStat{
+			stat("pgxpool_max_conns", "Maximum size of the pool.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.MaxConns()) }),
+			stat("pgxpool_total_conns", "Connections currently open.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.TotalConns()) }),
+			stat("pgxpool_acquired_conns", "Connections currently in use.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquiredConns()) }),
+			stat("pgxpool_idle_conns", "Connections currently idle.", prometheus.GaugeValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.IdleConns()) }),
+			stat("pgxpool_acquire_total", "Successful connection acquisitions.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.AcquireCount()) }),
+			stat("pgxpool_empty_acquire_total", "Acquisitions that had to wait for a connection.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return float64(s.EmptyAcquireCount()) }),
+			stat("pgxpool_acquire_wait_seconds_total", "Total time spent acquiring connections.", prometheus.CounterValue,
+				func(s *pgxpool.Stat) float64 { return s.AcquireDuration().Seconds() }),
+		},
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, s := range c.stats {
+		ch <- s.desc
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	st := c.pool.Stat()
+	for _, s := range c.stats {
+		ch <- prometheus.MustNewConstMetric(s.desc, s.kind, s.value(st))
+	}
+}
+
+// RegisterSubscriptionMetrics exposes business gauges. /metrics is not
+// authenticated, so they sum all tenants rather than naming them.
+// Values are cached for businessMetricsTTL.
+func RegisterSubscriptionMetrics(reg prometheus.Registerer, pool *pgxpool.Pool) {
+	c := &activeCount{pool: pool}
+
+	reg.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "subscriptions_active",
+		Help: "Subscriptions active in the current month.",
+	}, func() float64 { return float64(c.get()) }))
+}
+
+// activeCount caches the number of active subscriptions of all tenants.
+type activeCount struct {
+	pool      *pgxpool.Pool
+	mu        sync.Mutex
+	n         int64
+	fetchedAt time.Time
+}
+
+// get returns the cached count, refreshing it when stale.
+// On errors the previous count is kept.
+func (c *activeCount) get() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.fetchedAt) < businessMetricsTTL {
+		return c.n
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	n, err := c.fetch(ctx)
+	if err != nil {
+		slog.Error("Metrics: refresh failed", "error", err)
+		return c.n
+	}
+
+	c.n, c.fetchedAt = n, time.Now()
+	return c.n
+}
+
+// fetch sums the counts of the subscription_metrics function, which
+// aggregates across tenants despite row-level security.
+func (c *activeCount) fetch(ctx context.Context) (int64, error) {
+	var n int64
+	err := c.pool.QueryRow(ctx, "SELECT coalesce(sum(active), 0)::bigint FROM subscription_metrics();").Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("subscription metrics: %w", err)
+	}
+	return n, nil
+}
@@ -0,0 +1,347 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/DevSchmied/subscription-aggregation-service/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// migrationLockID is the advisory lock key held while a migration step runs.
+const migrationLockID int64 = 7218360425
+
+// Migration is one versioned schema change with its rollback.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// embedded are the migrations built into the binary.
+var embedded = mustLoadMigrations(migrations.FS)
+
+// SchemaVersion is the migration version this build expects,
+// the latest embedded migration.
+var SchemaVersion = embedded[len(embedded)-1].Version
+
+// Migrations returns the embedded migrations in version order.
+func Migrations() []Migration {
+	return append([]Migration(nil), embedded...)
+}
+
+// LoadMigrations reads NNNN_name.up.sql and NNNN_name.down.sql files.
+// Every version needs both files.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, f := range files {
+		base := path.Base(f)
+
+		// Split "0001_init.up.sql" into version, name and direction
+		stem, dir, ok := strings.Cut(strings.TrimSuffix(base, ".sql"), ".")
+		prefix, name, _ := strings.Cut(stem, "_")
+		v, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || v <= 0 || (dir != "up" && dir != "down") {
+			return nil, fmt.Errorf("unexpected migration file %s", base)
+		}
+
+		body, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+
+		m := byVersion[v]
+		if m == nil {
+			m = &Migration{Version: v, Name: name}
+			byVersion[v] = m
+		}
+		if m.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", v, m.Name, name)
+		}
+		if dir == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	if len(byVersion) == 0 {
+		return nil, errors.New("no migrations found")
+	}
+
+	out := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d needs an up and a down file", m.Version)
+		}
+		out = append(out, *m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+
+	return out, nil
+}
+
+// mustLoadMigrations loads the embedded migrations or panics.
+func mustLoadMigrations(fsys fs.FS) []Migration {
+	ms, err := LoadMigrations(fsys)
+	if err != nil {
+		panic("load embedded migrations: " + err.Error())
+	}
+	return ms
+}
+
+// Migrator applies migrations and records the version in the
+// golang-migrate schema_migrations table, so databases migrated
+// with either tool stay compatible.
+//
+// Each step runs in its own transaction under an advisory lock, so
+// concurrent instances apply every migration exactly once.
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+	// overlap is the overlap constraint setting applied after each
+	// run, nil leaves the constraint to the migrations
+	overlap *bool
+}
+
+// NewMigrator creates a migrator for migrations in version order.
+func NewMigrator(pool *pgxpool.Pool, migrations []Migration) *Migrator {
+	return &Migrator{pool: pool, migrations: migrations}
+}
+
+// WithOverlapConstraint makes every run end by adding or dropping the
+// overlap constraint as enabled asks, see SetOverlapConstraint.
+func (m *Migrator) WithOverlapConstraint(enabled bool) *Migrator {
+	m.overlap = &enabled
+	return m
+}
+
+// Status returns the applied version and the latest known one.
+func (m *Migrator) Status(ctx context.Context) (MigrationStatus, error) {
+	st := MigrationStatus{Expected: m.latest()}
+
+	err := pgx.BeginFunc(ctx, m.pool, func(tx pgx.Tx) error {
+		if err := ensureMigrationsTable(ctx, tx); err != nil {
+			return err
+		}
+		var err error
+		st.Version, st.Dirty, err = readVersion(ctx, tx)
+		return err
+	})
+
+	return st, err
+}
+
+// Up applies all pending migrations. A database at a newer version,
+// migrated by a newer build during a rolling deploy, is left as it is.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.migrate(ctx, m.latest(), true)
+}
+
+// Down rolls back the latest applied migration.
+func (m *Migrator) Down(ctx context.Context) error {
+	st, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	if st.Version == 0 {
+		return nil
+	}
+
+	return m.Goto(ctx, m.previous(st.Version))
+}
+
+// Goto migrates up or down to the given version; 0 rolls back everything.
+func (m *Migrator) Goto(ctx context.Context, version int64) error {
+	if version != 0 && m.index(version) < 0 {
+		return fmt.Errorf("unknown migration version %d", version)
+	}
+
+	return m.migrate(ctx, version, false)
+}
+
+// migrate steps to target, or only up to it with upOnly, then applies
+// the overlap constraint setting.
+func (m *Migrator) migrate(ctx context.Context, target int64, upOnly bool) error {
+	for {
+		done, err := m.step(ctx, target, upOnly)
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+
+	if m.overlap == nil {
+		return nil
+	}
+	return SetOverlapConstraint(ctx, m.pool, *m.overlap)
+}
+
+// step applies one migration towards target. It reports done once
+// the database is at target, or past it with upOnly.
+func (m *Migrator) step(ctx context.Context, target int64, upOnly bool) (bool, error) {
+	done := false
+
+	err := pgx.BeginFunc(ctx, m.pool, func(tx pgx.Tx) error {
+		// Serialize with other instances until commit
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1);`, migrationLockID); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+
+		if err := ensureMigrationsTable(ctx, tx); err != nil {
+			return err
+		}
+
+		current, dirty, err := readVersion(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if dirty {
+			return fmt.Errorf("%w: version %d is dirty, fix the schema and force the version", ErrSchemaMismatch, current)
+		}
+		if reached(current, target, upOnly) {
+			done = true
+			return nil
+		}
+
+		// Pick the next migration up, or the current one to roll back
+		var (
+			mig       Migration
+			sql       string
+			direction string
+			next      int64
+		)
+		if current < target {
+			i := sort.Search(len(m.migrations), func(i int) bool { return m.migrations[i].Version > current })
+			mig, sql, direction, next = m.migrations[i], m.migrations[i].Up, "up", m.migrations[i].Version
+		} else {
+			i := m.index(current)
+			if i < 0 {
+				return fmt.Errorf("%w: applied version %d is unknown to this build", ErrSchemaMismatch, current)
+			}
+			mig, sql, direction, next = m.migrations[i], m.migrations[i].Down, "down", m.previous(current)
+		}
+
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return fmt.Errorf("migration %d_%s %s: %w", mig.Version, mig.Name, direction, err)
+		}
+		if err := writeVersion(ctx, tx, next); err != nil {
+			return err
+		}
+
+		slog.InfoContext(ctx, "Migration applied",
+			"version", mig.Version, "name", mig.Name, "direction", direction)
+		return nil
+	})
+
+	return done, err
+}
+
+// reached reports whether a database at current needs no more steps
+// towards target; with upOnly, newer versions are never rolled back.
+func reached(current, target int64, upOnly bool) bool {
+	return current == target || (upOnly && current > target)
+}
+
+// latest returns the highest known version.
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// index returns the position of version, -1 if unknown.
+func (m *Migrator) index(version int64) int {
+	for i, mig := range m.migrations {
+		if mig.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// previous returns the version before the given one, 0 for the first.
+func (m *Migrator) previous(version int64) int64 {
+	prev := int64(0)
+	for _, mig := range m.migrations {
+		if mig.Version >= version {
+			break
+		}
+		prev = mig.Version
+	}
+	return prev
+}
+
+// ensureMigrationsTable creates the golang-migrate version table.
+func ensureMigrationsTable(ctx context.Context, tx pgx.Tx) error {
+	const q = `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version bigint NOT NULL PRIMARY KEY,
+			dirty boolean NOT NULL
+		);
+	`
+
+	if _, err := tx.Exec(ctx, q); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+	return nil
+}
+
+// readVersion returns the applied version, 0 when none.
+func readVersion(ctx context.Context, tx pgx.Tx) (int64, bool, error) {
+	const q = `
+		SELECT version, dirty
+		FROM schema_migrations
+		LIMIT 1;
+	`
+
+	var (
+		version int64
+		dirty   bool
+	)
+	if err := tx.QueryRow(ctx, q).Scan(&version, &dirty); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, false, nil
+		}
+		return 0, false, fmt.Errorf("read schema version: %w", err)
+	}
+
+	return version, dirty, nil
+}
+
+// writeVersion records the applied version; 0 leaves the table empty.
+func writeVersion(ctx context.Context, tx pgx.Tx, version int64) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM schema_migrations;`); err != nil {
+		return fmt.Errorf("write schema version: %w", err)
+	}
+	if version == 0 {
+		return nil
+	}
+
+	const q = `
+		INSERT INTO schema_migrations (version, dirty)
+		VALUES ($1, false);
+	`
+
+	if _, err := tx.Exec(ctx, q, version); err != nil {
+		return fmt.Errorf("write schema version: %w", err)
+	}
+	return nil
+}
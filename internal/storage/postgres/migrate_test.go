@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"errors"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// ====================================
+// Loading
+// ====================================
+
+func TestMigrationStatus_Check(t *testing.T) {
+	cases := []struct {
+		name string
+		st   MigrationStatus
+		ok   bool
+	}{
+		{"current", MigrationStatus{Version: 5, Expected: 5}, true},
+		{"newer", MigrationStatus{Version: 6, Expected: 5}, true},
+		{"older", MigrationStatus{Version: 4, Expected: 5}, false},
+		{"dirty", MigrationStatus{Version: 5, Expected: 5, Dirty: true}, false},
+		{"newer and dirty", MigrationStatus{Version: 6, Expected: 5, Dirty: true}, false},
+	}
+
+	for _, tc := range cases {
+		// Act
+		err := tc.st.Check()
+
+		// Assert
+		if (err == nil) != tc.ok {
+			t.Errorf("%s: expected ok=%v, got %v", tc.name, tc.ok, err)
+		}
+		if err != nil && !errors.Is(err, ErrSchemaMismatch) {
+			t.Errorf("%s: expected ErrSchemaMismatch, got %v", tc.name, err)
+		}
+	}
+}
+
+func TestSchemaVersion_MatchesLatestMigration(t *testing.T) {
+	// Arrange
+	files, err := filepath.Glob(filepath.Join("..", "..", "..", "migrations", "*.up.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("list migrations: %v", err)
+	}
+
+	// Act
+	latest := int64(0)
+	for _, f := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(f), "_")
+		n, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			t.Fatalf("unexpected migration name %s", f)
+		}
+		latest = max(latest, n)
+	}
+
+	// Assert
+	if latest != SchemaVersion {
+		t.Errorf("SchemaVersion is %d, latest migration is %d", SchemaVersion, latest)
+	}
+	if got := len(Migrations()); got != len(files) {
+		t.Errorf("embedded %d migrations, want %d", got, len(files))
+	}
+}
+
+func TestLoadMigrations_SortsAndPairsFiles(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"0010_b.up.sql":   {Data: []byte("UP 10")},
+		"0010_b.down.sql": {Data: []byte("DOWN 10")},
+		"0002_a.up.sql":   {Data: []byte("UP 2")},
+		"0002_a.down.sql": {Data: []byte("DOWN 2")},
+		"migrations.go":   {Data: []byte("package migrations")},
+	}
+
+	// Act
+	ms, err := LoadMigrations(fsys)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(ms) != 2 {
+		t.Fatalf("expected 2 migrations, got %d", len(ms))
+	}
+	if ms[0].Version != 2 || ms[0].Name != "a" || ms[0].Up != "UP 2" || ms[0].Down != "DOWN 2" {
+		t.Errorf("unexpected first migration: %+v", ms[0])
+	}
+	if ms[1].Version != 10 || ms[1].Name != "b" {
+		t.Errorf("unexpected second migration: %+v", ms[1])
+	}
+}
+
+func TestLoadMigrations_RejectsInvalidSets(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"missing down": {
+			"0001_init.up.sql": {Data: []byte("UP")},
+		},
+		"bad version": {
+			"init.up.sql":   {Data: []byte("UP")},
+			"init.down.sql": {Data: []byte("DOWN")},
+		},
+		"bad direction": {
+			"0001_init.sideways.sql": {Data: []byte("UP")},
+		},
+		"two names": {
+			"0001_a.up.sql":   {Data: []byte("UP")},
+			"0001_b.down.sql": {Data: []byte("DOWN")},
+		},
+		"empty": {},
+	}
+
+	for name, fsys := range tests {
+		t.Run(name, func(t *testing.T) {
+			// Act
+			_, err := LoadMigrations(fsys)
+
+			// Assert
+			if err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+// ====================================
+// Version navigation
+// ====================================
+
+func TestMigrator_PreviousAndIndex(t *testing.T) {
+	// Arrange
+	m := NewMigrator(nil, []Migration{{Version: 1}, {Version: 2}, {Version: 5}})
+
+	// Act / Assert
+	if got := m.latest(); got != 5 {
+		t.Errorf("latest = %d, want 5", got)
+	}
+	if got := m.previous(5); got != 2 {
+		t.Errorf("previous(5) = %d, want 2", got)
+	}
+	if got := m.previous(1); got != 0 {
+		t.Errorf("previous(1) = %d, want 0", got)
+	}
+	if got := m.index(2); got != 1 {
+		t.Errorf("index(2) = %d, want 1", got)
+	}
+	if got := m.index(3); got != -1 {
+		t.Errorf("index(3) = %d, want -1", got)
+	}
+}
+
+func TestReached(t *testing.T) {
+	cases := []struct {
+		name            string
+		current, target int64
+		upOnly          bool
+		want            bool
+	}{
+		{"at target", 5, 5, false, true},
+		{"behind", 4, 5, true, false},
+		{"newer with goto", 6, 5, false, false},
+		{"newer with up", 6, 5, true, true},
+	}
+
+	for _, tc := range cases {
+		if got := reached(tc.current, tc.target, tc.upOnly); got != tc.want {
+			t.Errorf("%s: reached = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/stream"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// outboxChannel is the channel the outbox_notify trigger announces on.
+const outboxChannel = "outbox_events"
+
+// outboxNotification is the payload sent by the outbox_notify trigger.
+// Payload is missing for events too large for a notification.
+type outboxNotification struct {
+	ID             int64           `json:"id"`
+	TenantID       string          `json:"tenant_id"`
+	EventID        uuid.UUID       `json:"event_id"`
+	Event          string          `json:"event"`
+	SubscriptionID uuid.UUID       `json:"subscription_id"`
+	CreatedAt      time.Time       `json:"created_at"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+// OutboxListener receives committed outbox messages with LISTEN on a
+// dedicated connection, so it does not hold a connection of the pool.
+type OutboxListener struct {
+	pool *pgxpool.Pool
+	repo *OutboxRepo
+}
+
+var _ stream.Source = (*OutboxListener)(nil)
+
+// NewOutboxListener creates a listener connecting like pool.
+func NewOutboxListener(pool *pgxpool.Pool) *OutboxListener {
+	return &OutboxListener{pool: pool, repo: NewOutboxRepo(pool)}
+}
+
+// Listen connects, listens and passes announced messages to fn until ctx
+// is done or the connection fails.
+func (l *OutboxListener) Listen(
+	ctx context.Context,
+	ready func(),
+	fn func(outbox.Message),
+) error {
+
+	conn, err := pgx.ConnectConfig(ctx, l.pool.Config().ConnConfig.Copy())
+	if err != nil {
+		return fmt.Errorf("connect listener: %w", err)
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+outboxChannel+";"); err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	ready()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return fmt.Errorf("wait for notification: %w", err)
+		}
+
+		m, err := l.decode(ctx, n.Payload)
+		if err != nil {
+			slog.Error("Stream: invalid notification", "error", err)
+			continue
+		}
+		fn(m)
+	}
+}
+
+// decode returns the message of a notification, loading its payload
+// when it did not fit.
+func (l *OutboxListener) decode(ctx context.Context, payload string) (outbox.Message, error) {
+	var n outboxNotification
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		return outbox.Message{}, err
+	}
+
+	if n.Payload == nil {
+		return l.repo.message(tenant.WithID(ctx, n.TenantID), n.ID)
+	}
+
+	return outbox.Message{
+		ID:             n.ID,
+		TenantID:       n.TenantID,
+		EventID:        n.EventID,
+		Type:           n.Event,
+		SubscriptionID: n.SubscriptionID,
+		Payload:        n.Payload,
+		CreatedAt:      n.CreatedAt,
+	}, nil
+}
@@ -0,0 +1,314 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/stream"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// recordEvent writes a change event to the outbox. db must be the
+// transaction of the change, so the event exists exactly if it commits.
+func recordEvent(
+	ctx context.Context,
+	db dbtx,
+	typ string,
+	s domain.Subscription,
+) error {
+
+	const q = `
+		INSERT INTO outbox (event_id, event, subscription_id, payload)
+		VALUES ($1, $2, $3, $4);
+	`
+
+	ev := domain.NewEvent(typ, s)
+	payload, err := outbox.Encode(ev)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+
+	if _, err := db.Exec(ctx, q, ev.ID, ev.Type, s.ID, payload); err != nil {
+		return fmt.Errorf("record event: %w", err)
+	}
+
+	return nil
+}
+
+// recordEventCreates writes created events for bulk-inserted subscriptions.
+func recordEventCreates(
+	ctx context.Context,
+	db dbtx,
+	subs []domain.Subscription,
+) error {
+
+	columns := []string{
+		"event_id",
+		"event",
+		"subscription_id",
+		"payload",
+	}
+
+	// Stream events into the outbox
+	_, err := db.CopyFrom(
+		ctx,
+		pgx.Identifier{"outbox"},
+		columns,
+		pgx.CopyFromSlice(len(subs), func(i int) ([]any, error) {
+			ev := domain.NewEvent(domain.EventSubscriptionCreated, subs[i])
+			payload, err := outbox.Encode(ev)
+			if err != nil {
+				return nil, err
+			}
+			return []any{ev.ID, ev.Type, subs[i].ID, payload}, nil
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("copy outbox events: %w", err)
+	}
+
+	return nil
+}
+
+// OutboxRepo reads and marks the outbox for the relay and event streams.
+type OutboxRepo struct {
+	db dbtx
+}
+
+var (
+	_ outbox.Store = (*OutboxRepo)(nil)
+	_ stream.Store = (*OutboxRepo)(nil)
+)
+
+// NewOutboxRepo creates a new repository instance.
+func NewOutboxRepo(pool *pgxpool.Pool) *OutboxRepo {
+	return &OutboxRepo{db: pool}
+}
+
+// Tenants returns the tenants with unsent messages.
+func (r *OutboxRepo) Tenants(ctx context.Context) ([]string, error) {
+	rows, err := r.db.Query(ctx, "SELECT outbox_tenants();")
+	if err != nil {
+		return nil, fmt.Errorf("list outbox tenants: %w", err)
+	}
+
+	tenants, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("scan outbox tenants: %w", err)
+	}
+
+	return tenants, nil
+}
+
+// Process locks due messages, hands them to fn and records the outcome,
+// all in one transaction. A message is only due when no older message of
+// its subscription is unsent, so a failing message holds back the later
+// changes of its subscription. Messages locked by another relay are
+// skipped, as are their successors.
+func (r *OutboxRepo) Process(
+	ctx context.Context,
+	limit int,
+	backoff func(attempt int) time.Duration,
+	fn func(outbox.Message) error,
+) (int, error) {
+
+	const qClaim = `
+		SELECT
+			o.id,
+			o.tenant_id,
+			o.event_id,
+			o.event,
+			o.subscription_id,
+			o.payload,
+			o.attempts,
+			o.created_at
+		FROM outbox o
+		WHERE o.sent_at IS NULL
+		AND o.next_attempt_at <= now()
+		AND NOT EXISTS (
+			SELECT 1
+			FROM outbox p
+			WHERE p.subscription_id = o.subscription_id
+			AND p.sent_at IS NULL
+			AND p.id < o.id
+		)
+		ORDER BY o.id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED;
+	`
+
+	const qSent = `
+		UPDATE outbox
+		SET sent_at = now(), attempts = attempts + 1, last_error = NULL
+		WHERE id = $1;
+	`
+
+	const qFailed = `
+		UPDATE outbox
+		SET
+			attempts = attempts + 1,
+			last_error = $2,
+			next_attempt_at = now() + make_interval(secs => $3)
+		WHERE id = $1;
+	`
+
+	sent := 0
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		rows, err := db.Query(ctx, qClaim, limit)
+		if err != nil {
+			return err
+		}
+
+		type claimed struct {
+			outbox.Message
+			attempts int
+		}
+		msgs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (claimed, error) {
+			var c claimed
+			err := row.Scan(
+				&c.ID,
+				&c.TenantID,
+				&c.EventID,
+				&c.Type,
+				&c.SubscriptionID,
+				&c.Payload,
+				&c.attempts,
+				&c.CreatedAt,
+			)
+			return c, err
+		})
+		if err != nil {
+			return err
+		}
+
+		// Publish in id order, the rows stay locked until commit
+		for _, m := range msgs {
+			if perr := fn(m.Message); perr != nil {
+				delay := backoff(m.attempts + 1).Seconds()
+				if _, err := db.Exec(ctx, qFailed, m.ID, perr.Error(), delay); err != nil {
+					return err
+				}
+				continue
+			}
+			if _, err := db.Exec(ctx, qSent, m.ID); err != nil {
+				return err
+			}
+			sent++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("process outbox: %w", err)
+	}
+
+	return sent, nil
+}
+
+// Purge removes messages of all tenants sent before the given time.
+func (r *OutboxRepo) Purge(ctx context.Context, sentBefore time.Time) (int64, error) {
+	var n int64
+	if err := r.db.QueryRow(ctx, "SELECT outbox_purge($1);", sentBefore).Scan(&n); err != nil {
+		return 0, fmt.Errorf("purge outbox: %w", err)
+	}
+	return n, nil
+}
+
+// outboxColumns are the message columns read by scanMessage.
+const outboxColumns = `
+	id,
+	tenant_id,
+	event_id,
+	event,
+	subscription_id,
+	payload,
+	created_at`
+
+// scanMessage scans a row of outboxColumns.
+func scanMessage(row pgx.Row) (outbox.Message, error) {
+	var m outbox.Message
+	err := row.Scan(
+		&m.ID,
+		&m.TenantID,
+		&m.EventID,
+		&m.Type,
+		&m.SubscriptionID,
+		&m.Payload,
+		&m.CreatedAt,
+	)
+	return m, err
+}
+
+// resumeWindow is how long before the message afterID was written Since
+// reads again. IDs are drawn at insert, not at commit, so a transaction
+// still open then may commit a lower ID after afterID was delivered; the
+// window covers the longest writing transaction, a bulk import.
+const resumeWindow = 5 * time.Minute
+
+// Since returns up to limit retained messages that match f, oldest
+// first, sent or not: those with IDs above afterID, and those of
+// transactions that started within resumeWindow before it. Clients drop
+// the repeated ones by event ID.
+func (r *OutboxRepo) Since(
+	ctx context.Context,
+	afterID int64,
+	f stream.Filter,
+	limit int,
+) ([]outbox.Message, error) {
+
+	const q = `
+		SELECT` + outboxColumns + `
+		FROM outbox
+		WHERE (
+			id > $1
+			OR created_at >= (SELECT created_at FROM outbox WHERE id = $1) - make_interval(secs => $5)
+		)
+		AND ($2::text IS NULL OR payload->'data'->>'user_id' = $2)
+		AND ($3::text IS NULL OR payload->'data'->>'service_name' = $3)
+		ORDER BY id
+		LIMIT $4;
+	`
+
+	var userID *string
+	if f.UserID != nil {
+		s := f.UserID.String()
+		userID = &s
+	}
+
+	var out []outbox.Message
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		rows, err := db.Query(ctx, q, afterID, userID, f.ServiceName, limit, resumeWindow.Seconds())
+		if err != nil {
+			return err
+		}
+		out, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (outbox.Message, error) {
+			return scanMessage(row)
+		})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read outbox: %w", err)
+	}
+
+	return out, nil
+}
+
+// message returns the message with id of the tenant in ctx.
+func (r *OutboxRepo) message(ctx context.Context, id int64) (outbox.Message, error) {
+	const q = `SELECT` + outboxColumns + ` FROM outbox WHERE id = $1;`
+
+	var m outbox.Message
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		var err error
+		m, err = scanMessage(db.QueryRow(ctx, q, id))
+		return err
+	})
+	if err != nil {
+		return outbox.Message{}, fmt.Errorf("read outbox message: %w", err)
+	}
+
+	return m, nil
+}
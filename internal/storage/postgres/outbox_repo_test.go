@@ -0,0 +1,223 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/stream"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+func TestOutboxRepo_RelaysChangesInOrder(t *testing.T) {
+	// Arrange
+	pool := testPool(t)
+	subs := NewSubscriptionRepo(pool)
+	repo := NewOutboxRepo(pool)
+
+	// A tenant of its own keeps messages of other tests out of the way
+	id := "test-outbox-" + uuid.NewString()[:8]
+	ctx := tenant.WithID(context.Background(), id)
+	other := tenant.WithID(context.Background(), "test-tenant-b")
+
+	s := domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Outbox",
+		Price:       100,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	if _, err := subs.Create(ctx, s); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	s.Price = 200
+	if _, err := subs.Update(ctx, s); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := subs.Delete(ctx, s.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	noBackoff := func(int) time.Duration { return 0 }
+	var got []outbox.Message
+	process := func(ctx context.Context, fail bool) int {
+		t.Helper()
+		n, err := repo.Process(ctx, 10, noBackoff, func(m outbox.Message) error {
+			got = append(got, m)
+			if fail {
+				return errors.New("sink unavailable")
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("process: %v", err)
+		}
+		return n
+	}
+
+	// Act
+	tenants, err := repo.Tenants(ctx)
+	if err != nil {
+		t.Fatalf("tenants: %v", err)
+	}
+	foreign := process(other, false)
+	failed := process(ctx, true)
+	sent := []int{process(ctx, false), process(ctx, false), process(ctx, false), process(ctx, false)}
+
+	// Assert
+	found := false
+	for _, tid := range tenants {
+		found = found || tid == id
+	}
+	if !found {
+		t.Errorf("expected tenant %s with unsent messages, got %v", id, tenants)
+	}
+	if foreign != 0 || failed != 0 {
+		t.Errorf("expected nothing sent for another tenant or a failing sink, got %d and %d", foreign, failed)
+	}
+	if sent[0] != 1 || sent[1] != 1 || sent[2] != 1 || sent[3] != 0 {
+		t.Errorf("expected one message per round, got %v", sent)
+	}
+
+	// The failed message is published again, then the later changes in order
+	var types []string
+	for _, m := range got {
+		if m.SubscriptionID == s.ID {
+			types = append(types, m.Type)
+		}
+	}
+	want := []string{
+		domain.EventSubscriptionCreated,
+		domain.EventSubscriptionCreated,
+		domain.EventSubscriptionUpdated,
+		domain.EventSubscriptionDeleted,
+	}
+	if len(types) != len(want) {
+		t.Fatalf("expected events %v, got %v", want, types)
+	}
+	for i := range want {
+		if types[i] != want[i] {
+			t.Fatalf("expected events %v, got %v", want, types)
+		}
+	}
+	if got[len(got)-1].TenantID != id {
+		t.Errorf("expected tenant %s on messages, got %s", id, got[len(got)-1].TenantID)
+	}
+}
+
+func TestOutboxListener_AnnouncesCommittedChanges(t *testing.T) {
+	// Arrange
+	pool := testPool(t)
+	subs := NewSubscriptionRepo(pool)
+	repo := NewOutboxRepo(pool)
+	ctx := tenant.WithID(context.Background(), "test-tenant-a")
+
+	listenCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ready := make(chan struct{})
+	got := make(chan outbox.Message, 16)
+	go func() {
+		_ = NewOutboxListener(pool).Listen(listenCtx, func() { close(ready) }, func(m outbox.Message) {
+			got <- m
+		})
+	}()
+	select {
+	case <-ready:
+	case <-time.After(5 * time.Second):
+		t.Fatal("listener not ready")
+	}
+
+	s := domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Stream " + uuid.NewString()[:8],
+		Price:       100,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Act
+	if _, err := subs.Create(ctx, s); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	t.Cleanup(func() { _ = subs.Delete(ctx, s.ID) })
+
+	var announced outbox.Message
+	for announced.SubscriptionID != s.ID {
+		select {
+		case announced = <-got:
+		case <-time.After(5 * time.Second):
+			t.Fatal("change not announced")
+		}
+	}
+	backlog, err := repo.Since(ctx, announced.ID-1, stream.Filter{ServiceName: &s.ServiceName}, 10)
+	if err != nil {
+		t.Fatalf("since: %v", err)
+	}
+
+	// Assert
+	if announced.TenantID != "test-tenant-a" || announced.Type != domain.EventSubscriptionCreated {
+		t.Errorf("unexpected announcement %+v", announced)
+	}
+	if len(backlog) != 1 || backlog[0].ID != announced.ID || backlog[0].EventID != announced.EventID {
+		t.Errorf("expected the announced message in the backlog, got %+v", backlog)
+	}
+}
+
+func TestOutboxRepo_SinceReplaysLateCommits(t *testing.T) {
+	// Arrange
+	pool := testPool(t)
+	subs := NewSubscriptionRepo(pool)
+	repo := NewOutboxRepo(pool)
+
+	ctx := tenant.WithID(context.Background(), "test-outbox-"+uuid.NewString()[:8])
+	sub := func(name string) domain.Subscription {
+		return domain.Subscription{
+			ID:          uuid.New(),
+			ServiceName: name,
+			Price:       100,
+			UserID:      uuid.New(),
+			StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	early, late := sub("Early"), sub("Late")
+
+	// early draws the lower ID but commits after late
+	err := subs.InTx(ctx, func(tx domain.SubscriptionStore) error {
+		if _, err := tx.Create(ctx, early); err != nil {
+			return err
+		}
+		_, err := subs.Create(ctx, late)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	all, err := repo.Since(ctx, 0, stream.Filter{}, 10)
+	if err != nil {
+		t.Fatalf("since: %v", err)
+	}
+	if len(all) != 2 || all[0].SubscriptionID != early.ID {
+		t.Fatalf("expected the early change first of 2, got %+v", all)
+	}
+
+	// Act: a client that received late before early committed resumes
+	got, err := repo.Since(ctx, all[1].ID, stream.Filter{}, 10)
+	if err != nil {
+		t.Fatalf("since: %v", err)
+	}
+
+	// Assert
+	found := false
+	for _, m := range got {
+		found = found || m.EventID == all[0].EventID
+	}
+	if !found {
+		t.Errorf("expected the late committed change %d replayed, got %+v", all[0].ID, got)
+	}
+}
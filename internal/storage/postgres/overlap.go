@@ -0,0 +1,131 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// overlapConstraint is the exclusion constraint rejecting overlapping
+// subscriptions of a user to a service within a tenant.
+const overlapConstraint = "subscriptions_no_overlap"
+
+// SetOverlapConstraint adds or drops the overlap constraint as enabled
+// asks. Migrations only install btree_gist, so the setting owns the
+// constraint at every schema version: it waits for the subscriptions
+// table and the extension, and leads with tenant_id once the table has
+// one, rebuilding a constraint of the other shape. It runs under the
+// migration lock. Adding it fails while overlapping rows exist.
+//
+// Migrators created WithOverlapConstraint call it after every run.
+func SetOverlapConstraint(ctx context.Context, pool *pgxpool.Pool, enabled bool) error {
+	const qPrerequisites = `
+		SELECT
+			to_regclass('subscriptions') IS NOT NULL,
+			EXISTS (SELECT FROM pg_extension WHERE extname = 'btree_gist'),
+			EXISTS (
+				SELECT FROM pg_attribute
+				WHERE attrelid = to_regclass('subscriptions')
+				AND attname = 'tenant_id'
+				AND NOT attisdropped
+			);
+	`
+
+	const qAdd = `
+		ALTER TABLE subscriptions
+			ADD CONSTRAINT subscriptions_no_overlap
+			EXCLUDE USING gist (
+				%s
+				user_id WITH =,
+				service_name WITH =,
+				daterange(start_date, end_date, '[]') WITH &&
+			);
+	`
+
+	const qDrop = `ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_no_overlap;`
+
+	return pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		// Serialize with migrations of other instances until commit
+		if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1);`, migrationLockID); err != nil {
+			return fmt.Errorf("lock migrations: %w", err)
+		}
+
+		var table, extension, tenants bool
+		if err := tx.QueryRow(ctx, qPrerequisites).Scan(&table, &extension, &tenants); err != nil {
+			return fmt.Errorf("look up overlap constraint prerequisites: %w", err)
+		}
+		if !table || !extension {
+			return nil
+		}
+
+		def, exists, err := overlapConstraintDef(ctx, tx)
+		if err != nil {
+			return err
+		}
+		current := exists && strings.Contains(def, "tenant_id") == tenants
+		if exists == enabled && (!enabled || current) {
+			return nil
+		}
+
+		if exists {
+			if _, err := tx.Exec(ctx, qDrop); err != nil {
+				return fmt.Errorf("drop overlap constraint: %w", err)
+			}
+			if !enabled {
+				slog.InfoContext(ctx, "Overlap constraint dropped")
+				return nil
+			}
+		}
+
+		lead := ""
+		if tenants {
+			lead = "tenant_id WITH =,"
+		}
+		if _, err := tx.Exec(ctx, fmt.Sprintf(qAdd, lead)); err != nil {
+			if isExclusionViolation(err) {
+				return errors.New("add overlap constraint: overlapping subscriptions exist, " +
+					"remove them or set DB_OVERLAP_CONSTRAINT=false")
+			}
+			return fmt.Errorf("add overlap constraint: %w", err)
+		}
+		slog.InfoContext(ctx, "Overlap constraint added", "tenant_led", tenants)
+		return nil
+	})
+}
+
+// OverlapConstraintEnabled reports whether the overlap constraint exists.
+func OverlapConstraintEnabled(ctx context.Context, pool *pgxpool.Pool) (bool, error) {
+	var exists bool
+	err := pgx.BeginFunc(ctx, pool, func(tx pgx.Tx) error {
+		var err error
+		_, exists, err = overlapConstraintDef(ctx, tx)
+		return err
+	})
+	return exists, err
+}
+
+// overlapConstraintDef looks the overlap constraint up in the catalog and
+// returns its definition.
+func overlapConstraintDef(ctx context.Context, tx pgx.Tx) (string, bool, error) {
+	const q = `
+		SELECT pg_get_constraintdef(oid)
+		FROM pg_constraint
+		WHERE conname = $1
+		AND conrelid = to_regclass('subscriptions');
+	`
+
+	var def string
+	err := tx.QueryRow(ctx, q, overlapConstraint).Scan(&def)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("look up overlap constraint: %w", err)
+	}
+	return def, true, nil
+}
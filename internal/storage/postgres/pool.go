@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/DevSchmied/subscription-aggregation-service/internal/config"
+	"github.com/exaring/otelpgx"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -35,6 +36,10 @@ func NewPool(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
 	poolCfg.MaxConnLifetime = 30 * time.Minute
 	poolCfg.MaxConnIdleTime = 5 * time.Minute
 
+	// Record statements as spans of the request trace, named after
+	// their leading keyword, e.g. "query SELECT"
+	poolCfg.ConnConfig.Tracer = otelpgx.NewTracer(otelpgx.WithTrimSQLInSpanName())
+
 	// Create connection pool
 	pool, err := pgxpool.NewWithConfig(ctx, poolCfg)
 	if err != nil {
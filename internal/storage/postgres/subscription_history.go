@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// versionsAsOf is a table expression with the subscription rows that were
+// current at the time bound to parameter $n. Past versions come from
+// subscriptions_history, which a trigger fills on every update and delete.
+func versionsAsOf(n int) string {
+	return fmt.Sprintf(`(
+			SELECT
+				id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+			FROM subscriptions
+			WHERE valid_from <= $%[1]d
+			UNION ALL
+			SELECT
+				id, service_name, price, user_id, start_date, end_date, created_at, updated_at
+			FROM subscriptions_history
+			WHERE valid_from <= $%[1]d AND valid_to > $%[1]d
+		) AS subscriptions`, n)
+}
+
+// subscriptionsSource returns the table expression for a query with args
+// and the args to run it with. With asOf set, the expression reads row
+// versions at that time, bound as the parameter after args.
+func subscriptionsSource(asOf *time.Time, args ...any) (string, []any) {
+	if asOf == nil {
+		return "subscriptions", args
+	}
+	return versionsAsOf(len(args) + 1), append(args, *asOf)
+}
+
+// GetByIDAsOf returns subscription by ID as it was at asOf.
+func (r *SubscriptionRepo) GetByIDAsOf(
+	ctx context.Context,
+	id uuid.UUID,
+	asOf time.Time,
+) (domain.Subscription, error) {
+
+	const q = `
+		SELECT
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			created_at,
+			updated_at
+		FROM %s
+		WHERE id = $1;
+	`
+
+	from, args := subscriptionsSource(&asOf, id)
+
+	var s domain.Subscription
+
+	// Query the version valid at asOf
+	if err := r.withTenant(ctx, "GetByIDAsOf", func(db dbtx) error {
+		return db.QueryRow(ctx, fmt.Sprintf(q, from), args...).Scan(
+			&s.ID,
+			&s.ServiceName,
+			&s.Price,
+			&s.UserID,
+			&s.StartDate,
+			&s.EndDate,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Subscription{}, ErrNotFound
+		}
+		return domain.Subscription{}, fmt.Errorf("get subscription as of: %w", err)
+	}
+
+	return s, nil
+}
@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+func TestSubscriptionsSource(t *testing.T) {
+	// Arrange
+	asOf := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	// Act
+	current, currentArgs := subscriptionsSource(nil, "a", "b")
+	past, pastArgs := subscriptionsSource(&asOf, "a", "b")
+
+	// Assert
+	if current != "subscriptions" || len(currentArgs) != 2 {
+		t.Errorf("expected plain table, got %q with %d args", current, len(currentArgs))
+	}
+	if !strings.Contains(past, "valid_from <= $3") || !strings.Contains(past, "valid_to > $3") {
+		t.Errorf("expected as-of bound to $3, got %s", past)
+	}
+	if len(pastArgs) != 3 || pastArgs[2] != asOf {
+		t.Errorf("expected as-of appended to args, got %v", pastArgs)
+	}
+}
+
+func TestAsOf_ReadsPastVersions(t *testing.T) {
+	// Arrange
+	repo := NewSubscriptionRepo(testPool(t))
+	ctx := tenant.WithID(context.Background(), "test-tenant-a")
+
+	s, err := repo.Create(ctx, domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "History " + uuid.NewString()[:8],
+		Price:       100,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Delete(ctx, s.ID) })
+
+	beforeCreate := s.CreatedAt.Add(-time.Second)
+	time.Sleep(10 * time.Millisecond)
+	afterCreate := time.Now()
+	time.Sleep(10 * time.Millisecond)
+
+	// Act
+	s.Price = 200
+	if _, err := repo.Update(ctx, s); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := repo.Delete(ctx, s.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	original, origErr := repo.GetByIDAsOf(ctx, s.ID, afterCreate)
+	_, missingErr := repo.GetByIDAsOf(ctx, s.ID, beforeCreate)
+	listed, listErr := repo.List(ctx, domain.ListFilter{UserID: &s.UserID, AsOf: &afterCreate})
+	_, currentErr := repo.GetByID(ctx, s.ID)
+
+	// Assert
+	if origErr != nil || original.Price != 100 {
+		t.Errorf("expected original price 100, got %d (%v)", original.Price, origErr)
+	}
+	if !errors.Is(missingErr, ErrNotFound) {
+		t.Errorf("expected ErrNotFound before create, got %v", missingErr)
+	}
+	if listErr != nil || len(listed) != 1 || listed[0].Price != 100 {
+		t.Errorf("expected one past row, got %+v (%v)", listed, listErr)
+	}
+	if !errors.Is(currentErr, ErrNotFound) {
+		t.Errorf("expected deleted row to be gone now, got %v", currentErr)
+	}
+}
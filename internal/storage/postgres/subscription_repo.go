@@ -4,25 +4,102 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
 	"time"
 
+	"github.com/DevSchmied/subscription-aggregation-service/internal/audit"
 	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// dbtx is implemented by both the connection pool and a transaction.
+type dbtx interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	Begin(ctx context.Context) (pgx.Tx, error)
+	CopyFrom(
+		ctx context.Context,
+		table pgx.Identifier,
+		columns []string,
+		src pgx.CopyFromSource,
+	) (int64, error)
+}
+
 // SubscriptionRepo provides subscription persistence.
 type SubscriptionRepo struct {
-	pool *pgxpool.Pool
+	db dbtx
+	// inTx is set when db is a transaction with the tenant applied
+	inTx    bool
+	observe QueryObserver
 }
 
+// QueryObserver receives the duration and result of each repository call.
+type QueryObserver func(method string, d time.Duration, err error)
+
+// SubscriptionRepo implements the store interface of the handlers.
+var _ domain.SubscriptionStore = (*SubscriptionRepo)(nil)
+
 // NewSubscriptionRepo creates a new repository instance.
 func NewSubscriptionRepo(pool *pgxpool.Pool) *SubscriptionRepo {
-	return &SubscriptionRepo{pool: pool}
+	return &SubscriptionRepo{db: pool}
+}
+
+// WithObserver returns a copy of the repository reporting call durations to o.
+func (r *SubscriptionRepo) WithObserver(o QueryObserver) *SubscriptionRepo {
+	c := *r
+	c.observe = o
+	return &c
+}
+
+// InTx runs fn inside a transaction and commits if fn returns nil.
+// The repository passed to fn is bound to the transaction; calling
+// InTx on it again opens a savepoint instead of a new transaction.
+// The tenant from ctx is applied to the transaction for row-level security.
+func (r *SubscriptionRepo) InTx(
+	ctx context.Context,
+	fn func(tx domain.SubscriptionStore) error,
+) error {
+
+	// Savepoints inherit the tenant of the outer transaction
+	return runTx(ctx, r.db, !r.inTx, func(tx dbtx) error {
+		return fn(&SubscriptionRepo{db: tx, inTx: true, observe: r.observe})
+	})
 }
 
-// Create inserts a new subscription.
+// withTenant runs fn on a connection scoped to the tenant from ctx.
+// The tenant setting is transaction-local, so outside InTx a short
+// transaction is opened around fn. The call is reported as method.
+func (r *SubscriptionRepo) withTenant(
+	ctx context.Context,
+	method string,
+	fn func(db dbtx) error,
+) (err error) {
+
+	defer func(start time.Time) {
+		d := time.Since(start)
+		if r.observe != nil {
+			r.observe(method, d, err)
+		}
+		slog.DebugContext(ctx, "Repository call",
+			"method", method,
+			"query_ms", float64(d.Microseconds())/1000,
+			"error", err,
+		)
+	}(time.Now())
+
+	if r.inTx {
+		return fn(r.db)
+	}
+
+	return runTx(ctx, r.db, true, fn)
+}
+
+// Create inserts a new subscription and records it in the audit log
+// and the outbox.
 func (r *SubscriptionRepo) Create(
 	ctx context.Context,
 	s domain.Subscription,
@@ -42,22 +119,95 @@ func (r *SubscriptionRepo) Create(
 	`
 
 	// Execute insert and scan timestamps
-	if err := r.pool.QueryRow(
-		ctx,
-		q,
-		s.ID,
-		s.ServiceName,
-		s.Price,
-		s.UserID,
-		s.StartDate,
-		s.EndDate,
-	).Scan(&s.CreatedAt, &s.UpdatedAt); err != nil {
+	if err := r.withTenant(ctx, "Create", func(db dbtx) error {
+		if err := db.QueryRow(
+			ctx,
+			q,
+			s.ID,
+			s.ServiceName,
+			s.Price,
+			s.UserID,
+			s.StartDate,
+			s.EndDate,
+		).Scan(&s.CreatedAt, &s.UpdatedAt); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, db, audit.ActionCreate, nil, &s); err != nil {
+			return err
+		}
+		return recordEvent(ctx, db, domain.EventSubscriptionCreated, s)
+	}); err != nil {
+		if isExclusionViolation(err) {
+			return domain.Subscription{}, ErrConflict
+		}
 		return domain.Subscription{}, fmt.Errorf("create subscription: %w", err)
 	}
 
 	return s, nil
 }
 
+// CreateMany bulk-inserts subscriptions using the COPY protocol.
+// The rows get the transaction time as created_at and updated_at, the
+// same as the column defaults, so the audit log and the outbox carry
+// the stored timestamps.
+func (r *SubscriptionRepo) CreateMany(
+	ctx context.Context,
+	subs []domain.Subscription,
+) (int64, error) {
+
+	columns := []string{
+		"id",
+		"service_name",
+		"price",
+		"user_id",
+		"start_date",
+		"end_date",
+		"created_at",
+		"updated_at",
+	}
+
+	// Stream rows into the table, tenant_id is filled by its column default
+	var n int64
+	err := r.withTenant(ctx, "CreateMany", func(db dbtx) error {
+		var now time.Time
+		if err := db.QueryRow(ctx, `SELECT now();`).Scan(&now); err != nil {
+			return err
+		}
+
+		stored := make([]domain.Subscription, len(subs))
+		for i, s := range subs {
+			s.CreatedAt, s.UpdatedAt = now, now
+			stored[i] = s
+		}
+
+		var err error
+		n, err = db.CopyFrom(
+			ctx,
+			pgx.Identifier{"subscriptions"},
+			columns,
+			pgx.CopyFromSlice(len(stored), func(i int) ([]any, error) {
+				s := stored[i]
+				return []any{s.ID, s.ServiceName, s.Price, s.UserID, s.StartDate, s.EndDate, s.CreatedAt, s.UpdatedAt}, nil
+			}),
+		)
+		if err != nil {
+			return err
+		}
+		if err := recordAuditCreates(ctx, db, stored); err != nil {
+			return err
+		}
+		return recordEventCreates(ctx, db, stored)
+	})
+	if err != nil {
+		if isExclusionViolation(err) {
+			return 0, ErrConflict
+		}
+		return 0, fmt.Errorf("copy subscriptions: %w", err)
+	}
+
+	return n, nil
+}
+
 // GetByID returns subscription by ID.
 func (r *SubscriptionRepo) GetByID(
 	ctx context.Context,
@@ -81,7 +231,51 @@ func (r *SubscriptionRepo) GetByID(
 	var s domain.Subscription
 
 	// Query single row by ID
-	if err := r.pool.QueryRow(ctx, q, id).Scan(
+	if err := r.withTenant(ctx, "GetByID", func(db dbtx) error {
+		return db.QueryRow(ctx, q, id).Scan(
+			&s.ID,
+			&s.ServiceName,
+			&s.Price,
+			&s.UserID,
+			&s.StartDate,
+			&s.EndDate,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		)
+	}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Subscription{}, ErrNotFound
+		}
+		return domain.Subscription{}, fmt.Errorf("get subscription by id: %w", err)
+	}
+
+	return s, nil
+}
+
+// getForUpdate returns the subscription and locks it until the end of the transaction.
+func getForUpdate(
+	ctx context.Context,
+	db dbtx,
+	id uuid.UUID,
+) (domain.Subscription, error) {
+
+	const q = `
+		SELECT
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			created_at,
+			updated_at
+		FROM subscriptions
+		WHERE id = $1
+		FOR UPDATE;
+	`
+
+	var s domain.Subscription
+	err := db.QueryRow(ctx, q, id).Scan(
 		&s.ID,
 		&s.ServiceName,
 		&s.Price,
@@ -90,17 +284,13 @@ func (r *SubscriptionRepo) GetByID(
 		&s.EndDate,
 		&s.CreatedAt,
 		&s.UpdatedAt,
-	); err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return domain.Subscription{}, ErrNotFound
-		}
-		return domain.Subscription{}, fmt.Errorf("get subscription by id: %w", err)
-	}
+	)
 
-	return s, nil
+	return s, err
 }
 
-// Update modifies an existing subscription.
+// Update modifies an existing subscription and records the change in the
+// audit log and the outbox.
 func (r *SubscriptionRepo) Update(
 	ctx context.Context,
 	s domain.Subscription,
@@ -120,26 +310,44 @@ func (r *SubscriptionRepo) Update(
 	`
 
 	// Update fields and timestamps
-	if err := r.pool.QueryRow(
-		ctx,
-		q,
-		s.ID,
-		s.ServiceName,
-		s.Price,
-		s.UserID,
-		s.StartDate,
-		s.EndDate,
-	).Scan(&s.CreatedAt, &s.UpdatedAt); err != nil {
+	if err := r.withTenant(ctx, "Update", func(db dbtx) error {
+		// Lock the current row for the audit snapshot
+		before, err := getForUpdate(ctx, db, s.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := db.QueryRow(
+			ctx,
+			q,
+			s.ID,
+			s.ServiceName,
+			s.Price,
+			s.UserID,
+			s.StartDate,
+			s.EndDate,
+		).Scan(&s.CreatedAt, &s.UpdatedAt); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, db, audit.ActionUpdate, &before, &s); err != nil {
+			return err
+		}
+		return recordEvent(ctx, db, domain.EventSubscriptionUpdated, s)
+	}); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return domain.Subscription{}, ErrNotFound
 		}
+		if isExclusionViolation(err) {
+			return domain.Subscription{}, ErrConflict
+		}
 		return domain.Subscription{}, fmt.Errorf("update subscription: %w", err)
 	}
 
 	return s, nil
 }
 
-// Delete removes subscription by ID.
+// Delete removes subscription by ID and records it in the audit log and
+// the outbox.
 func (r *SubscriptionRepo) Delete(
 	ctx context.Context,
 	id uuid.UUID,
@@ -147,33 +355,53 @@ func (r *SubscriptionRepo) Delete(
 
 	const q = `
 		DELETE FROM subscriptions
-		WHERE id = $1;
+		WHERE id = $1
+		RETURNING
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			created_at,
+			updated_at;
 	`
 
-	// Execute delete statement
-	affectedRows, err := r.pool.Exec(ctx, q, id)
+	// Execute delete statement, the removed row becomes the audit snapshot
+	err := r.withTenant(ctx, "Delete", func(db dbtx) error {
+		var s domain.Subscription
+		if err := db.QueryRow(ctx, q, id).Scan(
+			&s.ID,
+			&s.ServiceName,
+			&s.Price,
+			&s.UserID,
+			&s.StartDate,
+			&s.EndDate,
+			&s.CreatedAt,
+			&s.UpdatedAt,
+		); err != nil {
+			return err
+		}
+		if err := recordAudit(ctx, db, audit.ActionDelete, &s, nil); err != nil {
+			return err
+		}
+		return recordEvent(ctx, db, domain.EventSubscriptionDeleted, s)
+	})
 	if err != nil {
+		// No row means nothing was deleted
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
 		return fmt.Errorf("delete subscription: %w", err)
 	}
 
-	// Check affected rows
-	if affectedRows.RowsAffected() == 0 {
-		return ErrNotFound
-	}
-
 	return nil
 }
 
-// ListFilter defines optional list filters.
-type ListFilter struct {
-	UserID      *uuid.UUID
-	ServiceName *string
-}
-
 // List returns subscriptions with optional filters.
 func (r *SubscriptionRepo) List(
 	ctx context.Context,
-	f ListFilter,
+	f domain.ListFilter,
 ) ([]domain.Subscription, error) {
 
 	const q = `
@@ -186,52 +414,74 @@ func (r *SubscriptionRepo) List(
 			end_date,
 			created_at,
 			updated_at
-		FROM subscriptions
+		FROM %s
 		WHERE ($1::uuid IS NULL OR user_id = $1)
 		  AND ($2::text IS NULL OR service_name = $2)
-		ORDER BY created_at DESC;
+		  AND ($3::uuid[] IS NULL OR user_id = ANY($3))
+		  AND ($4::text[] IS NULL OR service_name = ANY($4))
+		  AND ($5::timestamptz IS NULL OR (created_at, id) < ($5, $6::uuid))
+		ORDER BY created_at DESC, id DESC;
 	`
 
-	// Query filtered subscriptions
-	rows, err := r.pool.Query(ctx, q, f.UserID, f.ServiceName)
-	if err != nil {
-		return nil, fmt.Errorf("list subscriptions: %w", err)
-	}
-	defer rows.Close()
+	afterTime, afterID := cursorArgs(f.After)
+	from, args := subscriptionsSource(f.AsOf, f.UserID, f.ServiceName, f.UserIDs, f.ServiceNames, afterTime, afterID)
 
 	var out []domain.Subscription
-	for rows.Next() {
-		var s domain.Subscription
-		if err := rows.Scan(
-			&s.ID,
-			&s.ServiceName,
-			&s.Price,
-			&s.UserID,
-			&s.StartDate,
-			&s.EndDate,
-			&s.CreatedAt,
-			&s.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("list scan: %w", err)
+	err := r.withTenant(ctx, "List", func(db dbtx) error {
+		// Query filtered subscriptions
+		rows, err := db.Query(ctx, fmt.Sprintf(q, from), args...)
+		if err != nil {
+			return fmt.Errorf("list subscriptions: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var s domain.Subscription
+			if err := rows.Scan(
+				&s.ID,
+				&s.ServiceName,
+				&s.Price,
+				&s.UserID,
+				&s.StartDate,
+				&s.EndDate,
+				&s.CreatedAt,
+				&s.UpdatedAt,
+			); err != nil {
+				return fmt.Errorf("list scan: %w", err)
+			}
+			out = append(out, s)
 		}
-		out = append(out, s)
-	}
 
-	// Check iteration errors
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("list rows: %w", err)
+		// Check iteration errors
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("list rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return out, nil
 }
 
+// cursorArgs splits an optional list cursor into query arguments.
+func cursorArgs(c *domain.Cursor) (*time.Time, *uuid.UUID) {
+	if c == nil {
+		return nil, nil
+	}
+	return &c.CreatedAt, &c.ID
+}
+
 // ListOverlapping returns subscriptions overlapping period.
+// With asOf set, rows are read as they were at that moment.
 func (r *SubscriptionRepo) ListOverlapping(
 	ctx context.Context,
 	userID *uuid.UUID,
 	serviceName *string,
 	periodStart,
 	periodEnd time.Time,
+	asOf *time.Time,
 ) ([]domain.Subscription, error) {
 
 	const q = `
@@ -244,7 +494,7 @@ func (r *SubscriptionRepo) ListOverlapping(
 			end_date,
 			created_at,
 			updated_at
-		FROM subscriptions
+		FROM %s
 		WHERE ($1::uuid IS NULL OR user_id = $1)
 		  AND ($2::text IS NULL OR service_name = $2)
 		  AND start_date <= $4
@@ -252,35 +502,129 @@ func (r *SubscriptionRepo) ListOverlapping(
 		ORDER BY start_date ASC;
 	`
 
-	// Query overlapping subscriptions
-	rows, err := r.pool.Query(ctx, q, userID, serviceName, periodStart, periodEnd)
-	if err != nil {
-		return nil, fmt.Errorf("list overlapping: %w", err)
-	}
-	defer rows.Close()
+	from, args := subscriptionsSource(asOf, userID, serviceName, periodStart, periodEnd)
 
 	var out []domain.Subscription
-	for rows.Next() {
-		var s domain.Subscription
-		if err := rows.Scan(
-			&s.ID,
-			&s.ServiceName,
-			&s.Price,
-			&s.UserID,
-			&s.StartDate,
-			&s.EndDate,
-			&s.CreatedAt,
-			&s.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("overlapping scan: %w", err)
+	err := r.withTenant(ctx, "ListOverlapping", func(db dbtx) error {
+		// Query overlapping subscriptions
+		rows, err := db.Query(ctx, fmt.Sprintf(q, from), args...)
+		if err != nil {
+			return fmt.Errorf("list overlapping: %w", err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var s domain.Subscription
+			if err := rows.Scan(
+				&s.ID,
+				&s.ServiceName,
+				&s.Price,
+				&s.UserID,
+				&s.StartDate,
+				&s.EndDate,
+				&s.CreatedAt,
+				&s.UpdatedAt,
+			); err != nil {
+				return fmt.Errorf("overlapping scan: %w", err)
+			}
+			out = append(out, s)
 		}
-		out = append(out, s)
-	}
 
-	// Check iteration errors
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("overlapping rows: %w", err)
+		// Check iteration errors
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("overlapping rows: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return out, nil
 }
+
+// streamBatchSize is the number of rows fetched per cursor round trip.
+const streamBatchSize = 500
+
+// Stream passes subscriptions matching the filter to fn one by one.
+// Rows are read through a server-side cursor in batches, so memory use
+// does not grow with the result size. Returning an error from fn stops
+// the stream.
+func (r *SubscriptionRepo) Stream(
+	ctx context.Context,
+	f domain.ListFilter,
+	fn func(domain.Subscription) error,
+) error {
+
+	const declare = `
+		DECLARE subscriptions_stream NO SCROLL CURSOR FOR
+		SELECT
+			id,
+			service_name,
+			price,
+			user_id,
+			start_date,
+			end_date,
+			created_at,
+			updated_at
+		FROM %s
+		WHERE ($1::uuid IS NULL OR user_id = $1)
+		  AND ($2::text IS NULL OR service_name = $2)
+		  AND ($3::uuid[] IS NULL OR user_id = ANY($3))
+		  AND ($4::text[] IS NULL OR service_name = ANY($4))
+		  AND ($5::timestamptz IS NULL OR (created_at, id) < ($5, $6::uuid))
+		ORDER BY created_at DESC, id DESC;
+	`
+
+	afterTime, afterID := cursorArgs(f.After)
+	from, args := subscriptionsSource(f.AsOf, f.UserID, f.ServiceName, f.UserIDs, f.ServiceNames, afterTime, afterID)
+	fetch := fmt.Sprintf("FETCH %d FROM subscriptions_stream;", streamBatchSize)
+
+	// Cursors only live inside a transaction
+	return r.withTenant(ctx, "Stream", func(db dbtx) error {
+		if _, err := db.Exec(ctx, fmt.Sprintf(declare, from), args...); err != nil {
+			return fmt.Errorf("declare stream cursor: %w", err)
+		}
+
+		for {
+			rows, err := db.Query(ctx, fetch)
+			if err != nil {
+				return fmt.Errorf("fetch stream: %w", err)
+			}
+
+			n := 0
+			for rows.Next() {
+				var s domain.Subscription
+				if err := rows.Scan(
+					&s.ID,
+					&s.ServiceName,
+					&s.Price,
+					&s.UserID,
+					&s.StartDate,
+					&s.EndDate,
+					&s.CreatedAt,
+					&s.UpdatedAt,
+				); err != nil {
+					rows.Close()
+					return fmt.Errorf("stream scan: %w", err)
+				}
+				n++
+
+				if err := fn(s); err != nil {
+					rows.Close()
+					return err
+				}
+			}
+
+			// Check iteration errors
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("stream rows: %w", err)
+			}
+
+			// Short batch means the cursor is exhausted
+			if n < streamBatchSize {
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testPool connects to TEST_DATABASE_URL or skips the test.
+// The database must be migrated and the role must not bypass
+// row-level security (no superuser, no BYPASSRLS).
+func testPool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, dsn)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	var bypass bool
+	if err := pool.QueryRow(ctx, `
+		SELECT rolsuper OR rolbypassrls FROM pg_roles WHERE rolname = current_user;
+	`).Scan(&bypass); err != nil {
+		t.Fatalf("check role: %v", err)
+	}
+	if bypass {
+		t.Skip("test role bypasses row-level security")
+	}
+
+	return pool
+}
+
+// ====================================
+// Tenant isolation
+// ====================================
+
+func TestTenantIsolation_CrossTenantReadsReturnNothing(t *testing.T) {
+	// Arrange
+	repo := NewSubscriptionRepo(testPool(t))
+
+	ctxA := tenant.WithID(context.Background(), "test-tenant-a")
+	ctxB := tenant.WithID(context.Background(), "test-tenant-b")
+
+	newSub := func() domain.Subscription {
+		return domain.Subscription{
+			ID:          uuid.New(),
+			ServiceName: "Isolation " + uuid.NewString()[:8],
+			Price:       100,
+			UserID:      uuid.New(),
+			StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+	}
+
+	subA, err := repo.Create(ctxA, newSub())
+	if err != nil {
+		t.Fatalf("create in tenant a: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Delete(ctxA, subA.ID) })
+
+	subB, err := repo.Create(ctxB, newSub())
+	if err != nil {
+		t.Fatalf("create in tenant b: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Delete(ctxB, subB.ID) })
+
+	// Act
+	_, getErr := repo.GetByID(ctxA, subB.ID)
+	listed, listErr := repo.List(ctxA, domain.ListFilter{UserID: &subB.UserID})
+	overlapping, overlapErr := repo.ListOverlapping(ctxA, &subB.UserID, nil,
+		time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC), nil)
+
+	streamed := 0
+	streamErr := repo.Stream(ctxA, domain.ListFilter{UserID: &subB.UserID}, func(domain.Subscription) error {
+		streamed++
+		return nil
+	})
+
+	// Assert
+	if !errors.Is(getErr, ErrNotFound) {
+		t.Errorf("get: expected ErrNotFound, got %v", getErr)
+	}
+	if listErr != nil || len(listed) != 0 {
+		t.Errorf("list: expected no rows, got %d (%v)", len(listed), listErr)
+	}
+	if overlapErr != nil || len(overlapping) != 0 {
+		t.Errorf("overlapping: expected no rows, got %d (%v)", len(overlapping), overlapErr)
+	}
+	if streamErr != nil || streamed != 0 {
+		t.Errorf("stream: expected no rows, got %d (%v)", streamed, streamErr)
+	}
+
+	// Own rows stay visible
+	if _, err := repo.GetByID(ctxA, subA.ID); err != nil {
+		t.Errorf("get own row: %v", err)
+	}
+}
+
+func TestTenantIsolation_CrossTenantWritesAffectNothing(t *testing.T) {
+	// Arrange
+	repo := NewSubscriptionRepo(testPool(t))
+
+	ctxA := tenant.WithID(context.Background(), "test-tenant-a")
+	ctxB := tenant.WithID(context.Background(), "test-tenant-b")
+
+	sub, err := repo.Create(ctxB, domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Isolation " + uuid.NewString()[:8],
+		Price:       100,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("create in tenant b: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Delete(ctxB, sub.ID) })
+
+	// Act
+	changed := sub
+	changed.Price = 999
+	_, updateErr := repo.Update(ctxA, changed)
+	deleteErr := repo.Delete(ctxA, sub.ID)
+
+	// Assert
+	if !errors.Is(updateErr, ErrNotFound) {
+		t.Errorf("update: expected ErrNotFound, got %v", updateErr)
+	}
+	if !errors.Is(deleteErr, ErrNotFound) {
+		t.Errorf("delete: expected ErrNotFound, got %v", deleteErr)
+	}
+
+	got, err := repo.GetByID(ctxB, sub.ID)
+	if err != nil || got.Price != sub.Price {
+		t.Errorf("expected row unchanged, got %+v (%v)", got, err)
+	}
+}
+
+func TestTenantIsolation_MissingTenant(t *testing.T) {
+	// Arrange
+	repo := NewSubscriptionRepo(testPool(t))
+
+	// Act
+	_, err := repo.List(context.Background(), domain.ListFilter{})
+
+	// Assert
+	if !errors.Is(err, tenant.ErrMissing) {
+		t.Fatalf("expected tenant.ErrMissing, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+)
+
+// runTx runs fn inside a transaction, or a savepoint when db already is
+// one, and commits if fn returns nil. With applyTenant the tenant from
+// ctx is set for the transaction first.
+func runTx(
+	ctx context.Context,
+	db dbtx,
+	applyTenant bool,
+	fn func(tx dbtx) error,
+) error {
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+
+	// Rollback is a no-op once the transaction is committed
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	if applyTenant {
+		if err := setTenant(ctx, tx); err != nil {
+			return err
+		}
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit tx: %w", err)
+	}
+
+	return nil
+}
+
+// setTenant applies the tenant from ctx to the current transaction,
+// the equivalent of SET LOCAL app.tenant_id with a bound parameter.
+func setTenant(ctx context.Context, db dbtx) error {
+	id, err := tenant.FromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := db.Exec(ctx, "SELECT set_config('app.tenant_id', $1, true);", id); err != nil {
+		return fmt.Errorf("set tenant: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,516 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/webhook"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WebhookRepo provides webhook and delivery persistence.
+type WebhookRepo struct {
+	db dbtx
+}
+
+// WebhookRepo is the store of the webhook dispatcher.
+var _ webhook.Store = (*WebhookRepo)(nil)
+
+// NewWebhookRepo creates a new repository instance.
+func NewWebhookRepo(pool *pgxpool.Pool) *WebhookRepo {
+	return &WebhookRepo{db: pool}
+}
+
+// deliveryColumns are the webhook_deliveries columns read by scanDelivery.
+const deliveryColumns = `
+	d.id,
+	d.webhook_id,
+	d.event_id,
+	d.event,
+	d.payload,
+	d.status,
+	d.attempts,
+	d.next_attempt_at,
+	d.response_status,
+	d.last_error,
+	d.delivered_at,
+	d.created_at`
+
+// scanDelivery reads deliveryColumns, followed by extra destinations.
+func scanDelivery(row pgx.Row, extra ...any) (domain.WebhookDelivery, error) {
+	var (
+		d       domain.WebhookDelivery
+		payload []byte
+	)
+	dest := append([]any{
+		&d.ID,
+		&d.WebhookID,
+		&d.EventID,
+		&d.Event,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.ResponseStatus,
+		&d.LastError,
+		&d.DeliveredAt,
+		&d.CreatedAt,
+	}, extra...)
+
+	if err := row.Scan(dest...); err != nil {
+		return domain.WebhookDelivery{}, err
+	}
+	d.Payload = payload
+
+	return d, nil
+}
+
+// Create registers a webhook.
+func (r *WebhookRepo) Create(
+	ctx context.Context,
+	w domain.Webhook,
+) (domain.Webhook, error) {
+
+	const q = `
+		INSERT INTO webhooks (id, url, secret, events)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at;
+	`
+
+	if w.Events == nil {
+		w.Events = []string{}
+	}
+
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		return db.QueryRow(ctx, q, w.ID, w.URL, w.Secret, w.Events).Scan(&w.CreatedAt)
+	})
+	if err != nil {
+		return domain.Webhook{}, fmt.Errorf("create webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// Get returns a webhook by ID.
+func (r *WebhookRepo) Get(ctx context.Context, id uuid.UUID) (domain.Webhook, error) {
+	const q = `
+		SELECT id, url, secret, events, created_at
+		FROM webhooks
+		WHERE id = $1;
+	`
+
+	var w domain.Webhook
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		return db.QueryRow(ctx, q, id).Scan(&w.ID, &w.URL, &w.Secret, &w.Events, &w.CreatedAt)
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.Webhook{}, ErrNotFound
+		}
+		return domain.Webhook{}, fmt.Errorf("get webhook: %w", err)
+	}
+
+	return w, nil
+}
+
+// List returns all webhooks, newest first.
+func (r *WebhookRepo) List(ctx context.Context) ([]domain.Webhook, error) {
+	const q = `
+		SELECT id, url, secret, events, created_at
+		FROM webhooks
+		ORDER BY created_at DESC, id;
+	`
+
+	var out []domain.Webhook
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		rows, err := db.Query(ctx, q)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var w domain.Webhook
+			if err := rows.Scan(&w.ID, &w.URL, &w.Secret, &w.Events, &w.CreatedAt); err != nil {
+				return err
+			}
+			out = append(out, w)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list webhooks: %w", err)
+	}
+
+	return out, nil
+}
+
+// Delete removes a webhook together with its deliveries.
+func (r *WebhookRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	const q = `DELETE FROM webhooks WHERE id = $1;`
+
+	var deleted int64
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		tag, err := db.Exec(ctx, q, id)
+		deleted = tag.RowsAffected()
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("delete webhook: %w", err)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeliveryFilter defines the deliveries of a webhook to list.
+type DeliveryFilter struct {
+	WebhookID uuid.UUID
+	Status    *string
+	Limit     int
+}
+
+// Deliveries returns deliveries of a webhook, newest first.
+// A missing webhook is reported as ErrNotFound.
+func (r *WebhookRepo) Deliveries(
+	ctx context.Context,
+	f DeliveryFilter,
+) ([]domain.WebhookDelivery, error) {
+
+	const (
+		qExists = `SELECT EXISTS (SELECT 1 FROM webhooks WHERE id = $1);`
+		q       = `
+			SELECT ` + deliveryColumns + `
+			FROM webhook_deliveries d
+			WHERE d.webhook_id = $1
+			  AND ($2::text IS NULL OR d.status = $2)
+			ORDER BY d.created_at DESC, d.id DESC
+			LIMIT $3;
+		`
+	)
+
+	var (
+		out    []domain.WebhookDelivery
+		exists bool
+	)
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		if err := db.QueryRow(ctx, qExists, f.WebhookID).Scan(&exists); err != nil || !exists {
+			return err
+		}
+
+		rows, err := db.Query(ctx, q, f.WebhookID, f.Status, f.Limit)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			d, err := scanDelivery(rows)
+			if err != nil {
+				return err
+			}
+			out = append(out, d)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list webhook deliveries: %w", err)
+	}
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return out, nil
+}
+
+// Redeliver schedules a delivery of the webhook to be sent again now,
+// with a fresh budget of attempts, whatever its current status.
+func (r *WebhookRepo) Redeliver(
+	ctx context.Context,
+	webhookID uuid.UUID,
+	deliveryID int64,
+) (domain.WebhookDelivery, error) {
+
+	const q = `
+		UPDATE webhook_deliveries d
+		SET status = 'pending',
+			attempts = 0,
+			next_attempt_at = now(),
+			response_status = NULL,
+			last_error = NULL,
+			delivered_at = NULL
+		WHERE d.id = $1 AND d.webhook_id = $2
+		RETURNING ` + deliveryColumns + `;
+	`
+
+	var d domain.WebhookDelivery
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		var err error
+		d, err = scanDelivery(db.QueryRow(ctx, q, deliveryID, webhookID))
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return domain.WebhookDelivery{}, ErrNotFound
+		}
+		return domain.WebhookDelivery{}, fmt.Errorf("redeliver webhook delivery: %w", err)
+	}
+
+	return d, nil
+}
+
+// Enqueue adds a pending delivery of an event to every webhook wanting it.
+// An event already queued for a webhook is skipped.
+func (r *WebhookRepo) Enqueue(
+	ctx context.Context,
+	eventID uuid.UUID,
+	typ string,
+	payload []byte,
+) (int, error) {
+
+	var n int
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		var err error
+		n, err = enqueueDeliveries(ctx, db, eventID, typ, payload)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// enqueueDeliveries inserts the deliveries of an event within db's transaction.
+func enqueueDeliveries(
+	ctx context.Context,
+	db dbtx,
+	eventID uuid.UUID,
+	typ string,
+	payload []byte,
+) (int, error) {
+
+	const q = `
+		INSERT INTO webhook_deliveries (webhook_id, event_id, event, payload)
+		SELECT w.id, $1, $2, $3
+		FROM webhooks w
+		WHERE cardinality(w.events) = 0 OR $2 = ANY (w.events)
+		ON CONFLICT (webhook_id, event_id) DO NOTHING;
+	`
+
+	tag, err := db.Exec(ctx, q, eventID, typ, payload)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue webhook deliveries: %w", err)
+	}
+
+	return int(tag.RowsAffected()), nil
+}
+
+// EnqueueLifecycle emits subscription.started for subscriptions that
+// started by month and subscription.ended for those whose last month was
+// before it. Emitted events are recorded, so each is sent once, and
+// months missed while no worker ran are caught up.
+func (r *WebhookRepo) EnqueueLifecycle(
+	ctx context.Context,
+	month time.Time,
+	encode func(domain.Event) ([]byte, error),
+) (int, error) {
+
+	const (
+		qDue = `
+			SELECT
+				s.id,
+				s.service_name,
+				s.price,
+				s.user_id,
+				s.start_date,
+				s.end_date,
+				s.created_at,
+				s.updated_at,
+				due.event
+			FROM subscriptions s
+			JOIN LATERAL (
+				SELECT $1::text AS event WHERE s.start_date <= $3
+				UNION ALL
+				SELECT $2::text WHERE s.end_date <= $4
+			) due ON true
+			WHERE (s.start_date <= $3 OR s.end_date <= $4)
+			  AND NOT EXISTS (
+				SELECT 1
+				FROM subscription_lifecycle_events e
+				WHERE e.subscription_id = s.id
+				  AND e.event = due.event
+			  );
+		`
+		qMark = `
+			INSERT INTO subscription_lifecycle_events (subscription_id, event)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING;
+		`
+	)
+
+	var events []domain.Event
+	n := 0
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		rows, err := db.Query(ctx, qDue,
+			domain.EventSubscriptionStarted,
+			domain.EventSubscriptionEnded,
+			month,
+			month.AddDate(0, -1, 0))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var (
+				s   domain.Subscription
+				typ string
+			)
+			if err := rows.Scan(
+				&s.ID,
+				&s.ServiceName,
+				&s.Price,
+				&s.UserID,
+				&s.StartDate,
+				&s.EndDate,
+				&s.CreatedAt,
+				&s.UpdatedAt,
+				&typ,
+			); err != nil {
+				return err
+			}
+			events = append(events, domain.NewEvent(typ, s))
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		// Record and enqueue in the same transaction, so no event is lost
+		for _, ev := range events {
+			tag, err := db.Exec(ctx, qMark, ev.Subscription.ID, ev.Type)
+			if err != nil {
+				return err
+			}
+			if tag.RowsAffected() == 0 {
+				continue // emitted concurrently by another instance
+			}
+
+			payload, err := encode(ev)
+			if err != nil {
+				return err
+			}
+			added, err := enqueueDeliveries(ctx, db, ev.ID, ev.Type, payload)
+			if err != nil {
+				return err
+			}
+			n += added
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("enqueue lifecycle events: %w", err)
+	}
+
+	return n, nil
+}
+
+// Tenants returns the tenants that have webhooks.
+func (r *WebhookRepo) Tenants(ctx context.Context) ([]string, error) {
+	rows, err := r.db.Query(ctx, `SELECT webhook_tenants();`)
+	if err != nil {
+		return nil, fmt.Errorf("list webhook tenants: %w", err)
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, fmt.Errorf("list webhook tenants: %w", err)
+	}
+
+	return ids, nil
+}
+
+// ClaimDue locks due deliveries, skipping those claimed by other
+// workers, and moves their next attempt lease into the future.
+func (r *WebhookRepo) ClaimDue(
+	ctx context.Context,
+	limit int,
+	lease time.Duration,
+) ([]webhook.Job, error) {
+
+	const q = `
+		WITH due AS (
+			SELECT id
+			FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at, id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = now() + make_interval(secs => $2)
+		FROM due, webhooks w
+		WHERE d.id = due.id AND w.id = d.webhook_id
+		RETURNING ` + deliveryColumns + `, w.url, w.secret;
+	`
+
+	var jobs []webhook.Job
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		rows, err := db.Query(ctx, q, limit, lease.Seconds())
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var job webhook.Job
+			job.Delivery, err = scanDelivery(rows, &job.URL, &job.Secret)
+			if err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("claim webhook deliveries: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// RecordAttempt stores the outcome of a delivery attempt.
+func (r *WebhookRepo) RecordAttempt(
+	ctx context.Context,
+	id int64,
+	a webhook.Attempt,
+) error {
+
+	const q = `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1,
+			status = $2,
+			response_status = $3,
+			last_error = $4,
+			next_attempt_at = CASE WHEN $2 = 'pending' THEN $5 ELSE next_attempt_at END,
+			delivered_at = CASE WHEN $2 = 'delivered' THEN now() END
+		WHERE id = $1;
+	`
+
+	err := runTx(ctx, r.db, true, func(db dbtx) error {
+		_, err := db.Exec(ctx, q, id, a.Status, a.ResponseStatus, a.Error, a.NextAttemptAt)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("record webhook attempt: %w", err)
+	}
+
+	return nil
+}
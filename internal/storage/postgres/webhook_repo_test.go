@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/webhook"
+	"github.com/google/uuid"
+)
+
+func TestWebhookRepo_DeliveryLifecycle(t *testing.T) {
+	// Arrange
+	pool := testPool(t)
+	repo := NewWebhookRepo(pool)
+	ctx := tenant.WithID(context.Background(), "test-tenant-a")
+	other := tenant.WithID(context.Background(), "test-tenant-b")
+
+	all, err := repo.Create(ctx, domain.Webhook{ID: uuid.New(), URL: "http://localhost/all", Secret: "secret"})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	deletes, err := repo.Create(ctx, domain.Webhook{
+		ID:     uuid.New(),
+		URL:    "http://localhost/deletes",
+		Secret: "secret",
+		Events: []string{domain.EventSubscriptionDeleted},
+	})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = repo.Delete(ctx, all.ID)
+		_ = repo.Delete(ctx, deletes.ID)
+	})
+
+	ev := domain.NewEvent(domain.EventSubscriptionCreated, domain.Subscription{ID: uuid.New()})
+
+	// Act
+	n, err := repo.Enqueue(ctx, ev.ID, ev.Type, []byte(`{"type":"subscription.created"}`))
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	jobs, err := repo.ClaimDue(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	again, err := repo.ClaimDue(ctx, 100, time.Minute)
+	if err != nil {
+		t.Fatalf("claim again: %v", err)
+	}
+	foreign, err := repo.Deliveries(other, DeliveryFilter{WebhookID: all.ID, Limit: 10})
+
+	// Assert
+	if n != 1 {
+		t.Fatalf("expected 1 delivery for the unfiltered webhook, got %d", n)
+	}
+
+	var job *webhook.Job
+	for i := range jobs {
+		if jobs[i].Delivery.EventID == ev.ID {
+			job = &jobs[i]
+		}
+	}
+	if job == nil || job.URL != all.URL || job.Secret != "secret" {
+		t.Fatalf("expected claimed delivery to the unfiltered webhook, got %+v", jobs)
+	}
+	for _, j := range again {
+		if j.Delivery.ID == job.Delivery.ID {
+			t.Error("claimed delivery was claimed again within its lease")
+		}
+	}
+	if !errors.Is(err, ErrNotFound) || foreign != nil {
+		t.Errorf("expected other tenant not to see the webhook, got %v %v", foreign, err)
+	}
+
+	// Record a failed and a successful attempt
+	status := http.StatusOK
+	if err := repo.RecordAttempt(ctx, job.Delivery.ID, webhook.Attempt{
+		Status:        domain.DeliveryPending,
+		NextAttemptAt: time.Now().Add(time.Minute),
+	}); err != nil {
+		t.Fatalf("record failure: %v", err)
+	}
+	if err := repo.RecordAttempt(ctx, job.Delivery.ID, webhook.Attempt{
+		Status:         domain.DeliveryDelivered,
+		ResponseStatus: &status,
+	}); err != nil {
+		t.Fatalf("record success: %v", err)
+	}
+
+	log, err := repo.Deliveries(ctx, DeliveryFilter{WebhookID: all.ID, Limit: 10})
+	if err != nil || len(log) != 1 {
+		t.Fatalf("expected 1 logged delivery, got %d: %v", len(log), err)
+	}
+	d := log[0]
+	if d.Status != domain.DeliveryDelivered || d.Attempts != 2 || d.DeliveredAt == nil {
+		t.Errorf("unexpected delivery: %+v", d)
+	}
+
+	// Redelivery starts over
+	d, err = repo.Redeliver(ctx, all.ID, d.ID)
+	if err != nil {
+		t.Fatalf("redeliver: %v", err)
+	}
+	if d.Status != domain.DeliveryPending || d.Attempts != 0 || d.DeliveredAt != nil {
+		t.Errorf("unexpected redelivery: %+v", d)
+	}
+}
+
+func TestWebhookRepo_LifecycleEventsOnce(t *testing.T) {
+	// Arrange
+	pool := testPool(t)
+	repo := NewWebhookRepo(pool)
+	subs := NewSubscriptionRepo(pool)
+	ctx := tenant.WithID(context.Background(), "test-lifecycle-"+uuid.NewString()[:8])
+
+	hook, err := repo.Create(ctx, domain.Webhook{
+		ID:     uuid.New(),
+		URL:    "http://localhost/lifecycle",
+		Secret: "secret",
+		Events: []string{domain.EventSubscriptionStarted, domain.EventSubscriptionEnded},
+	})
+	if err != nil {
+		t.Fatalf("create webhook: %v", err)
+	}
+	t.Cleanup(func() { _ = repo.Delete(ctx, hook.ID) })
+
+	// Started this month, ended a while ago without a scan in between,
+	// not started yet
+	month := time.Date(2031, 3, 1, 0, 0, 0, 0, time.UTC)
+	ended := month.AddDate(0, -3, 0)
+	for _, s := range []domain.Subscription{
+		{StartDate: month},
+		{StartDate: month.AddDate(-1, 0, 0), EndDate: &ended},
+		{StartDate: month.AddDate(0, 1, 0)},
+	} {
+		s.ID = uuid.New()
+		s.ServiceName = "Lifecycle " + uuid.NewString()[:8]
+		s.UserID = uuid.New()
+		if _, err := subs.Create(ctx, s); err != nil {
+			t.Fatalf("create subscription: %v", err)
+		}
+		t.Cleanup(func() { _ = subs.Delete(ctx, s.ID) })
+	}
+
+	// Act
+	first, err := repo.EnqueueLifecycle(ctx, month, outbox.Encode)
+	if err != nil {
+		t.Fatalf("first scan: %v", err)
+	}
+	second, err := repo.EnqueueLifecycle(ctx, month.AddDate(0, 1, 0), outbox.Encode)
+	if err != nil {
+		t.Fatalf("second scan: %v", err)
+	}
+
+	// Assert: three started and one ended event, each emitted once
+	if first != 3 || second != 1 {
+		t.Errorf("expected 3 then 1 deliveries, got %d then %d", first, second)
+	}
+}
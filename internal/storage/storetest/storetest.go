@@ -0,0 +1,550 @@
+// Package storetest is a conformance suite for domain.SubscriptionStore
+// implementations. Every backend runs it from its own tests.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+	"github.com/google/uuid"
+)
+
+// Options describe how the stores under test are configured.
+type Options struct {
+	// AllowOverlaps is set for stores without the overlap constraint,
+	// which must accept overlapping subscriptions
+	AllowOverlaps bool
+}
+
+// Run runs the suite against stores returned by newStore. Each test works
+// in a fresh random tenant with random users, so a shared database can
+// be used.
+func Run(t *testing.T, opts Options, newStore func(t *testing.T) domain.SubscriptionStore) {
+	overlap, createMany := testOverlap, testCreateMany
+	if opts.AllowOverlaps {
+		overlap, createMany = testOverlapAllowed, testCreateManyOverlapping
+	}
+
+	tests := []struct {
+		name string
+		fn   func(t *testing.T, ctx context.Context, s domain.SubscriptionStore)
+	}{
+		{"CreateAndGet", testCreateAndGet},
+		{"GetMissing", testGetMissing},
+		{"Update", testUpdate},
+		{"Delete", testDelete},
+		{"Overlap", overlap},
+		{"CreateMany", createMany},
+		{"List", testList},
+		{"ListOverlapping", testListOverlapping},
+		{"Stream", testStream},
+		{"AsOf", testAsOf},
+		{"InTx", testInTx},
+		{"TenantIsolation", testTenantIsolation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.fn(t, newTenant(), newStore(t))
+		})
+	}
+}
+
+// newTenant returns a context with a tenant unique to the test.
+func newTenant() context.Context {
+	return tenant.WithID(context.Background(), "storetest-"+uuid.NewString()[:8])
+}
+
+// month returns the first day of a month in 2025.
+func month(m time.Month) time.Time {
+	return time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// newSub returns a subscription of user to service for the given months,
+// open ended when end is zero.
+func newSub(user uuid.UUID, service string, start, end time.Time) domain.Subscription {
+	s := domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: service,
+		Price:       100,
+		UserID:      user,
+		StartDate:   start,
+	}
+	if !end.IsZero() {
+		s.EndDate = &end
+	}
+	return s
+}
+
+// mustCreate creates s or fails the test.
+func mustCreate(t *testing.T, ctx context.Context, st domain.SubscriptionStore, s domain.Subscription) domain.Subscription {
+	t.Helper()
+
+	out, err := st.Create(ctx, s)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	return out
+}
+
+// ids returns the IDs of subs in order.
+func ids(subs []domain.Subscription) []uuid.UUID {
+	out := make([]uuid.UUID, len(subs))
+	for i, s := range subs {
+		out[i] = s.ID
+	}
+	return out
+}
+
+// sameIDs reports whether got holds exactly want, in order.
+func sameIDs(got []domain.Subscription, want ...domain.Subscription) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i].ID != want[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+// ====================================
+// CRUD
+// ====================================
+
+func testCreateAndGet(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	in := newSub(uuid.New(), "Netflix", month(1), month(6))
+
+	// Act
+	created, createErr := st.Create(ctx, in)
+	got, getErr := st.GetByID(ctx, in.ID)
+
+	// Assert
+	if createErr != nil || getErr != nil {
+		t.Fatalf("unexpected errors: %v, %v", createErr, getErr)
+	}
+	if created.CreatedAt.IsZero() || created.UpdatedAt.IsZero() {
+		t.Errorf("expected timestamps, got %+v", created)
+	}
+	if got.ID != in.ID || got.ServiceName != "Netflix" || got.Price != 100 || got.UserID != in.UserID {
+		t.Errorf("unexpected subscription: %+v", got)
+	}
+	if !got.StartDate.Equal(month(1)) || got.EndDate == nil || !got.EndDate.Equal(month(6)) {
+		t.Errorf("unexpected dates: %v - %v", got.StartDate, got.EndDate)
+	}
+}
+
+func testGetMissing(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Act
+	_, err := st.GetByID(ctx, uuid.New())
+	_, asOfErr := st.GetByIDAsOf(ctx, uuid.New(), time.Now())
+
+	// Assert
+	if !errors.Is(err, domain.ErrNotFound) || !errors.Is(asOfErr, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v, %v", err, asOfErr)
+	}
+}
+
+func testUpdate(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	created := mustCreate(t, ctx, st, newSub(uuid.New(), "Netflix", month(1), time.Time{}))
+
+	// Act
+	upd := created
+	upd.Price = 250
+	upd.ServiceName = "Spotify"
+	updated, err := st.Update(ctx, upd)
+	got, getErr := st.GetByID(ctx, created.ID)
+	_, missingErr := st.Update(ctx, newSub(uuid.New(), "Netflix", month(1), time.Time{}))
+
+	// Assert
+	if err != nil || getErr != nil {
+		t.Fatalf("unexpected errors: %v, %v", err, getErr)
+	}
+	if got.Price != 250 || got.ServiceName != "Spotify" || got.EndDate != nil {
+		t.Errorf("update not stored: %+v", got)
+	}
+	if !updated.CreatedAt.Equal(created.CreatedAt) || updated.UpdatedAt.Before(created.UpdatedAt) {
+		t.Errorf("unexpected timestamps: created %v/%v, updated %v/%v",
+			created.CreatedAt, created.UpdatedAt, updated.CreatedAt, updated.UpdatedAt)
+	}
+	if !errors.Is(missingErr, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for missing row, got %v", missingErr)
+	}
+}
+
+func testDelete(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	created := mustCreate(t, ctx, st, newSub(uuid.New(), "Netflix", month(1), time.Time{}))
+
+	// Act
+	err := st.Delete(ctx, created.ID)
+	_, getErr := st.GetByID(ctx, created.ID)
+	againErr := st.Delete(ctx, created.ID)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !errors.Is(getErr, domain.ErrNotFound) || !errors.Is(againErr, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v, %v", getErr, againErr)
+	}
+}
+
+func testOverlap(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	user := uuid.New()
+	first := mustCreate(t, ctx, st, newSub(user, "Netflix", month(1), month(3)))
+
+	// Act
+	_, overlapErr := st.Create(ctx, newSub(user, "Netflix", month(3), month(5)))
+	_, openErr := st.Create(ctx, newSub(user, "Netflix", month(2), time.Time{}))
+	adjacent, adjacentErr := st.Create(ctx, newSub(user, "Netflix", month(4), month(5)))
+	_, otherServiceErr := st.Create(ctx, newSub(user, "Spotify", month(1), month(3)))
+	_, otherUserErr := st.Create(ctx, newSub(uuid.New(), "Netflix", month(1), month(3)))
+
+	moved := adjacent
+	moved.StartDate = month(3)
+	_, updateErr := st.Update(ctx, moved)
+
+	extended := first
+	end := month(3)
+	extended.EndDate = &end
+	_, sameRowErr := st.Update(ctx, extended)
+
+	// Assert
+	if !errors.Is(overlapErr, domain.ErrConflict) || !errors.Is(openErr, domain.ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v, %v", overlapErr, openErr)
+	}
+	if adjacentErr != nil || otherServiceErr != nil || otherUserErr != nil {
+		t.Errorf("unexpected errors: %v, %v, %v", adjacentErr, otherServiceErr, otherUserErr)
+	}
+	if !errors.Is(updateErr, domain.ErrConflict) {
+		t.Errorf("expected ErrConflict for update, got %v", updateErr)
+	}
+	if sameRowErr != nil {
+		t.Errorf("a row must not conflict with itself: %v", sameRowErr)
+	}
+}
+
+func testCreateMany(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	user := uuid.New()
+	batch := []domain.Subscription{
+		newSub(user, "Netflix", month(1), month(2)),
+		newSub(user, "Spotify", month(1), time.Time{}),
+	}
+	conflicting := []domain.Subscription{
+		newSub(user, "YouTube", month(1), month(2)),
+		newSub(user, "Netflix", month(2), month(4)),
+	}
+
+	// Act
+	n, err := st.CreateMany(ctx, batch)
+	_, conflictErr := st.CreateMany(ctx, conflicting)
+	listed, listErr := st.List(ctx, domain.ListFilter{UserID: &user})
+
+	// Assert
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d (%v)", n, err)
+	}
+	if !errors.Is(conflictErr, domain.ErrConflict) {
+		t.Errorf("expected ErrConflict, got %v", conflictErr)
+	}
+	if listErr != nil || len(listed) != 2 {
+		t.Errorf("expected only the first batch, got %v (%v)", ids(listed), listErr)
+	}
+}
+
+func testOverlapAllowed(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	user := uuid.New()
+	mustCreate(t, ctx, st, newSub(user, "Netflix", month(1), month(3)))
+	other := mustCreate(t, ctx, st, newSub(user, "Netflix", month(4), month(5)))
+
+	// Act
+	_, overlapErr := st.Create(ctx, newSub(user, "Netflix", month(3), month(5)))
+	_, openErr := st.Create(ctx, newSub(user, "Netflix", month(2), time.Time{}))
+
+	moved := other
+	moved.StartDate = month(2)
+	_, updateErr := st.Update(ctx, moved)
+
+	// Assert
+	if overlapErr != nil || openErr != nil || updateErr != nil {
+		t.Errorf("expected overlaps to be accepted, got %v, %v, %v", overlapErr, openErr, updateErr)
+	}
+}
+
+func testCreateManyOverlapping(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	user := uuid.New()
+	mustCreate(t, ctx, st, newSub(user, "Netflix", month(1), month(3)))
+	batch := []domain.Subscription{
+		newSub(user, "Netflix", month(2), month(4)),
+		newSub(user, "Netflix", month(3), time.Time{}),
+	}
+
+	// Act
+	n, err := st.CreateMany(ctx, batch)
+	listed, listErr := st.List(ctx, domain.ListFilter{UserID: &user})
+
+	// Assert
+	if err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got %d (%v)", n, err)
+	}
+	if listErr != nil || len(listed) != 3 {
+		t.Errorf("expected 3 subscriptions, got %v (%v)", ids(listed), listErr)
+	}
+}
+
+// ====================================
+// Queries
+// ====================================
+
+func testList(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	alice, bob := uuid.New(), uuid.New()
+	a1 := mustCreate(t, ctx, st, newSub(alice, "Netflix", month(1), time.Time{}))
+	time.Sleep(5 * time.Millisecond)
+	a2 := mustCreate(t, ctx, st, newSub(alice, "Spotify", month(1), time.Time{}))
+	time.Sleep(5 * time.Millisecond)
+	b1 := mustCreate(t, ctx, st, newSub(bob, "Netflix", month(1), time.Time{}))
+	netflix := "Netflix"
+
+	// Act
+	all, allErr := st.List(ctx, domain.ListFilter{})
+	byUser, userErr := st.List(ctx, domain.ListFilter{UserID: &alice})
+	byService, serviceErr := st.List(ctx, domain.ListFilter{ServiceName: &netflix})
+	both, bothErr := st.List(ctx, domain.ListFilter{UserID: &alice, ServiceName: &netflix})
+	anyUser, anyUserErr := st.List(ctx, domain.ListFilter{UserIDs: []uuid.UUID{bob, uuid.New()}})
+	anyService, anyServiceErr := st.List(ctx, domain.ListFilter{ServiceNames: []string{"Spotify", "Hulu"}})
+	none, noneErr := st.List(ctx, domain.ListFilter{UserIDs: []uuid.UUID{}})
+	after, afterErr := st.List(ctx, domain.ListFilter{After: &domain.Cursor{CreatedAt: a2.CreatedAt, ID: a2.ID}})
+
+	// Assert
+	if allErr != nil || userErr != nil || serviceErr != nil || bothErr != nil {
+		t.Fatalf("unexpected errors: %v, %v, %v, %v", allErr, userErr, serviceErr, bothErr)
+	}
+	if anyUserErr != nil || anyServiceErr != nil || noneErr != nil || afterErr != nil {
+		t.Fatalf("unexpected errors: %v, %v, %v, %v", anyUserErr, anyServiceErr, noneErr, afterErr)
+	}
+	if !sameIDs(all, b1, a2, a1) {
+		t.Errorf("expected newest first, got %v", ids(all))
+	}
+	if !sameIDs(byUser, a2, a1) {
+		t.Errorf("unexpected user filter result %v", ids(byUser))
+	}
+	if !sameIDs(byService, b1, a1) {
+		t.Errorf("unexpected service filter result %v", ids(byService))
+	}
+	if !sameIDs(both, a1) {
+		t.Errorf("unexpected combined filter result %v", ids(both))
+	}
+	if !sameIDs(anyUser, b1) || !sameIDs(anyService, a2) {
+		t.Errorf("unexpected any-of filter results %v and %v", ids(anyUser), ids(anyService))
+	}
+	if len(none) != 0 {
+		t.Errorf("expected an empty user list to match nothing, got %v", ids(none))
+	}
+	if !sameIDs(after, a1) {
+		t.Errorf("expected the rows after the cursor, got %v", ids(after))
+	}
+}
+
+func testListOverlapping(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	user := uuid.New()
+	early := mustCreate(t, ctx, st, newSub(user, "A", month(1), month(2)))
+	edge := mustCreate(t, ctx, st, newSub(user, "B", month(3), month(3)))
+	open := mustCreate(t, ctx, st, newSub(user, "C", month(2), time.Time{}))
+	mustCreate(t, ctx, st, newSub(user, "D", month(6), month(8)))
+	mustCreate(t, ctx, st, newSub(uuid.New(), "A", month(3), month(4)))
+	service := "C"
+
+	// Act
+	got, err := st.ListOverlapping(ctx, &user, nil, month(2), month(3), nil)
+	filtered, filterErr := st.ListOverlapping(ctx, &user, &service, month(2), month(3), nil)
+
+	// Assert
+	if err != nil || filterErr != nil {
+		t.Fatalf("unexpected errors: %v, %v", err, filterErr)
+	}
+	if !sameIDs(got, early, open, edge) {
+		t.Errorf("expected overlapping rows by start date, got %v", ids(got))
+	}
+	if !sameIDs(filtered, open) {
+		t.Errorf("unexpected service filter result %v", ids(filtered))
+	}
+}
+
+func testStream(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	user := uuid.New()
+	first := mustCreate(t, ctx, st, newSub(user, "Netflix", month(1), time.Time{}))
+	time.Sleep(5 * time.Millisecond)
+	second := mustCreate(t, ctx, st, newSub(user, "Spotify", month(1), time.Time{}))
+	stop := errors.New("stop")
+
+	// Act
+	var streamed []domain.Subscription
+	err := st.Stream(ctx, domain.ListFilter{UserID: &user}, func(s domain.Subscription) error {
+		streamed = append(streamed, s)
+		return nil
+	})
+	calls := 0
+	stopErr := st.Stream(ctx, domain.ListFilter{UserID: &user}, func(domain.Subscription) error {
+		calls++
+		return stop
+	})
+
+	// Assert
+	if err != nil || !sameIDs(streamed, second, first) {
+		t.Errorf("expected rows in list order, got %v (%v)", ids(streamed), err)
+	}
+	if !errors.Is(stopErr, stop) || calls != 1 {
+		t.Errorf("expected stream to stop after the first row, got %d calls (%v)", calls, stopErr)
+	}
+}
+
+func testAsOf(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	user := uuid.New()
+	created := mustCreate(t, ctx, st, newSub(user, "Netflix", month(1), time.Time{}))
+	time.Sleep(20 * time.Millisecond)
+
+	upd := created
+	upd.Price = 200
+	updated, err := st.Update(ctx, upd)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if err := st.Delete(ctx, created.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	// Points in time from the store's own clock
+	beforeCreate := created.CreatedAt.Add(-time.Millisecond)
+	betweenWrites := created.CreatedAt.Add(updated.UpdatedAt.Sub(created.CreatedAt) / 2)
+	afterUpdate := updated.UpdatedAt.Add(time.Millisecond)
+
+	// Act
+	original, origErr := st.GetByIDAsOf(ctx, created.ID, betweenWrites)
+	changed, changedErr := st.GetByIDAsOf(ctx, created.ID, afterUpdate)
+	_, missingErr := st.GetByIDAsOf(ctx, created.ID, beforeCreate)
+	listed, listErr := st.List(ctx, domain.ListFilter{UserID: &user, AsOf: &betweenWrites})
+	overlapping, overlapErr := st.ListOverlapping(ctx, &user, nil, month(1), month(12), &afterUpdate)
+	current, currentErr := st.List(ctx, domain.ListFilter{UserID: &user})
+
+	// Assert
+	if origErr != nil || original.Price != 100 {
+		t.Errorf("expected original price 100, got %d (%v)", original.Price, origErr)
+	}
+	if changedErr != nil || changed.Price != 200 {
+		t.Errorf("expected updated price 200, got %d (%v)", changed.Price, changedErr)
+	}
+	if !errors.Is(missingErr, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound before create, got %v", missingErr)
+	}
+	if listErr != nil || len(listed) != 1 || listed[0].Price != 100 {
+		t.Errorf("expected one past row, got %+v (%v)", listed, listErr)
+	}
+	if overlapErr != nil || len(overlapping) != 1 || overlapping[0].Price != 200 {
+		t.Errorf("expected the updated row, got %+v (%v)", overlapping, overlapErr)
+	}
+	if currentErr != nil || len(current) != 0 {
+		t.Errorf("expected no current rows, got %v (%v)", ids(current), currentErr)
+	}
+}
+
+// ====================================
+// Transactions and tenants
+// ====================================
+
+func testInTx(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	user := uuid.New()
+	committed := newSub(user, "Netflix", month(1), time.Time{})
+	rolledBack := newSub(user, "Spotify", month(1), time.Time{})
+	savepointed := newSub(user, "YouTube", month(1), time.Time{})
+	fail := errors.New("fail")
+
+	// Act
+	commitErr := st.InTx(ctx, func(tx domain.SubscriptionStore) error {
+		if _, err := tx.Create(ctx, committed); err != nil {
+			return err
+		}
+
+		// A failed savepoint only undoes its own writes
+		spErr := tx.InTx(ctx, func(sp domain.SubscriptionStore) error {
+			if _, err := sp.Create(ctx, savepointed); err != nil {
+				return err
+			}
+			return fail
+		})
+		if !errors.Is(spErr, fail) {
+			t.Errorf("expected savepoint error, got %v", spErr)
+		}
+
+		// Writes are visible inside the transaction
+		if _, err := tx.GetByID(ctx, committed.ID); err != nil {
+			t.Errorf("expected row inside transaction: %v", err)
+		}
+		return nil
+	})
+	rollbackErr := st.InTx(ctx, func(tx domain.SubscriptionStore) error {
+		if _, err := tx.Create(ctx, rolledBack); err != nil {
+			return err
+		}
+		return fail
+	})
+
+	_, committedErr := st.GetByID(ctx, committed.ID)
+	_, rolledBackErr := st.GetByID(ctx, rolledBack.ID)
+	_, savepointErr := st.GetByID(ctx, savepointed.ID)
+
+	// Assert
+	if commitErr != nil || !errors.Is(rollbackErr, fail) {
+		t.Fatalf("unexpected transaction results: %v, %v", commitErr, rollbackErr)
+	}
+	if committedErr != nil {
+		t.Errorf("expected committed row: %v", committedErr)
+	}
+	if !errors.Is(rolledBackErr, domain.ErrNotFound) || !errors.Is(savepointErr, domain.ErrNotFound) {
+		t.Errorf("expected rolled back rows to be missing, got %v, %v", rolledBackErr, savepointErr)
+	}
+}
+
+func testTenantIsolation(t *testing.T, ctx context.Context, st domain.SubscriptionStore) {
+	// Arrange
+	other := newTenant()
+	user := uuid.New()
+	s := mustCreate(t, ctx, st, newSub(user, "Netflix", month(1), time.Time{}))
+
+	// Act
+	_, getErr := st.GetByID(other, s.ID)
+	listed, listErr := st.List(other, domain.ListFilter{UserID: &user})
+	deleteErr := st.Delete(other, s.ID)
+	_, missingErr := st.GetByID(context.Background(), s.ID)
+
+	// Assert
+	if !errors.Is(getErr, domain.ErrNotFound) || !errors.Is(deleteErr, domain.ErrNotFound) {
+		t.Errorf("expected ErrNotFound across tenants, got %v, %v", getErr, deleteErr)
+	}
+	if listErr != nil || len(listed) != 0 {
+		t.Errorf("expected no rows across tenants, got %v (%v)", ids(listed), listErr)
+	}
+	if !errors.Is(missingErr, tenant.ErrMissing) {
+		t.Errorf("expected tenant.ErrMissing without tenant, got %v", missingErr)
+	}
+	if _, err := st.GetByID(ctx, s.ID); err != nil {
+		t.Errorf("expected row to survive foreign delete: %v", err)
+	}
+}
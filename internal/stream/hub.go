@@ -0,0 +1,204 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+)
+
+// ErrClosed is returned for subscriptions after the hub was closed.
+var ErrClosed = errors.New("stream closed")
+
+// Options configures a Hub; zero fields use the defaults.
+type Options struct {
+	Backlog    int           // messages replayed at most, default 1000
+	Buffer     int           // messages queued per subscriber, default 256
+	RetryDelay time.Duration // before listening again after a failure, default 1s
+}
+
+// withDefaults fills unset options.
+func (o Options) withDefaults() Options {
+	if o.Backlog <= 0 {
+		o.Backlog = 1000
+	}
+	if o.Buffer <= 0 {
+		o.Buffer = 256
+	}
+	if o.RetryDelay <= 0 {
+		o.RetryDelay = time.Second
+	}
+	return o
+}
+
+// Hub listens to a Source in the background and passes every message to
+// the subscriptions of its tenant that want it, until Shutdown.
+type Hub struct {
+	store  Store
+	source Source
+	opts   Options
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// NewHub starts a hub on source, replaying backlogs from store.
+func NewHub(store Store, source Source, opts Options) *Hub {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := &Hub{
+		store:   store,
+		source:  source,
+		opts:    opts.withDefaults(),
+		subs:    make(map[*Subscription]struct{}),
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+	go h.run(ctx)
+	return h
+}
+
+// Subscription receives the live messages of one tenant matching its
+// filter. C is closed when the subscription ends: on Close, on shutdown,
+// when the subscriber falls behind or messages may have been missed.
+// Subscribers then resume from the backlog.
+type Subscription struct {
+	C <-chan outbox.Message
+
+	c        chan outbox.Message
+	tenantID string
+	filter   Filter
+	hub      *Hub
+}
+
+// Subscribe starts receiving the messages of a tenant matching f.
+func (h *Hub) Subscribe(tenantID string, f Filter) (*Subscription, error) {
+	c := make(chan outbox.Message, h.opts.Buffer)
+	s := &Subscription{C: c, c: c, tenantID: tenantID, filter: f, hub: h}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, ErrClosed
+	}
+	h.subs[s] = struct{}{}
+
+	return s, nil
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.end(s)
+}
+
+// Backlog returns the retained messages of the tenant in ctx after
+// afterID that match f. When more than the backlog limit are retained
+// it returns none and false, as replaying only a part would skip changes.
+func (h *Hub) Backlog(ctx context.Context, afterID int64, f Filter) ([]outbox.Message, bool, error) {
+	msgs, err := h.store.Since(ctx, afterID, f, h.opts.Backlog+1)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(msgs) > h.opts.Backlog {
+		return nil, false, nil
+	}
+	return msgs, true, nil
+}
+
+// Close ends all subscriptions and refuses new ones, so open streams
+// finish before the server shuts down. Listening goes on until Shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for s := range h.subs {
+		h.end(s)
+	}
+}
+
+// Shutdown closes the hub and stops listening.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.Close()
+	h.cancel()
+
+	select {
+	case <-h.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run listens until Shutdown, listening again after failures.
+func (h *Hub) run(ctx context.Context) {
+	defer close(h.stopped)
+
+	for {
+		err := h.source.Listen(ctx, h.resync, h.dispatch)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Error("Stream: listening failed", "error", err)
+
+		select {
+		case <-time.After(h.opts.RetryDelay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// resync ends all subscriptions once listening (again), as they may have
+// missed messages before; their subscribers resume from the backlog.
+func (h *Hub) resync() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subs {
+		h.end(s)
+	}
+}
+
+// dispatch passes m to the subscriptions that want it.
+func (h *Hub) dispatch(m outbox.Message) {
+	subj, err := decodeSubject(m.Payload)
+	if err != nil {
+		slog.Warn("Stream: invalid message", "message_id", m.ID, "error", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for s := range h.subs {
+		if s.tenantID != m.TenantID || !s.filter.match(subj) {
+			continue
+		}
+		select {
+		case s.c <- m:
+		default:
+			// A subscriber too slow to keep up resumes from the backlog
+			h.end(s)
+		}
+	}
+}
+
+// end removes s and closes its channel once; h.mu must be held.
+func (h *Hub) end(s *Subscription) {
+	if _, ok := h.subs[s]; !ok {
+		return
+	}
+	delete(h.subs, s)
+	close(s.c)
+}
@@ -0,0 +1,241 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/google/uuid"
+)
+
+// fakeSource announces the messages sent on msgs until a value is sent
+// on fail, which makes the current Listen call fail.
+type fakeSource struct {
+	msgs      chan outbox.Message
+	fail      chan struct{}
+	listening chan struct{}
+	once      sync.Once
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		msgs:      make(chan outbox.Message),
+		fail:      make(chan struct{}),
+		listening: make(chan struct{}),
+	}
+}
+
+func (s *fakeSource) Listen(ctx context.Context, ready func(), fn func(outbox.Message)) error {
+	ready()
+	s.once.Do(func() { close(s.listening) })
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case m := <-s.msgs:
+			fn(m)
+		case <-s.fail:
+			return errors.New("connection lost")
+		}
+	}
+}
+
+// fakeStore returns the messages it holds after the requested ID.
+type fakeStore struct {
+	messages []outbox.Message
+}
+
+func (s *fakeStore) Since(_ context.Context, afterID int64, f Filter, limit int) ([]outbox.Message, error) {
+	var out []outbox.Message
+	for _, m := range s.messages {
+		subj, err := decodeSubject(m.Payload)
+		if err != nil {
+			return nil, err
+		}
+		if m.ID > afterID && f.match(subj) && len(out) < limit {
+			out = append(out, m)
+		}
+	}
+	return out, nil
+}
+
+// newHub starts a hub on a fake source and waits until it listens.
+func newHub(t *testing.T, store Store, opts Options) (*Hub, *fakeSource) {
+	t.Helper()
+
+	src := newFakeSource()
+	h := NewHub(store, src, opts)
+	t.Cleanup(func() { _ = h.Shutdown(context.Background()) })
+	<-src.listening
+
+	return h, src
+}
+
+// message returns a change of a subscription of user to service.
+func message(t *testing.T, id int64, tenantID string, user uuid.UUID, service string) outbox.Message {
+	t.Helper()
+
+	ev := domain.NewEvent(domain.EventSubscriptionUpdated, domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: service,
+		Price:       500,
+		UserID:      user,
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	payload, err := outbox.Encode(ev)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	return outbox.Message{
+		ID:             id,
+		TenantID:       tenantID,
+		EventID:        ev.ID,
+		Type:           ev.Type,
+		SubscriptionID: ev.Subscription.ID,
+		Payload:        payload,
+	}
+}
+
+// receive returns the next message of s, failing after a timeout.
+func receive(t *testing.T, s *Subscription) (outbox.Message, bool) {
+	t.Helper()
+	select {
+	case m, ok := <-s.C:
+		return m, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return outbox.Message{}, false
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// Hub
+// ==============================================================
+// ==============================================================
+func TestHub_DeliversMatchingMessages(t *testing.T) {
+	// Arrange
+	h, src := newHub(t, &fakeStore{}, Options{})
+	user := uuid.New()
+	netflix := "Netflix"
+
+	all, err := h.Subscribe("acme", Filter{})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	filtered, err := h.Subscribe("acme", Filter{UserID: &user, ServiceName: &netflix})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// Act
+	src.msgs <- message(t, 1, "other", user, "Netflix")
+	src.msgs <- message(t, 2, "acme", uuid.New(), "Netflix")
+	src.msgs <- message(t, 3, "acme", user, "Spotify")
+	src.msgs <- message(t, 4, "acme", user, "Netflix")
+
+	// Assert
+	for _, want := range []int64{2, 3, 4} {
+		if m, _ := receive(t, all); m.ID != want {
+			t.Errorf("expected message %d for the tenant, got %d", want, m.ID)
+		}
+	}
+	if m, _ := receive(t, filtered); m.ID != 4 {
+		t.Errorf("expected only message 4 for the filter, got %d", m.ID)
+	}
+}
+
+func TestHub_EndsSlowSubscriptions(t *testing.T) {
+	// Arrange
+	h, src := newHub(t, &fakeStore{}, Options{Buffer: 1})
+	sub, err := h.Subscribe("acme", Filter{})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// Act
+	src.msgs <- message(t, 1, "acme", uuid.New(), "Netflix")
+	src.msgs <- message(t, 2, "acme", uuid.New(), "Netflix")
+
+	// Assert
+	if m, ok := receive(t, sub); !ok || m.ID != 1 {
+		t.Fatalf("expected the buffered message 1, got %d", m.ID)
+	}
+	if _, ok := receive(t, sub); ok {
+		t.Error("expected the subscription to end once its buffer overflowed")
+	}
+}
+
+func TestHub_EndsSubscriptionsAfterReconnect(t *testing.T) {
+	// Arrange
+	h, src := newHub(t, &fakeStore{}, Options{RetryDelay: time.Millisecond})
+	sub, err := h.Subscribe("acme", Filter{})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// Act
+	src.fail <- struct{}{}
+
+	// Assert
+	if _, ok := receive(t, sub); ok {
+		t.Error("expected the subscription to end, it may have missed messages")
+	}
+}
+
+func TestHub_CloseEndsSubscriptions(t *testing.T) {
+	// Arrange
+	h, _ := newHub(t, &fakeStore{}, Options{})
+	sub, err := h.Subscribe("acme", Filter{})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+
+	// Act
+	h.Close()
+
+	// Assert
+	if _, ok := receive(t, sub); ok {
+		t.Error("expected the subscription to end")
+	}
+	if _, err := h.Subscribe("acme", Filter{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %v", err)
+	}
+	sub.Close() // closing again is a no-op
+}
+
+func TestHub_Backlog(t *testing.T) {
+	// Arrange
+	user := uuid.New()
+	store := &fakeStore{messages: []outbox.Message{
+		message(t, 1, "acme", user, "Netflix"),
+		message(t, 2, "acme", uuid.New(), "Netflix"),
+		message(t, 3, "acme", user, "Netflix"),
+		message(t, 4, "acme", user, "Netflix"),
+	}}
+	h, _ := newHub(t, store, Options{Backlog: 2})
+
+	// Act
+	mine, mineComplete, err := h.Backlog(context.Background(), 1, Filter{UserID: &user})
+	if err != nil {
+		t.Fatalf("backlog: %v", err)
+	}
+	all, allComplete, err := h.Backlog(context.Background(), 0, Filter{})
+	if err != nil {
+		t.Fatalf("backlog: %v", err)
+	}
+
+	// Assert
+	if !mineComplete || len(mine) != 2 || mine[0].ID != 3 || mine[1].ID != 4 {
+		t.Errorf("expected messages 3 and 4, got %v (complete %v)", mine, mineComplete)
+	}
+	if allComplete || all != nil {
+		t.Errorf("expected an incomplete backlog without messages, got %d", len(all))
+	}
+}
@@ -0,0 +1,63 @@
+// Package stream fans committed subscription changes out to long-lived
+// subscribers, such as Server-Sent Events clients. Changes are announced
+// by the database, so subscribers see the changes made by every instance.
+package stream
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/google/uuid"
+)
+
+// Filter narrows a subscription to the changes of one user or service.
+type Filter struct {
+	UserID      *uuid.UUID
+	ServiceName *string
+}
+
+// subject is the changed subscription as far as filters look at it.
+type subject struct {
+	UserID      uuid.UUID `json:"user_id"`
+	ServiceName string    `json:"service_name"`
+}
+
+// decodeSubject reads the subject from an event encoded by outbox.Encode.
+func decodeSubject(payload []byte) (subject, error) {
+	var ev struct {
+		Data subject `json:"data"`
+	}
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return subject{}, err
+	}
+	return ev.Data, nil
+}
+
+// match reports whether a change of s passes the filter.
+func (f Filter) match(s subject) bool {
+	if f.UserID != nil && *f.UserID != s.UserID {
+		return false
+	}
+	if f.ServiceName != nil && *f.ServiceName != s.ServiceName {
+		return false
+	}
+	return true
+}
+
+// Source announces outbox messages as their transactions commit.
+type Source interface {
+	// Listen calls ready once it receives announcements, then passes them
+	// to fn until ctx is done or the connection fails. Messages committed
+	// while not listening are missed.
+	Listen(ctx context.Context, ready func(), fn func(outbox.Message)) error
+}
+
+// Store reads the retained outbox of the tenant in ctx.
+type Store interface {
+	// Since returns up to limit messages with IDs above afterID that
+	// match f, oldest first. As IDs are not assigned in commit order, it
+	// may also return messages around afterID the subscriber already
+	// received; they carry the same event ID.
+	Since(ctx context.Context, afterID int64, f Filter, limit int) ([]outbox.Message, error)
+}
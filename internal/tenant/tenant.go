@@ -0,0 +1,82 @@
+// Package tenant carries the tenant of a request through the context.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+)
+
+// ErrMissing indicates that no tenant is set on the context.
+var ErrMissing = errors.New("tenant not set")
+
+// ErrInvalid indicates a malformed tenant ID.
+var ErrInvalid = errors.New("invalid tenant id")
+
+// Tenant resolution errors returned to clients.
+var (
+	ErrRequired = errors.New("tenant required")
+	ErrMismatch = errors.New("tenant not allowed for these credentials")
+	ErrUnbound  = errors.New("credentials are not bound to a tenant")
+)
+
+// idPattern restricts tenant IDs to short slugs.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// tenantKey is the context key for the tenant ID.
+type tenantKey struct{}
+
+// Validate checks the tenant ID format.
+func Validate(id string) error {
+	if !idPattern.MatchString(id) {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// WithID returns a context carrying the tenant ID.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, id)
+}
+
+// FromContext returns the tenant ID stored in ctx.
+func FromContext(ctx context.Context) (string, error) {
+	id, ok := ctx.Value(tenantKey{}).(string)
+	if !ok || id == "" {
+		return "", ErrMissing
+	}
+	return id, nil
+}
+
+// Resolve picks the tenant of a request. Authenticated callers get the
+// tenant bound to their credentials; the requested one may only repeat
+// it. Only unauthenticated requests, which pass when authentication is
+// disabled, choose the requested tenant, falling back to fallback.
+func Resolve(bound, requested, fallback string, authenticated bool) (string, error) {
+	requested = strings.TrimSpace(requested)
+
+	if authenticated {
+		if bound == "" {
+			return "", ErrUnbound
+		}
+		if requested != "" && requested != bound {
+			return "", ErrMismatch
+		}
+		return bound, nil
+	}
+
+	id := requested
+	if id == "" {
+		id = fallback
+	}
+	if id == "" {
+		return "", ErrRequired
+	}
+
+	if err := Validate(id); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
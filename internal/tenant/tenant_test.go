@@ -0,0 +1,77 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	for _, id := range []string{"acme", "ACME_corp-2", "a"} {
+		if err := Validate(id); err != nil {
+			t.Errorf("%q: unexpected error: %v", id, err)
+		}
+	}
+	for _, id := range []string{"", "acme corp", "acme;drop", string(make([]byte, 65))} {
+		if err := Validate(id); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%q: expected ErrInvalid, got %v", id, err)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	// Act
+	_, missingErr := FromContext(context.Background())
+	id, err := FromContext(WithID(context.Background(), "acme"))
+
+	// Assert
+	if !errors.Is(missingErr, ErrMissing) {
+		t.Errorf("expected ErrMissing, got %v", missingErr)
+	}
+	if err != nil || id != "acme" {
+		t.Errorf("expected acme, got %q (%v)", id, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		name          string
+		bound         string
+		requested     string
+		authenticated bool
+		expected      string
+		err           error
+	}{
+		{"default tenant", "", "", false, "default", nil},
+		{"requested tenant", "", "acme", false, "acme", nil},
+		{"bound tenant", "acme", "", true, "acme", nil},
+		{"bound tenant repeated in request", "acme", "acme", true, "acme", nil},
+		{"bound tenant overridden by request", "acme", "globex", true, "", ErrMismatch},
+		{"unbound credentials", "", "", true, "", ErrUnbound},
+		{"unbound credentials requesting a tenant", "", "acme", true, "", ErrUnbound},
+		{"invalid request", "", "acme corp", false, "", ErrInvalid},
+	}
+
+	for _, tc := range cases {
+		// Act
+		got, err := Resolve(tc.bound, tc.requested, "default", tc.authenticated)
+
+		// Assert
+		if !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.err, err)
+		}
+		if got != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.expected, got)
+		}
+	}
+}
+
+func TestResolve_NoDefault(t *testing.T) {
+	// Act
+	_, err := Resolve("", "", "", false)
+
+	// Assert
+	if !errors.Is(err, ErrRequired) {
+		t.Fatalf("expected ErrRequired, got %v", err)
+	}
+}
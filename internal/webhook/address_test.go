@@ -0,0 +1,103 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// ==============================================================
+// ==============================================================
+// Endpoint addresses
+// ==============================================================
+// ==============================================================
+func TestPublicAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"0.0.0.0", false},
+		{"100.64.0.1", false},
+		{"224.0.0.1", false},
+		{"::ffff:127.0.0.1", false},
+		{"64:ff9b::a9fe:a9fe", false},
+	}
+
+	for _, tt := range tests {
+		if got := PublicAddr(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("PublicAddr(%s) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url string
+		err error
+	}{
+		{"https://93.184.216.34/hook", nil},
+		{"http://127.0.0.1:9000/hook", ErrDisallowedAddress},
+		{"http://[::1]/hook", ErrDisallowedAddress},
+		{"http://169.254.169.254/latest/meta-data", ErrDisallowedAddress},
+		{"http://localhost:9000/hook", ErrDisallowedAddress},
+	}
+
+	for _, tt := range tests {
+		if err := CheckURL(context.Background(), tt.url); !errors.Is(err, tt.err) {
+			t.Errorf("CheckURL(%s) = %v, want %v", tt.url, err, tt.err)
+		}
+	}
+}
+
+func TestNewClient_RefusesInternalAddresses(t *testing.T) {
+	// Arrange
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("unexpected request")
+	}))
+	defer receiver.Close()
+
+	// Act
+	_, err := NewClient(time.Second, false).Post(receiver.URL, "application/json", nil)
+
+	// Assert
+	if !errors.Is(err, ErrDisallowedAddress) {
+		t.Errorf("expected ErrDisallowedAddress, got %v", err)
+	}
+}
+
+func TestNewClient_DoesNotFollowRedirects(t *testing.T) {
+	// Arrange
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/hook" {
+			t.Error("redirect followed")
+		}
+		http.Redirect(w, r, "http://169.254.169.254/latest/meta-data", http.StatusTemporaryRedirect)
+	}))
+	defer receiver.Close()
+
+	// Act
+	resp, err := NewClient(time.Second, true).Post(receiver.URL+"/hook", "application/json", nil)
+
+	// Assert
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusTemporaryRedirect {
+		t.Errorf("expected the redirect response, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,275 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/tenant"
+)
+
+// lifecycleInterval is how often started and ended events are looked for.
+const lifecycleInterval = time.Minute
+
+// Options configures a Dispatcher; zero fields use the defaults.
+type Options struct {
+	Interval    time.Duration // polling for due deliveries, default 5s
+	Timeout     time.Duration // per delivery request, default 10s
+	MaxAttempts int           // attempts before a delivery is dead, default 10
+	BatchSize   int           // deliveries claimed at once, default 50
+
+	// Backoff returns the delay after a failed attempt, default Backoff
+	Backoff func(attempt int) time.Duration
+
+	// AllowPrivateNetworks lets the default client reach loopback and
+	// private addresses, for local development
+	AllowPrivateNetworks bool
+
+	// Client sends the requests, default NewClient with Timeout
+	Client *http.Client
+}
+
+// withDefaults fills unset options.
+func (o Options) withDefaults() Options {
+	if o.Interval <= 0 {
+		o.Interval = 5 * time.Second
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = 10 * time.Second
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 10
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = 50
+	}
+	if o.Backoff == nil {
+		o.Backoff = Backoff
+	}
+	if o.Client == nil {
+		o.Client = NewClient(o.Timeout, o.AllowPrivateNetworks)
+	}
+	return o
+}
+
+// Dispatcher queues published events for the webhooks that want them
+// and delivers them in the background until Shutdown. It is an
+// outbox.Sink, so change events reach it once they are committed.
+type Dispatcher struct {
+	store Store
+	opts  Options
+
+	wake     chan struct{}
+	stop     chan struct{}
+	stopped  chan struct{}
+	stopOnce sync.Once
+	lastScan time.Time
+}
+
+// New starts a dispatcher on store.
+func New(store Store, opts Options) *Dispatcher {
+	d := &Dispatcher{
+		store:   store,
+		opts:    opts.withDefaults(),
+		wake:    make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go d.run()
+	return d
+}
+
+// Publish queues an outbox message for delivery to the webhooks of its
+// tenant. It returns once the deliveries are stored; sending happens later.
+func (d *Dispatcher) Publish(ctx context.Context, m outbox.Message) error {
+	ctx = tenant.WithID(ctx, m.TenantID)
+
+	n, err := d.store.Enqueue(ctx, m.EventID, m.Type, m.Payload)
+	if err != nil {
+		return err
+	}
+
+	// Deliver right away instead of at the next poll
+	if n > 0 {
+		select {
+		case d.wake <- struct{}{}:
+		default:
+		}
+	}
+
+	return nil
+}
+
+// Shutdown stops the dispatcher after the running attempts finish.
+// Deliveries left pending are sent after the next start.
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.stopOnce.Do(func() { close(d.stop) })
+
+	select {
+	case <-d.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run processes due deliveries on every poll or wake-up until Shutdown.
+func (d *Dispatcher) run() {
+	defer close(d.stopped)
+
+	ticker := time.NewTicker(d.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		case <-d.stop:
+			return
+		}
+		d.tick(context.Background())
+	}
+}
+
+// tick emits due lifecycle events and sends due deliveries of every tenant.
+func (d *Dispatcher) tick(ctx context.Context) {
+	tenants, err := d.store.Tenants(ctx)
+	if err != nil {
+		slog.Error("Webhooks: list tenants failed", "error", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	scan := now.Sub(d.lastScan) >= lifecycleInterval
+	if scan {
+		d.lastScan = now
+	}
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	for _, id := range tenants {
+		if d.stopping() {
+			return
+		}
+		tctx := tenant.WithID(ctx, id)
+
+		if scan {
+			if _, err := d.store.EnqueueLifecycle(tctx, month, outbox.Encode); err != nil {
+				slog.Error("Webhooks: lifecycle events failed", "tenant_id", id, "error", err)
+			}
+		}
+		d.deliverDue(tctx)
+	}
+}
+
+// deliverDue sends the due deliveries of the tenant in ctx, one batch
+// at a time and the deliveries of a batch in parallel.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	// Claims outlive the attempt, so a crashed worker's jobs are retried
+	lease := d.opts.Timeout + 30*time.Second
+
+	for !d.stopping() {
+		jobs, err := d.store.ClaimDue(ctx, d.opts.BatchSize, lease)
+		if err != nil {
+			slog.Error("Webhooks: claim deliveries failed", "error", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for _, job := range jobs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				a := d.attempt(ctx, job)
+				if err := d.store.RecordAttempt(ctx, job.Delivery.ID, a); err != nil {
+					slog.Error("Webhooks: record attempt failed",
+						"delivery_id", job.Delivery.ID, "error", err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(jobs) < d.opts.BatchSize {
+			return
+		}
+	}
+}
+
+// attempt sends one delivery and decides how to continue.
+func (d *Dispatcher) attempt(ctx context.Context, job Job) Attempt {
+	status, err := d.send(ctx, job)
+
+	var a Attempt
+	if status != 0 {
+		a.ResponseStatus = &status
+	}
+
+	n := job.Delivery.Attempts + 1
+	switch {
+	case err == nil:
+		a.Status = domain.DeliveryDelivered
+	case n >= d.opts.MaxAttempts:
+		a.Status = domain.DeliveryDead
+		slog.Warn("Webhooks: delivery dead",
+			"delivery_id", job.Delivery.ID, "attempts", n, "error", err)
+	default:
+		a.Status = domain.DeliveryPending
+		a.NextAttemptAt = time.Now().Add(d.opts.Backoff(n))
+	}
+	if err != nil {
+		msg := err.Error()
+		a.Error = &msg
+	}
+
+	return a
+}
+
+// send posts the signed payload and returns the response status.
+// Responses other than 2xx are errors.
+func (d *Dispatcher) send(ctx context.Context, job Job) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+
+	body := job.Delivery.Payload
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, job.Delivery.Event)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(job.Delivery.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(job.Secret, ts, body))
+
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode/100 != 2 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded %s", resp.Status)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// stopping reports whether Shutdown was called.
+func (d *Dispatcher) stopping() bool {
+	select {
+	case <-d.stop:
+		return true
+	default:
+		return false
+	}
+}
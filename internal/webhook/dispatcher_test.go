@@ -0,0 +1,327 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/DevSchmied/subscription-aggregation-service/internal/outbox"
+	"github.com/google/uuid"
+)
+
+// memStore keeps webhooks and deliveries of one tenant in memory.
+type memStore struct {
+	mu         sync.Mutex
+	webhooks   []domain.Webhook
+	deliveries []domain.WebhookDelivery
+}
+
+func (s *memStore) Enqueue(_ context.Context, eventID uuid.UUID, typ string, body []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, w := range s.webhooks {
+		if !w.Wants(typ) {
+			continue
+		}
+		s.deliveries = append(s.deliveries, domain.WebhookDelivery{
+			ID:            int64(len(s.deliveries) + 1),
+			WebhookID:     w.ID,
+			EventID:       eventID,
+			Event:         typ,
+			Payload:       body,
+			Status:        domain.DeliveryPending,
+			NextAttemptAt: time.Now(),
+		})
+		n++
+	}
+	return n, nil
+}
+
+func (s *memStore) EnqueueLifecycle(
+	context.Context,
+	time.Time,
+	func(domain.Event) ([]byte, error),
+) (int, error) {
+	return 0, nil
+}
+
+func (s *memStore) Tenants(context.Context) ([]string, error) {
+	return []string{"acme"}, nil
+}
+
+func (s *memStore) ClaimDue(_ context.Context, limit int, lease time.Duration) ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var jobs []Job
+	for i := range s.deliveries {
+		d := &s.deliveries[i]
+		if len(jobs) == limit || d.Status != domain.DeliveryPending || d.NextAttemptAt.After(time.Now()) {
+			continue
+		}
+		d.NextAttemptAt = time.Now().Add(lease)
+		for _, w := range s.webhooks {
+			if w.ID == d.WebhookID {
+				jobs = append(jobs, Job{Delivery: *d, URL: w.URL, Secret: w.Secret})
+			}
+		}
+	}
+	return jobs, nil
+}
+
+func (s *memStore) RecordAttempt(_ context.Context, id int64, a Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d := &s.deliveries[id-1]
+	d.Attempts++
+	d.Status = a.Status
+	d.ResponseStatus = a.ResponseStatus
+	d.LastError = a.Error
+	d.NextAttemptAt = a.NextAttemptAt
+	return nil
+}
+
+// delivery returns a copy of the delivery with id.
+func (s *memStore) delivery(id int64) domain.WebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deliveries[id-1]
+}
+
+// newDispatcher starts a fast-polling dispatcher for a webhook posting
+// to url with the given event filter.
+func newDispatcher(t *testing.T, url string, maxAttempts int, events ...string) (*Dispatcher, *memStore) {
+	t.Helper()
+
+	store := &memStore{webhooks: []domain.Webhook{{
+		ID:     uuid.New(),
+		URL:    url,
+		Secret: "s3cret-s3cret-s3cret",
+		Events: events,
+	}}}
+	d := New(store, Options{
+		Interval:             10 * time.Millisecond,
+		MaxAttempts:          maxAttempts,
+		Backoff:              func(int) time.Duration { return 0 },
+		AllowPrivateNetworks: true, // receivers listen on loopback
+	})
+	t.Cleanup(func() { _ = d.Shutdown(context.Background()) })
+
+	return d, store
+}
+
+// waitFor polls cond until it holds or the test times out.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// created returns the outbox message of a new subscription of tenant acme.
+func created(t *testing.T) outbox.Message {
+	t.Helper()
+
+	end := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	ev := domain.NewEvent(domain.EventSubscriptionCreated, domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		UserID:      uuid.New(),
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &end,
+	})
+	payload, err := outbox.Encode(ev)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	return outbox.Message{
+		ID:             1,
+		TenantID:       "acme",
+		EventID:        ev.ID,
+		Type:           ev.Type,
+		SubscriptionID: ev.Subscription.ID,
+		Payload:        payload,
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// Dispatcher
+// ==============================================================
+// ==============================================================
+func TestDispatcher_DeliversSignedPayload(t *testing.T) {
+	// Arrange
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	got := make(chan received, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- received{header: r.Header.Clone(), body: body}
+	}))
+	defer receiver.Close()
+
+	d, store := newDispatcher(t, receiver.URL, 3)
+	m := created(t)
+
+	// Act
+	if err := d.Publish(context.Background(), m); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	// Assert
+	var r received
+	select {
+	case r = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery received")
+	}
+
+	ts, err := strconv.ParseInt(r.header.Get(HeaderTimestamp), 10, 64)
+	if err != nil {
+		t.Fatalf("invalid timestamp header: %v", err)
+	}
+	if want := Sign("s3cret-s3cret-s3cret", ts, r.body); r.header.Get(HeaderSignature) != want {
+		t.Errorf("unexpected signature %q, want %q", r.header.Get(HeaderSignature), want)
+	}
+	if r.header.Get(HeaderEvent) != domain.EventSubscriptionCreated || r.header.Get(HeaderDelivery) != "1" {
+		t.Errorf("unexpected event headers: %v", r.header)
+	}
+
+	var p map[string]any
+	if err := json.Unmarshal(r.body, &p); err != nil {
+		t.Fatalf("invalid payload: %v", err)
+	}
+	data, _ := p["data"].(map[string]any)
+	if p["id"] != m.EventID.String() || p["type"] != domain.EventSubscriptionCreated {
+		t.Errorf("unexpected payload: %s", r.body)
+	}
+	if data["service_name"] != "Netflix" || data["start_date"] != "01-2025" || data["end_date"] != "06-2025" {
+		t.Errorf("unexpected subscription data: %s", r.body)
+	}
+
+	waitFor(t, func() bool { return store.delivery(1).Status == domain.DeliveryDelivered })
+}
+
+func TestDispatcher_RetriesFailedDeliveries(t *testing.T) {
+	// Arrange
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	d, store := newDispatcher(t, receiver.URL, 5)
+
+	// Act
+	if err := d.Publish(context.Background(), created(t)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	// Assert
+	waitFor(t, func() bool { return store.delivery(1).Status == domain.DeliveryDelivered })
+
+	got := store.delivery(1)
+	if got.Attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", got.Attempts)
+	}
+	if got.ResponseStatus == nil || *got.ResponseStatus != http.StatusOK {
+		t.Errorf("expected last response 200, got %v", got.ResponseStatus)
+	}
+}
+
+func TestDispatcher_DeadAfterMaxAttempts(t *testing.T) {
+	// Arrange
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	d, store := newDispatcher(t, receiver.URL, 3)
+
+	// Act
+	if err := d.Publish(context.Background(), created(t)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	// Assert
+	waitFor(t, func() bool { return store.delivery(1).Status == domain.DeliveryDead })
+
+	got := store.delivery(1)
+	if got.Attempts != 3 || got.LastError == nil {
+		t.Errorf("unexpected dead delivery: %+v", got)
+	}
+
+	// Dead deliveries are not retried
+	time.Sleep(50 * time.Millisecond)
+	if n := calls.Load(); n != 3 {
+		t.Errorf("expected 3 requests, got %d", n)
+	}
+}
+
+func TestDispatcher_SkipsUnwantedEvents(t *testing.T) {
+	// Arrange
+	receiver := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("unexpected delivery")
+	}))
+	defer receiver.Close()
+
+	d, store := newDispatcher(t, receiver.URL, 3, domain.EventSubscriptionDeleted)
+
+	// Act
+	err := d.Publish(context.Background(), created(t))
+
+	// Assert
+	if err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if len(store.deliveries) != 0 {
+		t.Errorf("expected no deliveries, got %d", len(store.deliveries))
+	}
+}
+
+// ==============================================================
+// ==============================================================
+// Backoff
+// ==============================================================
+// ==============================================================
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{5, 160 * time.Second},
+		{10, time.Hour},
+		{100, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Package webhook delivers subscription events to registered HTTP
+// endpoints, signed with HMAC-SHA256 and retried with exponential backoff.
+// Change events arrive through the outbox, started and ended events are
+// found by the dispatcher itself.
+package webhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/DevSchmied/subscription-aggregation-service/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Headers of every delivery request.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Store persists webhooks and their deliveries for the tenant in ctx.
+type Store interface {
+	// Enqueue adds a pending delivery of an event to every webhook that
+	// wants it and returns the number of deliveries. Events already
+	// enqueued are skipped.
+	Enqueue(ctx context.Context, eventID uuid.UUID, typ string, payload []byte) (int, error)
+
+	// EnqueueLifecycle emits the started and ended events due by month,
+	// once per subscription, and enqueues them like Enqueue.
+	EnqueueLifecycle(
+		ctx context.Context,
+		month time.Time,
+		encode func(domain.Event) ([]byte, error),
+	) (int, error)
+
+	// Tenants returns the tenants that have webhooks, ignoring ctx's tenant.
+	Tenants(ctx context.Context) ([]string, error)
+
+	// ClaimDue returns up to limit pending deliveries that are due and
+	// postpones them by lease, so other workers skip them meanwhile.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+
+	// RecordAttempt stores the outcome of one delivery attempt.
+	RecordAttempt(ctx context.Context, id int64, a Attempt) error
+}
+
+// Job is a claimed delivery with the endpoint to send it to.
+type Job struct {
+	Delivery domain.WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+// Attempt is the outcome of one delivery attempt.
+type Attempt struct {
+	Status         string // pending to retry at NextAttemptAt, delivered or dead
+	ResponseStatus *int
+	Error          *string
+	NextAttemptAt  time.Time
+}
+
+// Sign returns the signature header value of a body sent at timestamp,
+// "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>".
+// Including the timestamp lets receivers reject replayed requests.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Backoff returns the delay before retrying after the given failed
+// attempt: 10s doubled for every further attempt, at most one hour.
+func Backoff(attempt int) time.Duration {
+	const (
+		base     = 10 * time.Second
+		maxDelay = time.Hour
+	)
+
+	d := base
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxDelay {
+			return maxDelay
+		}
+	}
+	return d
+}
+
+// secretPrefix marks secrets generated by this service.
+const secretPrefix = "whsec_"
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate webhook secret: %w", err)
+	}
+	return secretPrefix + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,166 @@
+// Package xlsx provides a minimal streaming writer for single-sheet XLSX files.
+//
+// Rows are written straight into the zip stream using inline strings,
+// so memory use does not depend on the number of rows.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Cell is a single worksheet cell value.
+type Cell struct {
+	value    string
+	isNumber bool
+}
+
+// String returns a text cell.
+func String(s string) Cell {
+	return Cell{value: s}
+}
+
+// Int returns a numeric cell.
+func Int(n int) Cell {
+	return Cell{value: strconv.Itoa(n), isNumber: true}
+}
+
+// Writer streams rows of one worksheet into an XLSX archive.
+type Writer struct {
+	zw    *zip.Writer
+	sheet *bufio.Writer
+	row   int
+	err   error
+}
+
+// Static package parts required by spreadsheet applications.
+const (
+	contentTypesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	rootRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	workbookRelsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	workbookXMLFormat = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	sheetHeaderXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+
+	sheetFooterXML = `</sheetData></worksheet>`
+)
+
+// NewWriter writes the package parts and opens the worksheet for rows.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	var name strings.Builder
+	if err := xml.EscapeText(&name, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+
+	parts := []struct {
+		path string
+		body string
+	}{
+		{"[Content_Types].xml", contentTypesXML},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", fmt.Sprintf(workbookXMLFormat, name.String())},
+		{"xl/_rels/workbook.xml.rels", workbookRelsXML},
+	}
+
+	for _, p := range parts {
+		f, err := zw.Create(p.path)
+		if err != nil {
+			return nil, fmt.Errorf("create %s: %w", p.path, err)
+		}
+		if _, err := io.WriteString(f, p.body); err != nil {
+			return nil, fmt.Errorf("write %s: %w", p.path, err)
+		}
+	}
+
+	// Worksheet is the last part, so rows can be streamed into it
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, fmt.Errorf("create sheet: %w", err)
+	}
+
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(sheetHeaderXML); err != nil {
+		return nil, err
+	}
+
+	return &Writer{zw: zw, sheet: sheet}, nil
+}
+
+// WriteRow appends one row to the worksheet.
+func (w *Writer) WriteRow(cells ...Cell) error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.row++
+	fmt.Fprintf(w.sheet, `<row r="%d">`, w.row)
+
+	for _, c := range cells {
+		if c.isNumber {
+			fmt.Fprintf(w.sheet, `<c t="n"><v>%s</v></c>`, c.value)
+			continue
+		}
+		w.sheet.WriteString(`<c t="inlineStr"><is><t>`)
+		if err := xml.EscapeText(w.sheet, []byte(c.value)); err != nil {
+			w.err = err
+			return err
+		}
+		w.sheet.WriteString(`</t></is></c>`)
+	}
+
+	_, w.err = w.sheet.WriteString(`</row>`)
+	return w.err
+}
+
+// Flush pushes buffered rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		w.err = err
+		return err
+	}
+	w.err = w.zw.Flush()
+	return w.err
+}
+
+// Close finishes the worksheet and the archive.
+func (w *Writer) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if _, err := w.sheet.WriteString(sheetFooterXML); err != nil {
+		return err
+	}
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+	return w.zw.Close()
+}
@@ -0,0 +1,67 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+// readPart returns the content of a file inside the archive.
+func readPart(t *testing.T, data []byte, name string) string {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open zip: %v", err)
+	}
+
+	f, err := zr.Open(name)
+	if err != nil {
+		t.Fatalf("open %s: %v", name, err)
+	}
+	defer f.Close()
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read %s: %v", name, err)
+	}
+	return string(b)
+}
+
+func TestWriter_WritesRows(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, "Subscriptions")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Act
+	_ = w.WriteRow(String("service_name"), String("price"))
+	_ = w.WriteRow(String("Tom & Jerry <HD>"), Int(500))
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+
+	// Assert
+	sheet := readPart(t, buf.Bytes(), "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheet, `<row r="2">`) {
+		t.Errorf("expected second row, got %s", sheet)
+	}
+	if !strings.Contains(sheet, "Tom &amp; Jerry &lt;HD&gt;") {
+		t.Errorf("expected escaped text, got %s", sheet)
+	}
+	if !strings.Contains(sheet, `<c t="n"><v>500</v></c>`) {
+		t.Errorf("expected numeric cell, got %s", sheet)
+	}
+	if !strings.HasSuffix(sheet, "</sheetData></worksheet>") {
+		t.Errorf("expected closed worksheet, got %s", sheet)
+	}
+
+	workbook := readPart(t, buf.Bytes(), "xl/workbook.xml")
+	if !strings.Contains(workbook, `name="Subscriptions"`) {
+		t.Errorf("expected sheet name, got %s", workbook)
+	}
+}
@@ -0,0 +1,3 @@
+-- The overlap constraint is kept per deployment by DB_OVERLAP_CONSTRAINT
+-- after every migration run, outside of the versioned migrations
+CREATE EXTENSION IF NOT EXISTS btree_gist;
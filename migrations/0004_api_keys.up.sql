@@ -0,0 +1,15 @@
+-- Keys act as a single user unless explicitly issued as admin
+CREATE TABLE api_keys (
+    id uuid PRIMARY KEY,
+    name text NOT NULL,
+    prefix text NOT NULL,
+    key_hash text NOT NULL UNIQUE,
+    scopes text[] NOT NULL DEFAULT '{}',
+    admin boolean NOT NULL DEFAULT false,
+    user_id uuid NULL,
+    expires_at timestamptz NULL,
+    last_used_at timestamptz NULL,
+    revoked_at timestamptz NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    CONSTRAINT api_keys_admin_or_user CHECK (admin OR user_id IS NOT NULL)
+);
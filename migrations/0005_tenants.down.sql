@@ -0,0 +1,10 @@
+ALTER TABLE api_keys DROP COLUMN IF EXISTS tenant_id;
+
+DROP POLICY IF EXISTS subscriptions_tenant_isolation ON subscriptions;
+
+ALTER TABLE subscriptions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_subscriptions_tenant_user;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,21 @@
+-- Existing rows belong to the default tenant
+ALTER TABLE subscriptions
+    ADD COLUMN tenant_id text NOT NULL DEFAULT 'default';
+
+-- New rows take the tenant set by the application for the transaction
+ALTER TABLE subscriptions
+    ALTER COLUMN tenant_id SET DEFAULT current_setting('app.tenant_id', true);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_tenant_user
+    ON subscriptions(tenant_id, user_id);
+
+ALTER TABLE subscriptions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY subscriptions_tenant_isolation ON subscriptions
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- API keys may be bound to a single tenant
+ALTER TABLE api_keys
+    ADD COLUMN tenant_id text NULL;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS subscription_audit (
+    id              bigserial PRIMARY KEY,
+    tenant_id       text NOT NULL DEFAULT current_setting('app.tenant_id', true),
+    subscription_id uuid NOT NULL,
+    user_id         uuid NOT NULL,
+    action          text NOT NULL CHECK (action IN ('create', 'update', 'delete')),
+    actor           text NOT NULL,
+    request_id      text NOT NULL DEFAULT '',
+    before          jsonb NULL,
+    after           jsonb NULL,
+    created_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_audit_subscription
+    ON subscription_audit(subscription_id, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_subscription_audit_tenant_created
+    ON subscription_audit(tenant_id, created_at);
+
+ALTER TABLE subscription_audit ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_audit FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY subscription_audit_tenant_isolation ON subscription_audit
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS subscriptions_versioning ON subscriptions;
+DROP FUNCTION IF EXISTS subscriptions_versioning();
+
+DROP TABLE IF EXISTS subscriptions_history;
+
+ALTER TABLE subscriptions DROP COLUMN IF EXISTS valid_from;
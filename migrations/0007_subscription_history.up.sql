@@ -0,0 +1,66 @@
+-- System time since which the current row version is valid.
+-- History starts with this migration: existing rows are valid since their last update.
+ALTER TABLE subscriptions
+    ADD COLUMN valid_from timestamptz NULL;
+
+UPDATE subscriptions SET valid_from = updated_at;
+
+ALTER TABLE subscriptions
+    ALTER COLUMN valid_from SET NOT NULL,
+    ALTER COLUMN valid_from SET DEFAULT now();
+
+-- Superseded and deleted row versions
+CREATE TABLE IF NOT EXISTS subscriptions_history (
+    id           uuid NOT NULL,
+    tenant_id    text NOT NULL,
+    service_name text NOT NULL,
+    price        integer NOT NULL,
+    user_id      uuid NOT NULL,
+    start_date   date NOT NULL,
+    end_date     date NULL,
+    created_at   timestamptz NOT NULL,
+    updated_at   timestamptz NOT NULL,
+    valid_from   timestamptz NOT NULL,
+    valid_to     timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_history_id
+    ON subscriptions_history(id, valid_from, valid_to);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_history_tenant_period
+    ON subscriptions_history(tenant_id, valid_from, valid_to);
+
+ALTER TABLE subscriptions_history ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscriptions_history FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY subscriptions_history_tenant_isolation ON subscriptions_history
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- Move the previous version to history on every update and delete
+CREATE OR REPLACE FUNCTION subscriptions_versioning() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        INSERT INTO subscriptions_history (
+            id, tenant_id, service_name, price, user_id, start_date, end_date,
+            created_at, updated_at, valid_from, valid_to
+        )
+        VALUES (
+            OLD.id, OLD.tenant_id, OLD.service_name, OLD.price, OLD.user_id,
+            OLD.start_date, OLD.end_date, OLD.created_at, OLD.updated_at,
+            OLD.valid_from, now()
+        );
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+
+    NEW.valid_from := now();
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER subscriptions_versioning
+    BEFORE INSERT OR UPDATE OR DELETE ON subscriptions
+    FOR EACH ROW EXECUTE FUNCTION subscriptions_versioning();
@@ -0,0 +1 @@
+DROP FUNCTION IF EXISTS subscription_metrics();
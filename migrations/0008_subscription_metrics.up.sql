@@ -0,0 +1,33 @@
+-- Group role of the service. Row-level security does not apply to
+-- superusers, table owners or roles with BYPASSRLS, so the service logs
+-- in as a member of this role instead; migration 0012_app_role grants it
+-- the tables.
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT FROM pg_roles WHERE rolname = 'subscriptions_app') THEN
+        CREATE ROLE subscriptions_app NOLOGIN NOSUPERUSER NOBYPASSRLS;
+    END IF;
+END
+$$;
+
+-- Aggregated counts for the metrics endpoint across all tenants.
+-- SECURITY DEFINER runs with the rights of the migration role, which must
+-- bypass row-level security (superuser or BYPASSRLS); this holds for
+-- every SECURITY DEFINER function of the schema. Only the service role
+-- may call it.
+CREATE OR REPLACE FUNCTION subscription_metrics()
+RETURNS TABLE (tenant_id text, active bigint)
+LANGUAGE sql
+STABLE
+SECURITY DEFINER
+SET search_path = public
+AS $$
+    SELECT s.tenant_id, count(*)
+    FROM subscriptions s
+    WHERE s.start_date <= date_trunc('month', now())::date
+      AND (s.end_date IS NULL OR s.end_date >= date_trunc('month', now())::date)
+    GROUP BY s.tenant_id;
+$$;
+
+REVOKE ALL ON FUNCTION subscription_metrics() FROM PUBLIC;
+GRANT EXECUTE ON FUNCTION subscription_metrics() TO subscriptions_app;
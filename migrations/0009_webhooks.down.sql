@@ -0,0 +1,4 @@
+DROP FUNCTION IF EXISTS webhook_tenants();
+DROP TABLE IF EXISTS subscription_lifecycle_events;
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
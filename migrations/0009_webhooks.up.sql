@@ -0,0 +1,78 @@
+CREATE TABLE IF NOT EXISTS webhooks (
+    id         uuid PRIMARY KEY,
+    tenant_id  text NOT NULL DEFAULT current_setting('app.tenant_id', true),
+    url        text NOT NULL,
+    secret     text NOT NULL,
+    events     text[] NOT NULL DEFAULT '{}', -- empty for every event
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE webhooks ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhooks FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY webhooks_tenant_isolation ON webhooks
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id              bigserial PRIMARY KEY,
+    tenant_id       text NOT NULL DEFAULT current_setting('app.tenant_id', true),
+    webhook_id      uuid NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+    event_id        uuid NOT NULL,
+    event           text NOT NULL,
+    payload         jsonb NOT NULL,
+    status          text NOT NULL DEFAULT 'pending'
+                    CHECK (status IN ('pending', 'delivered', 'dead')),
+    attempts        integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    response_status integer NULL,
+    last_error      text NULL,
+    delivered_at    timestamptz NULL,
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    UNIQUE (webhook_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_due
+    ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook
+    ON webhook_deliveries(webhook_id, created_at);
+
+ALTER TABLE webhook_deliveries ENABLE ROW LEVEL SECURITY;
+ALTER TABLE webhook_deliveries FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY webhook_deliveries_tenant_isolation ON webhook_deliveries
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- Started and ended events already emitted, at most one per subscription
+-- and event.
+CREATE TABLE IF NOT EXISTS subscription_lifecycle_events (
+    tenant_id       text NOT NULL DEFAULT current_setting('app.tenant_id', true),
+    subscription_id uuid NOT NULL,
+    event           text NOT NULL,
+    created_at      timestamptz NOT NULL DEFAULT now(),
+    PRIMARY KEY (subscription_id, event)
+);
+
+ALTER TABLE subscription_lifecycle_events ENABLE ROW LEVEL SECURITY;
+ALTER TABLE subscription_lifecycle_events FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY subscription_lifecycle_events_tenant_isolation ON subscription_lifecycle_events
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- Tenants with registered webhooks, for the delivery worker that runs
+-- outside of any request.
+CREATE OR REPLACE FUNCTION webhook_tenants()
+RETURNS SETOF text
+LANGUAGE sql
+STABLE
+SECURITY DEFINER
+SET search_path = public
+AS $$
+    SELECT DISTINCT w.tenant_id FROM webhooks w;
+$$;
+
+REVOKE ALL ON FUNCTION webhook_tenants() FROM PUBLIC;
+GRANT EXECUTE ON FUNCTION webhook_tenants() TO subscriptions_app;
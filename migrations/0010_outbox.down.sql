@@ -0,0 +1,3 @@
+DROP FUNCTION IF EXISTS outbox_purge(timestamptz);
+DROP FUNCTION IF EXISTS outbox_tenants();
+DROP TABLE IF EXISTS outbox;
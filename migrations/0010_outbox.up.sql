@@ -0,0 +1,57 @@
+-- Subscription change events, written in the transaction of the change
+-- and published by the relay. Within a subscription, ids follow commit
+-- order because its changes lock the subscription row.
+CREATE TABLE IF NOT EXISTS outbox (
+    id              bigserial PRIMARY KEY,
+    tenant_id       text NOT NULL DEFAULT current_setting('app.tenant_id', true),
+    event_id        uuid NOT NULL UNIQUE,
+    event           text NOT NULL,
+    subscription_id uuid NOT NULL,
+    payload         jsonb NOT NULL,
+    attempts        integer NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    last_error      text NULL,
+    sent_at         timestamptz NULL,
+    created_at      timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_unsent
+    ON outbox(subscription_id, id) WHERE sent_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_outbox_sent
+    ON outbox(sent_at) WHERE sent_at IS NOT NULL;
+
+ALTER TABLE outbox ENABLE ROW LEVEL SECURITY;
+ALTER TABLE outbox FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY outbox_tenant_isolation ON outbox
+    USING (tenant_id = current_setting('app.tenant_id', true))
+    WITH CHECK (tenant_id = current_setting('app.tenant_id', true));
+
+-- Tenants with unsent events, for the relay that runs outside of any
+-- request.
+CREATE OR REPLACE FUNCTION outbox_tenants()
+RETURNS SETOF text
+LANGUAGE sql
+STABLE
+SECURITY DEFINER
+SET search_path = public
+AS $$
+    SELECT DISTINCT o.tenant_id FROM outbox o WHERE o.sent_at IS NULL;
+$$;
+
+-- Removes events sent before the given time across all tenants.
+CREATE OR REPLACE FUNCTION outbox_purge(sent_before timestamptz)
+RETURNS bigint
+LANGUAGE sql
+SECURITY DEFINER
+SET search_path = public
+AS $$
+    WITH deleted AS (
+        DELETE FROM outbox o WHERE o.sent_at < sent_before RETURNING 1
+    )
+    SELECT count(*) FROM deleted;
+$$;
+
+REVOKE ALL ON FUNCTION outbox_tenants(), outbox_purge(timestamptz) FROM PUBLIC;
+GRANT EXECUTE ON FUNCTION outbox_tenants(), outbox_purge(timestamptz) TO subscriptions_app;
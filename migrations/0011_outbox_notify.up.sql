@@ -0,0 +1,35 @@
+-- Announces every committed outbox event on the outbox_events channel,
+-- so all instances can stream changes to their clients. NOTIFY payloads
+-- are limited to 8000 bytes; larger events are announced without their
+-- payload and loaded by the listener.
+CREATE OR REPLACE FUNCTION outbox_notify()
+RETURNS trigger
+LANGUAGE plpgsql
+AS $$
+DECLARE
+    msg text;
+BEGIN
+    msg := json_build_object(
+        'id', NEW.id,
+        'tenant_id', NEW.tenant_id,
+        'event_id', NEW.event_id,
+        'event', NEW.event,
+        'subscription_id', NEW.subscription_id,
+        'created_at', NEW.created_at,
+        'payload', NEW.payload
+    )::text;
+
+    IF octet_length(msg) > 7900 THEN
+        msg := json_build_object('id', NEW.id, 'tenant_id', NEW.tenant_id)::text;
+    END IF;
+
+    PERFORM pg_notify('outbox_events', msg);
+    RETURN NULL;
+END;
+$$;
+
+DROP TRIGGER IF EXISTS outbox_notify ON outbox;
+
+CREATE TRIGGER outbox_notify
+    AFTER INSERT ON outbox
+    FOR EACH ROW EXECUTE FUNCTION outbox_notify();
@@ -0,0 +1,7 @@
+-- The role is shared by all databases of the cluster, only revoke the
+-- privileges on this one
+REVOKE ALL ON subscriptions, api_keys, subscription_audit, subscriptions_history,
+    webhooks, webhook_deliveries, subscription_lifecycle_events, outbox,
+    schema_migrations FROM subscriptions_app;
+REVOKE ALL ON SEQUENCE subscription_audit_id_seq, webhook_deliveries_id_seq, outbox_id_seq
+    FROM subscriptions_app;
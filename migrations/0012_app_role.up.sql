@@ -0,0 +1,10 @@
+-- Privileges of the group role created by 0008_subscription_metrics
+GRANT SELECT, INSERT, UPDATE, DELETE ON subscriptions, api_keys TO subscriptions_app;
+GRANT SELECT, INSERT ON subscription_audit, subscriptions_history TO subscriptions_app;
+GRANT USAGE ON SEQUENCE subscription_audit_id_seq TO subscriptions_app;
+GRANT SELECT, INSERT, UPDATE, DELETE ON webhooks, webhook_deliveries TO subscriptions_app;
+GRANT SELECT, INSERT ON subscription_lifecycle_events TO subscriptions_app;
+GRANT USAGE ON SEQUENCE webhook_deliveries_id_seq TO subscriptions_app;
+GRANT SELECT, INSERT, UPDATE ON outbox TO subscriptions_app;
+GRANT USAGE ON SEQUENCE outbox_id_seq TO subscriptions_app;
+GRANT SELECT ON schema_migrations TO subscriptions_app;
@@ -0,0 +1,9 @@
+// Package migrations embeds the SQL migrations into the binary.
+package migrations
+
+import "embed"
+
+// FS holds the NNNN_name.up.sql and NNNN_name.down.sql files.
+//
+//go:embed *.sql
+var FS embed.FS